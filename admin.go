@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// 管理API（/admin/*）: アップデートの確認・適用・ロールバック
+// admin.token を設定した場合のみ有効。Authorization: Bearer <token> で認証する
+
+// scheduleRestart - 応答を返した後にジョブの完了を待って終了（テストで差し替え可能）
+var scheduleRestart = func() { go restartForUpdate() }
+
+// adminRequest - メソッドと管理トークンを確認（失敗時はエラーレスポンスを返してfalse）
+func adminRequest(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		writeMethodNotAllowed(w, r, method)
+		return false
+	}
+
+	token := currentConfig().Admin.Token
+	if token == "" {
+		writeAPIError(w, r, http.StatusForbidden, ErrCodeAdminDisabled,
+			"Admin API is disabled; set admin.token in the configuration file", nil)
+		return false
+	}
+
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(given)), []byte(token)) != 1 {
+		writeRequestLog(r, "WARN", fmt.Sprintf("管理APIの認証に失敗 from %s", r.RemoteAddr))
+		w.Header().Set("WWW-Authenticate", `Bearer realm="print_pdf"`)
+		writeAPIError(w, r, http.StatusUnauthorized, ErrCodeUnauthorized, "Missing or invalid bearer token", nil)
+		return false
+	}
+	return true
+}
+
+// writeUpdateError - アップデート処理のエラーをAPIエラーに変換
+func writeUpdateError(w http.ResponseWriter, r *http.Request, err error) {
+	writeRequestLog(r, "ERROR", fmt.Sprintf("管理API: %v", err))
+	switch {
+	case errors.Is(err, errUpdateInProgress):
+		writeAPIError(w, r, http.StatusConflict, ErrCodeUpdateInProgress, "An update or rollback is already in progress", nil)
+	case errors.Is(err, errNoUpdateAvailable):
+		writeAPIError(w, r, http.StatusConflict, ErrCodeNoUpdateAvailable, "No newer release is available", map[string]string{"currentVersion": Version})
+	case errors.Is(err, errDevBuild):
+		writeAPIError(w, r, http.StatusConflict, ErrCodeNoUpdateAvailable, "Development builds cannot be updated", map[string]string{"currentVersion": Version})
+	case errors.Is(err, errSelfUpdateUnsupported):
+		writeAPIError(w, r, http.StatusConflict, ErrCodeNoUpdateAvailable, "Self-update is not supported on this platform", nil)
+	case errors.Is(err, errReleaseNotFound):
+		writeAPIError(w, r, http.StatusNotFound, ErrCodeReleaseNotFound, "Release not found", nil)
+	case errors.Is(err, errNoRollback):
+		writeAPIError(w, r, http.StatusConflict, ErrCodeNoRollback, "No previous version is available to roll back to", nil)
+	default:
+		writeAPIError(w, r, http.StatusBadGateway, ErrCodeUpdateFailed, "Update failed", map[string]string{"error": err.Error()})
+	}
+}
+
+// adminUpdateStatusHandler - GET /admin/update
+func adminUpdateStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if !adminRequest(w, r, "GET") {
+		return
+	}
+	writeJSON(w, http.StatusOK, updates.Status())
+}
+
+// adminUpdateCheckHandler - POST /admin/update/check
+func adminUpdateCheckHandler(w http.ResponseWriter, r *http.Request) {
+	if !adminRequest(w, r, "POST") {
+		return
+	}
+	writeRequestLog(r, "INFO", "管理API: アップデートを確認します")
+	if err := updates.Check(r.Context()); err != nil {
+		writeUpdateError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updates.Status())
+}
+
+// adminUpdateApplyHandler - POST /admin/update/apply
+// 展開と置換用スクリプトの起動まで行い、202を返してからジョブの完了を待って再起動する
+func adminUpdateApplyHandler(w http.ResponseWriter, r *http.Request) {
+	if !adminRequest(w, r, "POST") {
+		return
+	}
+	body, ok := readRequestBody(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateApplyRequest
+	if len(bytes.TrimSpace(body)) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			writeInvalidJSON(w, r, err)
+			return
+		}
+	}
+	if req.Version != "" {
+		if _, err := parseSemVer(req.Version); err != nil {
+			writeAPIError(w, r, http.StatusUnprocessableEntity, ErrCodeValidationFailed, "Request validation failed",
+				[]FieldError{{Field: "version", Message: "must be a semantic version such as v1.2.3"}})
+			return
+		}
+	}
+
+	writeRequestLog(r, "INFO", fmt.Sprintf("管理API: アップデートを適用します (version=%q)", req.Version))
+	release, err := updates.Apply(r.Context(), req.Version)
+	if err != nil {
+		writeUpdateError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, UpdateActionResponse{
+		Status:  "accepted",
+		Action:  "apply",
+		Version: release.Version,
+		Message: fmt.Sprintf("Update to %s staged; the service restarts after pending jobs finish", release.Version),
+	})
+	scheduleRestart()
+}
+
+// adminUpdateRollbackHandler - POST /admin/update/rollback
+// 前回のアップデートで残した実行ファイル（.old）に戻して再起動する
+func adminUpdateRollbackHandler(w http.ResponseWriter, r *http.Request) {
+	if !adminRequest(w, r, "POST") {
+		return
+	}
+	writeRequestLog(r, "INFO", "管理API: 前のバージョンにロールバックします")
+	if err := updates.Rollback(); err != nil {
+		writeUpdateError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, UpdateActionResponse{
+		Status:  "accepted",
+		Action:  "rollback",
+		Message: "Rollback staged; the service restarts after pending jobs finish",
+	})
+	scheduleRestart()
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// adminRequestRecorder - 管理APIを呼び出す
+func adminRequestRecorder(method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	requestIDMiddleware(newServeMux()).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminAuthentication(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured string
+		token      string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "admin disabled", configured: "", token: "secret", wantStatus: http.StatusForbidden, wantCode: ErrCodeAdminDisabled},
+		{name: "missing token", configured: "secret", wantStatus: http.StatusUnauthorized, wantCode: ErrCodeUnauthorized},
+		{name: "wrong token", configured: "secret", token: "guess", wantStatus: http.StatusUnauthorized, wantCode: ErrCodeUnauthorized},
+		{name: "valid token", configured: "secret", token: "secret", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withUpdateEnv(t, "v1.1.0")
+			cfg := currentConfig()
+			cfg.Admin.Token = tt.configured
+			setConfig(cfg)
+
+			rec := adminRequestRecorder("GET", "/admin/update", tt.token, "")
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			if got := decodeAPIError(t, rec).Code; got != tt.wantCode {
+				t.Errorf("code = %s, want %s", got, tt.wantCode)
+			}
+			if tt.wantStatus == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("WWW-Authenticate header missing")
+			}
+		})
+	}
+}
+
+func TestAdminUpdateApply(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "latest", body: "", wantStatus: http.StatusAccepted},
+		{name: "explicit version", body: `{"version":"v1.3.0-beta.1"}`, wantStatus: http.StatusAccepted},
+		{name: "invalid version", body: `{"version":"latest"}`, wantStatus: http.StatusUnprocessableEntity, wantCode: ErrCodeValidationFailed},
+		{name: "unknown field", body: `{"version":"v1.2.0","force":true}`, wantStatus: http.StatusBadRequest, wantCode: ErrCodeInvalidJSON},
+		{name: "release not found", body: `{"version":"v2.0.0"}`, wantStatus: http.StatusNotFound, wantCode: ErrCodeReleaseNotFound},
+		{name: "running version", body: `{"version":"v1.1.0"}`, wantStatus: http.StatusConflict, wantCode: ErrCodeNoUpdateAvailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := withUpdateEnv(t, "v1.1.0")
+
+			rec := adminRequestRecorder("POST", "/admin/update/apply", "secret", tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantCode != "" {
+				if got := decodeAPIError(t, rec).Code; got != tt.wantCode {
+					t.Errorf("code = %s, want %s", got, tt.wantCode)
+				}
+				if env.restarted != 0 {
+					t.Error("restart must not be scheduled on failure")
+				}
+				return
+			}
+			if env.restarted != 1 {
+				t.Errorf("restart scheduled %d times, want 1", env.restarted)
+			}
+
+			// 再起動待ちの間は 409
+			rec = adminRequestRecorder("POST", "/admin/update/apply", "secret", "")
+			if rec.Code != http.StatusConflict || decodeAPIError(t, rec).Code != ErrCodeUpdateInProgress {
+				t.Errorf("second apply = %d %s", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestAdminUpdateRollback(t *testing.T) {
+	env := withUpdateEnv(t, "v1.2.0")
+
+	rec := adminRequestRecorder("POST", "/admin/update/rollback", "secret", "")
+	if rec.Code != http.StatusConflict || decodeAPIError(t, rec).Code != ErrCodeNoRollback {
+		t.Fatalf("rollback without previous version = %d %s", rec.Code, rec.Body.String())
+	}
+
+	os.WriteFile(env.exe+".old", []byte("print_pdf v1.1.0"), 0755)
+	rec = adminRequestRecorder("POST", "/admin/update/rollback", "secret", "")
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("rollback = %d %s", rec.Code, rec.Body.String())
+	}
+	if env.restarted != 1 {
+		t.Errorf("restart scheduled %d times, want 1", env.restarted)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// エラーコード（READMEの「エラーコード一覧」と対応）
+const (
+	ErrCodeNotFound            = "NOT_FOUND"             // 404
+	ErrCodeMethodNotAllowed    = "METHOD_NOT_ALLOWED"    // 405
+	ErrCodeInvalidBody         = "INVALID_BODY"          // 400 リクエストボディを読み取れない
+	ErrCodeInvalidJSON         = "INVALID_JSON"          // 400 JSONとして解析できない
+	ErrCodeInvalidMultipart    = "INVALID_MULTIPART"     // 400 マルチパートとして解析できない
+	ErrCodeValidationFailed    = "VALIDATION_FAILED"     // 422 内容が不正
+	ErrCodePDFGenerationFailed = "PDF_GENERATION_FAILED" // 500
+	ErrCodeInternal            = "INTERNAL_ERROR"        // 500
+	ErrCodePrintFailed         = "PRINT_FAILED"          // 502 プリンター・印刷処理の失敗
+	ErrCodePrintQueueFull      = "PRINT_QUEUE_FULL"      // 503
+	ErrCodeShuttingDown        = "SHUTTING_DOWN"         // 503 停止・アップデートのため受付停止中
+	ErrCodeServicePaused       = "SERVICE_PAUSED"        // 503 サービス一時停止中
+	ErrCodeUnauthorized        = "UNAUTHORIZED"          // 401 管理APIのトークンがない・一致しない
+	ErrCodeAdminDisabled       = "ADMIN_DISABLED"        // 403 admin.token が未設定
+	ErrCodeReleaseNotFound     = "RELEASE_NOT_FOUND"     // 404 指定バージョンのリリースがない
+	ErrCodeUpdateInProgress    = "UPDATE_IN_PROGRESS"    // 409 アップデート・ロールバック処理中
+	ErrCodeNoUpdateAvailable   = "NO_UPDATE_AVAILABLE"   // 409 適用できるバージョンがない
+	ErrCodeNoRollback          = "NO_ROLLBACK_AVAILABLE" // 409 前のバージョンが残っていない
+	ErrCodeUpdateFailed        = "UPDATE_FAILED"         // 502 アップデート元・ダウンロード・検証の失敗
+)
+
+// APIErrorResponse - 全エンドポイント共通のエラーレスポンス
+type APIErrorResponse struct {
+	Status    string      `json:"status"` // 常に "error"
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"requestId"`
+}
+
+// FieldError - 入力検証エラーの詳細
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// writeAPIError - 共通形式のエラーレスポンスを返す
+func writeAPIError(w http.ResponseWriter, r *http.Request, status int, code string, message string, details interface{}) {
+	response := APIErrorResponse{
+		Status:    "error",
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: requestIDFromContext(r.Context()),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeMethodNotAllowed - 許可されていないメソッドへの応答
+func writeMethodNotAllowed(w http.ResponseWriter, r *http.Request, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeAPIError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed,
+		"Method not allowed", map[string]interface{}{"method": r.Method, "allowed": allowed})
+}
+
+// writeJSON - 成功レスポンスを返す
+func writeJSON(w http.ResponseWriter, status int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// validatePrintRequest - PDF生成・印刷リクエストの内容を検証
+func validatePrintRequest(req PrintRequest) []FieldError {
+	var errs []FieldError
+	if len(req.Items) == 0 {
+		errs = append(errs, FieldError{Field: "items", Message: "at least one item is required"})
+	}
+	if req.PrinterName != nil && strings.TrimSpace(*req.PrinterName) == "" {
+		errs = append(errs, FieldError{Field: "printerName", Message: "must not be blank when specified"})
+	}
+	errs = append(errs, validateItemDates(req.Items)...)
+	errs = append(errs, validateItemTax(req.Items)...)
+	errs = append(errs, validateApprovals(req.Items)...)
+	errs = append(errs, validateWatermark(req.Watermark)...)
+	return errs
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// stubPrinter - 印刷処理をテスト用に差し替え
+func stubPrinter(t *testing.T, fn func(pdfPath, printerName string) error) {
+	t.Helper()
+	original := printPDF
+	printPDF = fn
+	t.Cleanup(func() { printPDF = original })
+}
+
+// serveWithRequestID - リクエストIDミドルウェア経由でハンドラーを実行
+func serveWithRequestID(handler http.HandlerFunc, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	requestIDMiddleware(handler).ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeAPIError(t *testing.T, rec *httptest.ResponseRecorder) APIErrorResponse {
+	t.Helper()
+	var resp APIErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("response is not JSON: %s", rec.Body.String())
+	}
+	if resp.Status != "error" {
+		t.Errorf("status = %q, expected error", resp.Status)
+	}
+	if resp.RequestID == "" || resp.RequestID != rec.Header().Get("X-Request-ID") {
+		t.Errorf("requestId = %q, header = %q", resp.RequestID, rec.Header().Get("X-Request-ID"))
+	}
+	return resp
+}
+
+func TestJSONEndpointErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		method     string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"generate: 不正なメソッド", generatePDFHandler, "GET", "", http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed},
+		{"generate: 不正なJSON", generatePDFHandler, "POST", "{not json", http.StatusBadRequest, ErrCodeInvalidJSON},
+		{"generate: 空の配列", generatePDFHandler, "POST", "[]", http.StatusUnprocessableEntity, ErrCodeValidationFailed},
+		{"generate: アイテムなし", generatePDFHandler, "POST", `{"items":[]}`, http.StatusUnprocessableEntity, ErrCodeValidationFailed},
+		{"generate: 空白のあとのアイテムなし", generatePDFHandler, "POST", ` {"print":true}`, http.StatusUnprocessableEntity, ErrCodeValidationFailed},
+		{"print-pdf: 不正なJSON", printPDFHandler, "POST", "[1,2]", http.StatusBadRequest, ErrCodeInvalidJSON},
+		{"print-pdf: アイテムなし", printPDFHandler, "POST", `{"items":[]}`, http.StatusUnprocessableEntity, ErrCodeValidationFailed},
+		{"print-pdf: 空のプリンター名", printPDFHandler, "POST", `{"items":[{"name":"a"}],"printerName":" "}`, http.StatusUnprocessableEntity, ErrCodeValidationFailed},
+		{"metrics: 不正なメソッド", metricsHandler, "POST", "", http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", bytes.NewBufferString(tt.body))
+			rec := serveWithRequestID(tt.handler, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, expected %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if resp := decodeAPIError(t, rec); resp.Code != tt.wantCode {
+				t.Errorf("code = %q, expected %q", resp.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestPrintPDFHandlerPrinterFailure(t *testing.T) {
+	stubPrinter(t, func(pdfPath, printerName string) error {
+		return errors.New("printer offline")
+	})
+
+	body := `{"items":[{"car":"test","name":"テスト","ryohi":[]}],"printerName":"LBP221"}`
+	rec := serveWithRequestID(printPDFHandler, httptest.NewRequest("POST", "/print-pdf", bytes.NewBufferString(body)))
+
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("status = %d, expected 502: %s", rec.Code, rec.Body.String())
+	}
+	resp := decodeAPIError(t, rec)
+	if resp.Code != ErrCodePrintFailed {
+		t.Errorf("code = %q, expected %q", resp.Code, ErrCodePrintFailed)
+	}
+	details, ok := resp.Details.(map[string]interface{})
+	if !ok || details["printer"] != "LBP221" || details["pdfGenerated"] != true {
+		t.Errorf("details = %v", resp.Details)
+	}
+}
+
+func TestPrintPDFHandlerQueueFull(t *testing.T) {
+	original := jobQueue
+	jobQueue = newPrintQueue(1, 1)
+	t.Cleanup(func() { jobQueue = original })
+
+	// キューを埋めておく
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go jobQueue.Submit(context.Background(), func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	defer close(release)
+
+	body := `{"items":[{"car":"test","name":"テスト","ryohi":[]}]}`
+	rec := serveWithRequestID(printPDFHandler, httptest.NewRequest("POST", "/print-pdf", bytes.NewBufferString(body)))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, expected 503: %s", rec.Code, rec.Body.String())
+	}
+	if resp := decodeAPIError(t, rec); resp.Code != ErrCodePrintQueueFull {
+		t.Errorf("code = %q, expected %q", resp.Code, ErrCodePrintQueueFull)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("Retry-After header should be set")
+	}
+}
+
+func TestPrintPDFHandlerSuccess(t *testing.T) {
+	var printedTo string
+	stubPrinter(t, func(pdfPath, printerName string) error {
+		printedTo = printerName
+		return nil
+	})
+
+	body := `{"items":[{"car":"test","name":"テスト","ryohi":[]}],"printerName":"LBP221"}`
+	rec := serveWithRequestID(printPDFHandler, httptest.NewRequest("POST", "/print-pdf", bytes.NewBufferString(body)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, expected 200: %s", rec.Code, rec.Body.String())
+	}
+	if printedTo != "LBP221" {
+		t.Errorf("printed to %q, expected LBP221", printedTo)
+	}
+}
+
+func newEnvelopeRequest(t *testing.T, filename string, content []byte) *http.Request {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.WriteField("printer", "LBP221-futo")
+	if filename != "" {
+		fw, err := mw.CreateFormFile("document", filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write(content)
+	}
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/print", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestEnvelopePrintHandlerErrors(t *testing.T) {
+	t.Run("マルチパート以外", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/print", bytes.NewBufferString("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		rec := serveWithRequestID(envelopePrintHandler, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("status = %d, expected 400", rec.Code)
+		}
+		if resp := decodeAPIError(t, rec); resp.Code != ErrCodeInvalidMultipart {
+			t.Errorf("code = %q", resp.Code)
+		}
+	})
+
+	t.Run("ファイルなし", func(t *testing.T) {
+		rec := serveWithRequestID(envelopePrintHandler, newEnvelopeRequest(t, "", nil))
+		if rec.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("status = %d, expected 422", rec.Code)
+		}
+		if resp := decodeAPIError(t, rec); resp.Code != ErrCodeValidationFailed {
+			t.Errorf("code = %q", resp.Code)
+		}
+	})
+
+	t.Run("PDF以外", func(t *testing.T) {
+		rec := serveWithRequestID(envelopePrintHandler, newEnvelopeRequest(t, "futo.txt", []byte("hello")))
+		if rec.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("status = %d, expected 422", rec.Code)
+		}
+		decodeAPIError(t, rec)
+	})
+}
+
+func TestValidatePrintRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        PrintRequest
+		wantFields []string
+	}{
+		{"正常", PrintRequest{Items: []Item{{Name: "a"}}}, nil},
+		{"アイテムなし", PrintRequest{}, []string{"items"}},
+		{"空のプリンター名", PrintRequest{Items: []Item{{}}, PrinterName: StringPtr("")}, []string{"printerName"}},
+		{"RFC 3339の日付", PrintRequest{Items: []Item{{StartDate: StringPtr("2024-12-17T00:00:00+09:00")}}}, nil},
+		{"不正な日付", PrintRequest{Items: []Item{{EndDate: StringPtr("2024-12-40"), Ryohi: []Ryohi{{Date: StringPtr("someday")}}}}}, []string{"items[0].endDate", "items[0].ryohi[0].date"}},
+		{"税率", PrintRequest{Items: []Item{{TaxRate: Float64Ptr(10)}, {TaxRate: Float64Ptr(8)}}}, nil},
+		{"消費税額", PrintRequest{Items: []Item{{Price: 5000, Tax: Float64Ptr(500.0)}}}, nil},
+		{"範囲外の税率", PrintRequest{Items: []Item{{TaxRate: Float64Ptr(-1)}, {TaxRate: Float64Ptr(500)}}}, []string{"items[0].taxRate", "items[1].taxRate"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validatePrintRequest(tt.req)
+			if len(errs) != len(tt.wantFields) {
+				t.Fatalf("errors = %v, expected fields %v", errs, tt.wantFields)
+			}
+			for i, field := range tt.wantFields {
+				if errs[i].Field != field {
+					t.Errorf("errs[%d].Field = %q, expected %q", i, errs[i].Field, field)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// /v1 のルート
+// リクエストは PrintRequest オブジェクトのみ受け付け、未知のフィールドはエラーにする。
+
+// decodeStrictPrintRequest - PrintRequest を厳密に解析
+func decodeStrictPrintRequest(body []byte) (PrintRequest, error) {
+	var req PrintRequest
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		return PrintRequest{}, err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return PrintRequest{}, errors.New("unexpected data after JSON object")
+	}
+	return req, nil
+}
+
+// HTTPハンドラー: POST /v1/generate-pdf（print が true の場合のみ印刷）
+func v1GeneratePDFHandler(w http.ResponseWriter, r *http.Request) {
+	if !acceptPost(w, r) {
+		return
+	}
+	writeRequestLog(r, "INFO", fmt.Sprintf("PDF生成リクエストを受信 from %s", r.RemoteAddr))
+
+	body, ok := readRequestBody(w, r)
+	if !ok {
+		return
+	}
+	printRequest, err := decodeStrictPrintRequest(body)
+	if err != nil {
+		writeInvalidJSON(w, r, err)
+		return
+	}
+
+	servePDFRequest(w, r, printRequest, printRequest.Print)
+}
+
+// HTTPハンドラー: POST /v1/print-pdf（常に印刷）
+func v1PrintPDFHandler(w http.ResponseWriter, r *http.Request) {
+	if !acceptPost(w, r) {
+		return
+	}
+	writeRequestLog(r, "INFO", fmt.Sprintf("PDF印刷リクエストを受信 from %s", r.RemoteAddr))
+
+	body, ok := readRequestBody(w, r)
+	if !ok {
+		return
+	}
+	printRequest, err := decodeStrictPrintRequest(body)
+	if err != nil {
+		writeInvalidJSON(w, r, err)
+		return
+	}
+
+	servePDFRequest(w, r, printRequest, true)
+}
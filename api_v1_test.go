@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDecodeStrictPrintRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantItems int
+		wantErr   bool
+	}{
+		{name: "正常", body: `{"items":[{"name":"a","ryohi":[{"kukan":"本社～博多"}]}],"print":true}`, wantItems: 1},
+		{name: "末尾の空白は許可", body: "{\"items\":[{\"name\":\"a\"}]}\n", wantItems: 1},
+		{name: "Item配列形式は不可", body: `[{"name":"a"}]`, wantErr: true},
+		{name: "未知のトップレベルフィールド", body: `{"items":[{"name":"a"}],"copies":2}`, wantErr: true},
+		{name: "未知のItemフィールド", body: `{"items":[{"name":"a","nmae":"b"}]}`, wantErr: true},
+		{name: "未知のRyohiフィールド", body: `{"items":[{"ryohi":[{"kukkan":"x"}]}]}`, wantErr: true},
+		{name: "型の不一致", body: `{"items":[{"price":"100"}]}`, wantErr: true},
+		{name: "後続データ", body: `{"items":[]}{"items":[]}`, wantErr: true},
+		{name: "不正なJSON", body: `{"items":`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := decodeStrictPrintRequest([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(req.Items) != tt.wantItems {
+				t.Errorf("items = %d, want %d", len(req.Items), tt.wantItems)
+			}
+		})
+	}
+}
+
+func TestV1Handlers(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		body       string
+		printErr   error
+		wantStatus int
+		wantCode   string
+		wantPrints int
+	}{
+		{name: "generate: 印刷なし", handler: v1GeneratePDFHandler, body: `{"items":[{"name":"a"}]}`, wantStatus: http.StatusOK},
+		{name: "generate: 印刷あり", handler: v1GeneratePDFHandler, body: `{"items":[{"name":"a"}],"print":true}`, wantStatus: http.StatusOK, wantPrints: 1},
+		{name: "generate: Item配列形式", handler: v1GeneratePDFHandler, body: `[{"name":"a"}]`, wantStatus: http.StatusBadRequest, wantCode: ErrCodeInvalidJSON},
+		{name: "generate: 未知のフィールド", handler: v1GeneratePDFHandler, body: `{"items":[{"name":"a"}],"copies":2}`, wantStatus: http.StatusBadRequest, wantCode: ErrCodeInvalidJSON},
+		{name: "generate: アイテムなし", handler: v1GeneratePDFHandler, body: `{"items":[]}`, wantStatus: http.StatusUnprocessableEntity, wantCode: ErrCodeValidationFailed},
+		{name: "print-pdf: 常に印刷", handler: v1PrintPDFHandler, body: `{"items":[{"name":"a"}]}`, wantStatus: http.StatusOK, wantPrints: 1},
+		{name: "print-pdf: 未知のフィールド", handler: v1PrintPDFHandler, body: `{"items":[{"name":"a"}],"printer":"x"}`, wantStatus: http.StatusBadRequest, wantCode: ErrCodeInvalidJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prints := 0
+			stubPrinter(t, func(pdfPath, printerName string) error {
+				prints++
+				return tt.printErr
+			})
+
+			rec := serveWithRequestID(tt.handler, httptest.NewRequest("POST", "/v1", bytes.NewBufferString(tt.body)))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantCode != "" {
+				if resp := decodeAPIError(t, rec); resp.Code != tt.wantCode {
+					t.Errorf("code = %s, want %s", resp.Code, tt.wantCode)
+				}
+			}
+			if prints != tt.wantPrints {
+				t.Errorf("prints = %d, want %d", prints, tt.wantPrints)
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// 承認欄
+// Webシステムで承認済みの承認者を、右上の承認欄に印影（PNG）または赤い丸印（日付入りのデータ印）で印字する
+
+// Approval - 承認者（Item.Approvals）
+type Approval struct {
+	Role  string  `json:"role"`  // 承認欄の役割（pdf.approvalColumns の role、または見出し）
+	Name  string  `json:"name"`  // 承認者名（印影がない場合は丸印に印字）
+	Date  *string `json:"date"`  // 承認日（丸印の中段に印字）
+	Stamp *string `json:"stamp"` // 印影のPNG画像（Base64。data:image/png;base64, から始まってもよい）
+}
+
+// ApprovalColumn - 承認欄の列
+type ApprovalColumn struct {
+	Role  string `json:"role"`  // 承認者の役割（Approval.Role と対応）
+	Label string `json:"label"` // 見出し
+}
+
+// defaultApprovalColumns - 承認欄の既定の列
+var defaultApprovalColumns = []ApprovalColumn{
+	{Role: "president", Label: "社　長"},
+	{Role: "accounting", Label: "会　計"},
+	{Role: "department", Label: "所　属"},
+}
+
+// maxApprovalColumns - 承認欄の列数の上限（幅45mmを等分するため、印影が小さくなりすぎない数）
+const maxApprovalColumns = 5
+
+// 承認欄の位置（右上、基本情報テーブルの右）
+const (
+	approvalX      = 155.0
+	approvalY      = 25.0
+	approvalWidth  = 45.0
+	approvalHeadH  = 5.0
+	approvalStampH = 15.0
+)
+
+// 印影のPNGの上限（画素を展開する前に確認し、小さなファイルで大量のメモリを使わせない）
+const (
+	maxStampBytes = 1 << 20 // Base64を戻した大きさ
+	maxStampSide  = 1024    // 縦・横の画素数（欄は15mm角のため十分な解像度）
+)
+
+// 丸印の色（朱色）
+var sealColor = [3]int{220, 40, 30}
+
+// approvalColumns - 設定された承認欄の列（未設定の場合は既定の列）
+func (p PDFConfig) approvalColumns() []ApprovalColumn {
+	if len(p.ApprovalColumns) == 0 {
+		return defaultApprovalColumns
+	}
+	return p.ApprovalColumns
+}
+
+// validateApprovalColumns - 承認欄の列の設定を確認
+func validateApprovalColumns(cols []ApprovalColumn) error {
+	if len(cols) > maxApprovalColumns {
+		return fmt.Errorf("承認欄の列は%d列までです（%d列）", maxApprovalColumns, len(cols))
+	}
+	seen := map[string]bool{}
+	for i, col := range cols {
+		if strings.TrimSpace(col.Role) == "" || strings.TrimSpace(col.Label) == "" {
+			return fmt.Errorf("承認欄の%d列目: role と label を指定してください", i+1)
+		}
+		if seen[col.Role] {
+			return fmt.Errorf("承認欄の role が重複しています: %q", col.Role)
+		}
+		seen[col.Role] = true
+	}
+	return nil
+}
+
+// compactLabel - 空白を除いた見出し（"社　長" → "社長"）
+func compactLabel(s string) string {
+	return strings.Join(strings.FieldsFunc(s, isWrapSpace), "")
+}
+
+// approvalColumnIndex - 承認者の役割に対応する列（role または空白を除いた見出しで照合。ない場合は -1）
+func approvalColumnIndex(cols []ApprovalColumn, role string) int {
+	role = strings.TrimSpace(role)
+	for i, col := range cols {
+		if role == col.Role || compactLabel(role) == compactLabel(col.Label) {
+			return i
+		}
+	}
+	return -1
+}
+
+// stampPNG - 印影のPNGを取り出し、ヘッダーで大きさを確認する（data URL の接頭辞は除く。画素は展開しない）
+func stampPNG(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if i := strings.Index(s, ","); strings.HasPrefix(s, "data:") && i >= 0 {
+		s = s[i+1:]
+	}
+	if base64.StdEncoding.DecodedLen(len(s)) > maxStampBytes {
+		return nil, fmt.Errorf("stamp must be at most %d bytes", maxStampBytes)
+	}
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("stamp must be a base64-encoded PNG image: %v", err)
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("stamp must be a base64-encoded PNG image: %v", err)
+	}
+	if cfg.Width > maxStampSide || cfg.Height > maxStampSide {
+		return nil, fmt.Errorf("stamp must be at most %dx%d pixels (got %dx%d)", maxStampSide, maxStampSide, cfg.Width, cfg.Height)
+	}
+	return data, nil
+}
+
+// validateApprovals - Item.Approvals を検証（承認欄にない役割は印字時にログへ記録して無視する）
+// role と見出しのどちらでも同じ列に対応するため、重複は対応する列で判定する（"president" と "社長" は重複）
+func validateApprovals(items []Item) []FieldError {
+	cols := currentConfig().PDF.approvalColumns()
+	var errs []FieldError
+	for i, item := range items {
+		seen := map[string]bool{}
+		for j, a := range item.Approvals {
+			prefix := fmt.Sprintf("items[%d].approvals[%d].", i, j)
+			role := strings.TrimSpace(a.Role)
+			key := role
+			if index := approvalColumnIndex(cols, role); index >= 0 {
+				key = cols[index].Role
+			}
+			switch {
+			case role == "":
+				errs = append(errs, FieldError{Field: prefix + "role", Message: "is required"})
+			case seen[key]:
+				errs = append(errs, FieldError{Field: prefix + "role", Message: fmt.Sprintf("duplicate role %q (same approval column as an earlier approval)", role)})
+			}
+			seen[key] = true
+			if strings.TrimSpace(a.Name) == "" && a.Stamp == nil {
+				errs = append(errs, FieldError{Field: prefix + "name", Message: "is required when no stamp is given"})
+			}
+			if a.Date != nil && strings.TrimSpace(*a.Date) != "" {
+				if _, err := parseItemDate(*a.Date); err != nil {
+					errs = append(errs, FieldError{Field: prefix + "date", Message: err.Error()})
+				}
+			}
+			if a.Stamp != nil {
+				if _, err := stampPNG(*a.Stamp); err != nil {
+					errs = append(errs, FieldError{Field: prefix + "stamp", Message: err.Error()})
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// approvalColumnBox - 列の印影欄の枠
+func approvalColumnBox(index, count int) fitBox {
+	w := approvalWidth / float64(count)
+	return fitBox{X: approvalX + float64(index)*w, Y: approvalY + approvalHeadH, W: w, H: approvalStampH}
+}
+
+// drawApprovalTable - 承認テーブルを描画
+func (c *ReportLabStylePdfClient) drawApprovalTable() {
+	cols := currentConfig().PDF.approvalColumns()
+
+	// テーブル用の細い線幅を設定
+	c.pdf.SetLineWidth(0.2)
+
+	// ヘッダー行（収まらない見出しは縮小）と印影欄
+	c.pdf.SetFont("yumin", "", 9)
+	for i, col := range cols {
+		box := approvalColumnBox(i, len(cols))
+		c.pdf.Rect(box.X, approvalY, box.W, approvalHeadH, "D")
+		c.fitText(fitBox{box.X + 0.5, approvalY, box.W - 1, approvalHeadH}, col.Label, fitStyle{Align: alignCenter, MinSize: 5, MaxSize: 9}, "承認欄の見出し")
+		c.pdf.Rect(box.X, box.Y, box.W, box.H, "D")
+	}
+}
+
+// printApprovals - 承認者の印影・丸印を承認欄に印字
+func (c *ReportLabStylePdfClient) printApprovals(approvals []Approval) {
+	cols := currentConfig().PDF.approvalColumns()
+	for _, a := range approvals {
+		index := approvalColumnIndex(cols, a.Role)
+		if index < 0 {
+			writeEventLog("WARN", fmt.Sprintf("承認欄にない役割のため印字しません: %q（%s）", a.Role, a.Name))
+			continue
+		}
+		box := approvalColumnBox(index, len(cols))
+		if a.Stamp != nil {
+			err := c.drawStampImage(box, *a.Stamp)
+			if err == nil {
+				continue
+			}
+			writeEventLog("WARN", fmt.Sprintf("印影を読み込めないため丸印を印字します（%s）: %v", a.Name, err))
+		}
+		c.drawSeal(box, cols[index].Label, a)
+	}
+}
+
+// drawStampImage - 印影の画像を縦横比を保って欄の中央に描画
+// 同じ印影は初回だけ展開して登録し、以降のページでは登録済みの画像を使う
+func (c *ReportLabStylePdfClient) drawStampImage(box fitBox, stamp string) error {
+	data, err := stampPNG(stamp)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	name := "stamp-" + hex.EncodeToString(sum[:8])
+	opt := gofpdf.ImageOptions{ImageType: "PNG"}
+	info := c.pdf.GetImageInfo(name)
+	if info == nil {
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		// gofpdf はインターレースや16bitのPNGを扱えないため、8bitのRGBAで書き直して登録する
+		rgba, ok := img.(*image.NRGBA)
+		if !ok {
+			rgba = image.NewNRGBA(img.Bounds())
+			draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, rgba); err != nil {
+			return err
+		}
+		info = c.pdf.RegisterImageOptionsReader(name, opt, &buf)
+	}
+	if !c.pdf.Ok() {
+		return c.pdf.Error()
+	}
+
+	// 余白1mmの内側に収める
+	maxW, maxH := box.W-2, box.H-2
+	scale := math.Min(maxW/info.Width(), maxH/info.Height())
+	w, h := info.Width()*scale, info.Height()*scale
+	c.pdf.ImageOptions(name, box.X+(box.W-w)/2, box.Y+(box.H-h)/2, w, h, false, opt, 0, "")
+	return nil
+}
+
+// drawSeal - 赤い丸印を描画（日付がある場合は上段に見出し・中段に日付・下段に氏名のデータ印）
+func (c *ReportLabStylePdfClient) drawSeal(box fitBox, label string, a Approval) {
+	cx, cy := box.X+box.W/2, box.Y+box.H/2
+	r := math.Min(box.W, box.H)/2 - 1.2
+
+	c.pdf.SetDrawColor(sealColor[0], sealColor[1], sealColor[2])
+	c.pdf.SetTextColor(sealColor[0], sealColor[1], sealColor[2])
+	c.pdf.SetLineWidth(0.35)
+	defer func() {
+		c.pdf.SetDrawColor(0, 0, 0)
+		c.pdf.SetTextColor(0, 0, 0)
+		c.pdf.SetLineWidth(0.2)
+	}()
+	c.pdf.Circle(cx, cy, r, "D")
+
+	name := strings.TrimSpace(a.Name)
+	var date string
+	if a.Date != nil {
+		if t, err := parseItemDate(*a.Date); err == nil {
+			date = formatDate(t, c.dates.Seal)
+		}
+	}
+	style := fitStyle{Align: alignCenter, MinSize: 3, MaxSize: 8}
+
+	if date == "" {
+		// 氏名だけの丸印（4文字までの全角の名前は縦書き）
+		if utf8.RuneCountInString(name) <= 4 && isFullWidthText(name) {
+			name = strings.Join(strings.Split(name, ""), "\n")
+		}
+		side := r * 1.3
+		c.fitText(fitBox{cx - side/2, cy - side/2, side, side}, name, style, "承認者名")
+		return
+	}
+
+	// データ印: 中段を2本の線で区切る
+	d := r / 3
+	chord := math.Sqrt(r*r - d*d)
+	c.pdf.Line(cx-chord, cy-d, cx+chord, cy-d)
+	c.pdf.Line(cx-chord, cy+d, cx+chord, cy+d)
+
+	band := r - d - 0.5
+	side := r * 1.4
+	style.MaxSize = 6
+	c.fitText(fitBox{cx - side/2, cy - d - band, side, band}, compactLabel(label), style, "承認欄の見出し")
+	c.fitText(fitBox{cx - r*0.9, cy - d + 0.3, r * 1.8, 2*d - 0.6}, date, style, "承認日")
+	c.fitText(fitBox{cx - side/2, cy + d + 0.2, side, band}, name, style, "承認者名")
+}
+
+// isFullWidthText - すべて全角文字か（縦書きにする名前の判定）
+func isFullWidthText(s string) bool {
+	for _, r := range s {
+		if r < 0x2E80 || (r >= 0xFF61 && r <= 0xFFDC) { // CJK より前（英数字・記号）と半角カナ
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testStamp - テスト用の印影（赤い正方形のPNG）をBase64で作成
+func testStamp(t *testing.T, img image.Image) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func redSquare(size int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			img.Set(x, y, color.NRGBA{R: 220, G: 40, B: 30, A: 255})
+		}
+	}
+	return img
+}
+
+func TestApprovalColumnIndex(t *testing.T) {
+	cols := defaultApprovalColumns
+	tests := []struct {
+		role string
+		want int
+	}{
+		{"president", 0},
+		{"accounting", 1},
+		{" department ", 2},
+		{"社長", 0},
+		{"会　計", 1},
+		{"manager", -1},
+		{"", -1},
+	}
+	for _, tt := range tests {
+		if got := approvalColumnIndex(cols, tt.role); got != tt.want {
+			t.Errorf("approvalColumnIndex(%q) = %d, want %d", tt.role, got, tt.want)
+		}
+	}
+}
+
+func TestValidateApprovals(t *testing.T) {
+	stamp := testStamp(t, redSquare(8))
+	items := []Item{
+		{Approvals: []Approval{
+			{Role: "president", Name: "山田", Date: StringPtr("2025-01-06")},
+			{Role: "accounting", Stamp: StringPtr("data:image/png;base64," + stamp)},
+			{Role: "manager", Name: "佐藤"}, // 承認欄にない役割は印字時に無視する
+		}},
+		{Approvals: []Approval{
+			{Role: "", Name: "田中"},
+			{Role: "president", Date: StringPtr("someday")},
+			{Role: "president", Name: "鈴木", Stamp: StringPtr("not a png")},
+			{Role: "accounting", Name: "佐藤", Stamp: StringPtr(testStamp(t, image.NewNRGBA(image.Rect(0, 0, maxStampSide+1, 1))))},
+			{Role: "department", Name: "高橋", Stamp: StringPtr(strings.Repeat("A", maxStampBytes*4/3+8))},
+		}},
+		{Approvals: []Approval{
+			{Role: "president", Name: "山田"},
+			{Role: "社　長", Name: "山田"}, // 見出しでも同じ列
+			{Role: "会計", Name: "佐藤"},
+			{Role: "accounting", Name: "佐藤"},
+		}},
+	}
+
+	errs := validateApprovals(items)
+	want := []string{
+		"items[1].approvals[0].role",
+		"items[1].approvals[1].name",
+		"items[1].approvals[1].date",
+		"items[1].approvals[2].role",
+		"items[1].approvals[2].stamp",
+		"items[1].approvals[3].stamp",
+		"items[1].approvals[4].stamp",
+		"items[2].approvals[1].role",
+		"items[2].approvals[3].role",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("errors = %v, want fields %v", errs, want)
+	}
+	for i, field := range want {
+		if errs[i].Field != field {
+			t.Errorf("errs[%d].Field = %q, want %q", i, errs[i].Field, field)
+		}
+	}
+}
+
+func TestLoadConfigApprovalColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		want    []string // 見出し
+		wantErr string
+	}{
+		{name: "既定の列", config: `{}`, want: []string{"社　長", "会　計", "所　属"}},
+		{
+			name:   "4列",
+			config: `{"pdf":{"approvalColumns":[{"role":"president","label":"社長"},{"role":"manager","label":"部長"},{"role":"accounting","label":"経理"},{"role":"applicant","label":"申請者"}]}}`,
+			want:   []string{"社長", "部長", "経理", "申請者"},
+		},
+		{name: "列が多すぎる", config: `{"pdf":{"approvalColumns":[{"role":"a","label":"a"},{"role":"b","label":"b"},{"role":"c","label":"c"},{"role":"d","label":"d"},{"role":"e","label":"e"},{"role":"f","label":"f"}]}}`, wantErr: "5列まで"},
+		{name: "見出しがない", config: `{"pdf":{"approvalColumns":[{"role":"a"}]}}`, wantErr: "role と label"},
+		{name: "role の重複", config: `{"pdf":{"approvalColumns":[{"role":"a","label":"A"},{"role":"a","label":"B"}]}}`, wantErr: "重複"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), configFileName)
+			if err := os.WriteFile(path, []byte(tt.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := loadConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var labels []string
+			for _, col := range cfg.PDF.approvalColumns() {
+				labels = append(labels, col.Label)
+			}
+			if strings.Join(labels, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("labels = %v, want %v", labels, tt.want)
+			}
+		})
+	}
+}
+
+func TestStampPNGLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		stamp   string
+		wantErr string
+	}{
+		{name: "上限の大きさ", stamp: testStamp(t, image.NewNRGBA(image.Rect(0, 0, maxStampSide, maxStampSide)))},
+		{name: "data URL", stamp: "data:image/png;base64," + testStamp(t, redSquare(4))},
+		{name: "幅が上限を超える", stamp: testStamp(t, image.NewNRGBA(image.Rect(0, 0, maxStampSide+1, 1))), wantErr: "pixels"},
+		{name: "高さが上限を超える", stamp: testStamp(t, image.NewGray(image.Rect(0, 0, 1, 100000))), wantErr: "pixels"},
+		{name: "ファイルが大きすぎる", stamp: strings.Repeat("A", maxStampBytes*4/3+8), wantErr: "bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := stampPNG(tt.stamp)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("stampPNG() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("stampPNG() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsFullWidthText(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"山田", true},
+		{"やまだ", true},
+		{"Yamada", false},
+		{"山田A", false},
+		{"ﾔﾏﾀﾞ", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isFullWidthText(tt.input); got != tt.want {
+			t.Errorf("isFullWidthText(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestRenderPDFWithApprovals(t *testing.T) {
+	original := currentConfig()
+	t.Cleanup(func() { setConfig(original) })
+
+	// 16bitのPNGも8bitに変換して埋め込む
+	wide := image.NewRGBA64(image.Rect(0, 0, 40, 20))
+	for x := 0; x < 40; x++ {
+		wide.Set(x, 10, color.RGBA64{R: 0xffff, A: 0xffff})
+	}
+	stamp := testStamp(t, redSquare(16))
+	items := []Item{{
+		Name: "山田　太郎",
+		Approvals: []Approval{
+			{Role: "president", Name: "山田", Date: StringPtr("2025-01-06")},
+			{Role: "accounting", Name: "佐藤", Stamp: StringPtr(stamp)},
+			{Role: "department", Name: "Tanaka", Stamp: StringPtr(testStamp(t, wide))},
+			{Role: "manager", Name: "鈴木"},
+		},
+	}, {
+		Name:      "佐藤　花子",
+		Approvals: []Approval{{Role: "社長", Name: "山田"}, {Role: "accounting", Name: "佐藤", Stamp: StringPtr(stamp)}},
+	}}
+
+	for _, cols := range [][]ApprovalColumn{nil, defaultApprovalColumns[:1], append(defaultApprovalColumns, ApprovalColumn{"manager", "部　長"}, ApprovalColumn{"applicant", "申請者"})} {
+		cfg := defaultConfig()
+		cfg.PDF.ApprovalColumns = cols
+		setConfig(cfg)
+
+		var buf bytes.Buffer
+		pages, err := RenderPDF(&buf, "", items)
+		if err != nil {
+			t.Fatalf("%d columns: %v", len(cols), err)
+		}
+		if pages != len(items) {
+			t.Errorf("%d columns: pages = %d, want %d", len(cols), pages, len(items))
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/qr"
+)
+
+// 元データへのQRコード・バーコード
+// 経理担当者が用紙から元の記録を開けるよう、Itemの項目から作ったURLやIDをページごとに印字する
+// 画像にせずモジュールを矩形で描画するため、印刷の解像度に関係なく読み取れる
+
+// バーコードの種類（pdf.barcode.type）
+const (
+	BarcodeNone    = ""        // 印字しない
+	BarcodeQR      = "qr"      // QRコード（誤り訂正レベルM）
+	BarcodeCode128 = "code128" // Code128（ASCIIのみ）
+)
+
+// バーコードの位置（pdf.barcode.position）
+const (
+	BarcodePositionRemarks = "remarks" // 備考欄の右端（QRコードの既定）
+	BarcodePositionFooter  = "footer"  // 外枠の下の余白（Code128の既定）
+)
+
+// BarcodeConfig - ページごとに印字するQRコード・バーコード
+type BarcodeConfig struct {
+	Type     string `json:"type"`     // qr, code128（空の場合は印字しない）
+	Content  string `json:"content"`  // 内容のテンプレート（text/template。例: https://example.com/records/{{.ID}}）
+	Position string `json:"position"` // remarks, footer（空の場合は種類ごとの既定）
+}
+
+// barcodeFields - 内容のテンプレートで使える項目
+type barcodeFields struct {
+	ID        string
+	Name      string
+	Car       string
+	Office    string
+	Purpose   string
+	PayDay    string // YYYY-MM-DD（解析できない場合はそのまま）
+	StartDate string // YYYY-MM-DD
+	EndDate   string // YYYY-MM-DD
+	Price     int
+	Page      int // ページ番号（1から）
+}
+
+// 印字できる最小のモジュール幅（mm）。これより小さくなる場合は読み取れないため印字しない
+const (
+	qrMinModuleMM      = 0.25
+	code128MinModuleMM = 0.19
+	code128MaxModuleMM = 0.4 // 短い内容が横に広がりすぎないよう
+	qrQuietZone        = 4   // 周囲の余白（モジュール数）
+	code128QuietZone   = 10
+)
+
+// 配置する枠（mm）
+var (
+	barcodeRemarksBox = fitBox{X: 136.5, Y: 119.5, W: 18, H: 18} // 備考欄（x 10〜155, y 119〜138）の右端
+	barcodeFooterBox  = fitBox{X: 10, Y: 138.8, W: 190, H: 8.8}  // 外枠の下（用紙の下端まで10mm）
+	barcodeTextHeight = 2.6                                      // Code128の下に印字する内容の高さ
+)
+
+// position - 位置（未指定の場合は種類ごとの既定）
+func (b BarcodeConfig) position() string {
+	if b.Position != "" {
+		return b.Position
+	}
+	if b.Type == BarcodeCode128 {
+		return BarcodePositionFooter
+	}
+	return BarcodePositionRemarks
+}
+
+// box - 配置する枠
+func (b BarcodeConfig) box() fitBox {
+	if b.position() == BarcodePositionFooter {
+		return barcodeFooterBox
+	}
+	return barcodeRemarksBox
+}
+
+// inRemarks - 備考欄に配置するか（備考の文字はその分だけ狭くする）
+func (b BarcodeConfig) inRemarks() bool {
+	return b.Type != BarcodeNone && b.position() == BarcodePositionRemarks
+}
+
+// barcodeFuncs - 内容のテンプレートで使える関数（urlquery などの標準の関数に追加）
+var barcodeFuncs = template.FuncMap{
+	"urlescape": func(v any) string { return escapeURLComponent(fmt.Sprint(v)) }, // URLのパス・クエリのどちらにも使えるようにエスケープ
+	"raw":       func(v any) string { return fmt.Sprint(v) },                     // URLのテンプレートでもエスケープしない
+}
+
+// urlSafeFuncs - 最後に適用されていれば自動でエスケープしない関数
+var urlSafeFuncs = map[string]bool{"urlescape": true, "urlquery": true, "raw": true}
+
+// parseBarcodeTemplate - 内容のテンプレートを解析
+// http:// または https:// から始まるURLのテンプレートは、項目の値で別の記録へのリンクにならないよう、
+// 各 {{...}} の出力を urlescape でエスケープする（urlquery・raw を最後に指定した場合を除く）
+func parseBarcodeTemplate(content string) (*template.Template, error) {
+	tmpl, err := template.New("barcode").Option("missingkey=error").Funcs(barcodeFuncs).Parse(content)
+	if err != nil {
+		return nil, err
+	}
+	if isURLTemplate(content) {
+		escapeActions(tmpl.Tree.Root)
+	}
+	return tmpl, nil
+}
+
+// isURLTemplate - URLを作るテンプレートか
+func isURLTemplate(content string) bool {
+	s := strings.ToLower(strings.TrimSpace(content))
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+// escapeActions - テンプレートの出力する {{...}} の最後に urlescape を追加（if・range・with の中も含む）
+func escapeActions(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			escapeActions(child)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) > 0 { // {{$x := ...}} は出力しない
+			return
+		}
+		last := n.Pipe.Cmds[len(n.Pipe.Cmds)-1]
+		if id, ok := last.Args[0].(*parse.IdentifierNode); ok && urlSafeFuncs[id.Ident] {
+			return
+		}
+		n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{NodeType: parse.NodeCommand, Pos: n.Pos, Args: []parse.Node{parse.NewIdentifier("urlescape")}})
+	case *parse.IfNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	case *parse.RangeNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	case *parse.WithNode:
+		escapeActions(n.List)
+		escapeActions(n.ElseList)
+	}
+}
+
+// escapeURLComponent - 英数字と - . _ ~ 以外をパーセントエンコード（空白は %20。パスとクエリのどちらでも同じ値に戻る）
+func escapeURLComponent(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-._~", c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// validate - 設定を確認（テンプレートは空の項目で実行して、存在しない項目を参照していないか確認する）
+func (b BarcodeConfig) validate() error {
+	switch b.Type {
+	case BarcodeNone:
+		return nil
+	case BarcodeQR, BarcodeCode128:
+	default:
+		return fmt.Errorf("バーコードの種類は qr・code128 のいずれかを指定してください: %q", b.Type)
+	}
+	switch b.Position {
+	case "", BarcodePositionRemarks, BarcodePositionFooter:
+	default:
+		return fmt.Errorf("バーコードの位置は remarks・footer のいずれかを指定してください: %q", b.Position)
+	}
+	if strings.TrimSpace(b.Content) == "" {
+		return fmt.Errorf("バーコードの内容（content）を指定してください")
+	}
+	tmpl, err := parseBarcodeTemplate(b.Content)
+	if err != nil {
+		return fmt.Errorf("バーコードの内容のテンプレートが不正です: %v", err)
+	}
+	if err := tmpl.Execute(&bytes.Buffer{}, barcodeFields{}); err != nil {
+		return fmt.Errorf("バーコードの内容のテンプレートが不正です: %v", err)
+	}
+	return nil
+}
+
+// newBarcodeFields - アイテムからテンプレートの項目を作る
+func newBarcodeFields(item Item, page int) barcodeFields {
+	text := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return strings.TrimSpace(*s)
+	}
+	date := func(s *string) string {
+		return formatDateText(text(s), DateStyleISO)
+	}
+	return barcodeFields{
+		ID:        text(item.ID),
+		Name:      strings.TrimSpace(item.Name),
+		Car:       strings.TrimSpace(item.Car),
+		Office:    text(item.Office),
+		Purpose:   text(item.Purpose),
+		PayDay:    date(item.PayDay),
+		StartDate: date(item.StartDate),
+		EndDate:   date(item.EndDate),
+		Price:     item.Price,
+		Page:      page,
+	}
+}
+
+// barcodeContent - テンプレートからバーコードの内容を作る
+func barcodeContent(cfg BarcodeConfig, item Item, page int) (string, error) {
+	tmpl, err := parseBarcodeTemplate(cfg.Content)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, newBarcodeFields(item, page)); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// encodeBarcode - 内容をバーコードにする
+func encodeBarcode(kind, content string) (barcode.Barcode, error) {
+	if kind == BarcodeCode128 {
+		return code128.Encode(content)
+	}
+	return qr.Encode(content, qr.M, qr.Auto)
+}
+
+// layoutBarcode - バーコードの黒いモジュールを、枠に合わせた矩形（mm）にする
+// 横に連続するモジュールは1つの矩形にまとめる。QRコードは枠の中央、Code128は枠の左に余白を空けて配置する
+func layoutBarcode(kind string, bc barcode.Barcode, box fitBox) (rects []fitBox, module float64, err error) {
+	b := bc.Bounds()
+	cols, rows := b.Dx(), b.Dy()
+	var x0, y0, barH float64
+
+	if kind == BarcodeCode128 {
+		module = min(box.W/float64(cols+2*code128QuietZone), code128MaxModuleMM)
+		if module < code128MinModuleMM {
+			return nil, module, fmt.Errorf("内容が長すぎてバーコードが枠に収まりません（%d文字）", len(bc.Content()))
+		}
+		x0, y0 = box.X+code128QuietZone*module, box.Y
+		barH = box.H - barcodeTextHeight
+	} else {
+		module = min(box.W, box.H) / float64(cols+2*qrQuietZone)
+		if module < qrMinModuleMM {
+			return nil, module, fmt.Errorf("内容が長すぎてQRコードが枠に収まりません（%d文字）", len(bc.Content()))
+		}
+		x0 = box.X + (box.W-float64(cols)*module)/2
+		y0 = box.Y + (box.H-float64(rows)*module)/2
+		barH = module
+	}
+
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; {
+			if !isDarkModule(bc, b.Min.X+x, b.Min.Y+y) {
+				x++
+				continue
+			}
+			start := x
+			for x < cols && isDarkModule(bc, b.Min.X+x, b.Min.Y+y) {
+				x++
+			}
+			rects = append(rects, fitBox{X: x0 + float64(start)*module, Y: y0 + float64(y)*barH, W: float64(x-start) * module, H: barH})
+		}
+	}
+	return rects, module, nil
+}
+
+// isDarkModule - モジュールが黒か
+func isDarkModule(bc barcode.Barcode, x, y int) bool {
+	r, g, b, _ := bc.At(x, y).RGBA()
+	return r+g+b < 3*0x8000
+}
+
+// printBarcode - 設定に従ってページにQRコード・バーコードを印字（内容が空・作れない場合はログに記録して印字しない）
+func (c *ReportLabStylePdfClient) printBarcode(item Item) {
+	cfg := currentConfig().PDF.Barcode
+	if cfg.Type == BarcodeNone {
+		return
+	}
+	content, err := barcodeContent(cfg, item, c.pdf.PageNo())
+	if err != nil {
+		writeEventLog("WARN", fmt.Sprintf("バーコードの内容を作成できません: %v", err))
+		return
+	}
+	if content == "" {
+		return
+	}
+	bc, err := encodeBarcode(cfg.Type, content)
+	if err != nil {
+		writeEventLog("WARN", fmt.Sprintf("バーコードを作成できません: %q: %v", content, err))
+		return
+	}
+	box := cfg.box()
+	rects, module, err := layoutBarcode(cfg.Type, bc, box)
+	if err != nil {
+		writeEventLog("WARN", fmt.Sprintf("バーコードを印字しません: %q: %v", content, err))
+		return
+	}
+
+	c.pdf.SetFillColor(0, 0, 0)
+	for _, r := range rects {
+		c.pdf.Rect(r.X, r.Y, r.W, r.H, "F")
+	}
+
+	// Code128は読み取れない場合に備えて内容も印字
+	if cfg.Type == BarcodeCode128 {
+		width := float64(bc.Bounds().Dx()) * module
+		c.pdf.SetFont("yumin", "", 6)
+		c.fitText(fitBox{box.X + code128QuietZone*module, box.Y + box.H - barcodeTextHeight, width, barcodeTextHeight},
+			content, fitStyle{Align: alignCenter, MinSize: 4, MaxSize: 6}, "バーコードの内容")
+	}
+}
@@ -0,0 +1,586 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// rasterizeRects - 矩形（mm）を1mmあたり scale ピクセルの画像に描画（枠の外側は余白）
+func rasterizeRects(rects []fitBox, box fitBox, scale float64) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, int(math.Ceil(box.W*scale)), int(math.Ceil(box.H*scale))))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for _, r := range rects {
+		x0, y0 := int(math.Round((r.X-box.X)*scale)), int(math.Round((r.Y-box.Y)*scale))
+		x1, y1 := int(math.Round((r.X+r.W-box.X)*scale)), int(math.Round((r.Y+r.H-box.Y)*scale))
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				img.SetGray(x, y, color.Gray{})
+			}
+		}
+	}
+	return img
+}
+
+func isDarkPixel(img *image.Gray, x, y int) bool {
+	return img.GrayAt(x, y).Y < 0x80
+}
+
+// darkBounds - 黒いピクセルを囲む範囲
+func darkBounds(img *image.Gray) (minX, minY, maxX, maxY int, ok bool) {
+	b := img.Bounds()
+	minX, minY, maxX, maxY = b.Max.X, b.Max.Y, -1, -1
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if isDarkPixel(img, x, y) {
+				minX, minY = min(minX, x), min(minY, y)
+				maxX, maxY = max(maxX, x), max(maxY, y)
+			}
+		}
+	}
+	return minX, minY, maxX, maxY, maxX >= 0
+}
+
+// --- QRコードの読み取り（歪みのない画像、誤り訂正レベルM、バージョン1〜10） ---
+
+// qrBlocksM - 誤り訂正レベルMのブロック構成（バージョン → ブロックごとのデータコード語数）
+var qrBlocksM = map[int][]int{
+	1: {16}, 2: {28}, 3: {44}, 4: {32, 32}, 5: {43, 43}, 6: {27, 27, 27, 27}, 7: {31, 31, 31, 31},
+	8: {38, 38, 39, 39}, 9: {36, 36, 36, 37, 37}, 10: {43, 43, 43, 43, 44},
+}
+
+// qrAlignment - 位置合わせパターンの中心
+var qrAlignment = map[int][]int{
+	2: {6, 18}, 3: {6, 22}, 4: {6, 26}, 5: {6, 30}, 6: {6, 34},
+	7: {6, 22, 38}, 8: {6, 24, 42}, 9: {6, 26, 46}, 10: {6, 28, 50},
+}
+
+func decodeQR(img *image.Gray) (string, error) {
+	minX, minY, maxX, _, ok := darkBounds(img)
+	if !ok {
+		return "", fmt.Errorf("no QR code found")
+	}
+	// 左上の位置検出パターンの上辺は7モジュール
+	run := 0
+	for x := minX; x <= maxX && isDarkPixel(img, x, minY); x++ {
+		run++
+	}
+	m := float64(run) / 7
+	n := int(math.Round(float64(maxX-minX+1) / m))
+	version := (n - 17) / 4
+	if (n-17)%4 != 0 || qrBlocksM[version] == nil {
+		return "", fmt.Errorf("unsupported QR size %d", n)
+	}
+	dark := func(row, col int) bool {
+		return isDarkPixel(img, minX+int((float64(col)+0.5)*m), minY+int((float64(row)+0.5)*m))
+	}
+
+	// 形式情報（左上）
+	format := 0
+	read := func(row, col int) {
+		format <<= 1
+		if dark(row, col) {
+			format |= 1
+		}
+	}
+	for col := 0; col <= 5; col++ {
+		read(8, col)
+	}
+	read(8, 7)
+	read(8, 8)
+	read(7, 8)
+	for row := 5; row >= 0; row-- {
+		read(row, 8)
+	}
+	format ^= 0x5412
+	data := format >> 10
+	if bch := qrFormatBCH(data); format != data<<10|bch {
+		return "", fmt.Errorf("invalid format information %015b", format)
+	}
+	if data>>3 != 0 {
+		return "", fmt.Errorf("unsupported error correction level %02b", data>>3)
+	}
+	mask := data & 7
+
+	isFunction := func(row, col int) bool {
+		switch {
+		case row < 9 && col < 9, row < 9 && col >= n-8, row >= n-8 && col < 9:
+			return true
+		case row == 6 || col == 6:
+			return true
+		case version >= 7 && ((row < 6 && col >= n-11 && col <= n-9) || (col < 6 && row >= n-11 && row <= n-9)):
+			return true
+		}
+		pos := qrAlignment[version]
+		for _, cy := range pos {
+			for _, cx := range pos {
+				if (cy == 6 && cx == 6) || (cy == 6 && cx == pos[len(pos)-1]) || (cy == pos[len(pos)-1] && cx == 6) {
+					continue
+				}
+				if abs(row-cy) <= 2 && abs(col-cx) <= 2 {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	// 右下から2列ずつ上下に往復して読む
+	var bits []bool
+	up := true
+	for right := n - 1; right > 0; right -= 2 {
+		if right == 6 {
+			right--
+		}
+		for k := 0; k < n; k++ {
+			row := k
+			if up {
+				row = n - 1 - k
+			}
+			for dc := 0; dc < 2; dc++ {
+				col := right - dc
+				if !isFunction(row, col) {
+					bits = append(bits, dark(row, col) != qrMask(mask, row, col))
+				}
+			}
+		}
+		up = !up
+	}
+	codewords := make([]byte, len(bits)/8)
+	for i := range codewords {
+		for _, b := range bits[i*8 : i*8+8] {
+			codewords[i] <<= 1
+			if b {
+				codewords[i] |= 1
+			}
+		}
+	}
+
+	// ブロックのインターリーブを戻す（誤り訂正コード語は使わない）
+	blocks := qrBlocksM[version]
+	dataBlocks := make([][]byte, len(blocks))
+	idx := 0
+	for i := 0; i < blocks[len(blocks)-1]; i++ {
+		for b, size := range blocks {
+			if i < size {
+				dataBlocks[b] = append(dataBlocks[b], codewords[idx])
+				idx++
+			}
+		}
+	}
+	return parseQRData(bytes.Join(dataBlocks, nil), version)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// qrFormatBCH - 形式情報（5bit）のBCH符号
+func qrFormatBCH(data int) int {
+	v := data << 10
+	for i := 14; i >= 10; i-- {
+		if v>>i&1 == 1 {
+			v ^= 0x537 << (i - 10)
+		}
+	}
+	return v
+}
+
+// qrMask - マスクパターン（row: 行、col: 列）
+func qrMask(mask, row, col int) bool {
+	switch mask {
+	case 0:
+		return (row+col)%2 == 0
+	case 1:
+		return row%2 == 0
+	case 2:
+		return col%3 == 0
+	case 3:
+		return (row+col)%3 == 0
+	case 4:
+		return (row/2+col/3)%2 == 0
+	case 5:
+		return (row*col)%2+(row*col)%3 == 0
+	case 6:
+		return ((row*col)%2+(row*col)%3)%2 == 0
+	default:
+		return ((row+col)%2+(row*col)%3)%2 == 0
+	}
+}
+
+// parseQRData - データコード語を数字・英数字・8bitバイトのモードで読む
+func parseQRData(data []byte, version int) (string, error) {
+	pos := 0
+	next := func(n int) int {
+		v := 0
+		for i := 0; i < n; i++ {
+			if pos/8 >= len(data) {
+				return -1
+			}
+			v = v<<1 | int(data[pos/8]>>(7-pos%8)&1)
+			pos++
+		}
+		return v
+	}
+	countBits := func(small, large int) int {
+		if version <= 9 {
+			return small
+		}
+		return large
+	}
+	const alnum = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+	var out strings.Builder
+	for {
+		mode := next(4)
+		switch mode {
+		case -1, 0:
+			return out.String(), nil
+		case 1: // 数字
+			for count := next(countBits(10, 12)); count > 0; count -= 3 {
+				switch {
+				case count >= 3:
+					fmt.Fprintf(&out, "%03d", next(10))
+				case count == 2:
+					fmt.Fprintf(&out, "%02d", next(7))
+				default:
+					fmt.Fprintf(&out, "%d", next(4))
+				}
+			}
+		case 2: // 英数字
+			for count := next(countBits(9, 11)); count > 0; count -= 2 {
+				if count >= 2 {
+					v := next(11)
+					out.WriteByte(alnum[v/45])
+					out.WriteByte(alnum[v%45])
+				} else {
+					out.WriteByte(alnum[next(6)])
+				}
+			}
+		case 4: // 8bitバイト
+			for count := next(countBits(8, 16)); count > 0; count-- {
+				out.WriteByte(byte(next(8)))
+			}
+		case 7: // ECI
+			next(8)
+		default:
+			return "", fmt.Errorf("unsupported QR mode %04b", mode)
+		}
+	}
+}
+
+// --- Code128の読み取り ---
+
+// code128Patterns - シンボル値ごとのバーとスペースの幅（103〜105はスタート、106はストップ）
+var code128Patterns = []string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+func decodeCode128(img *image.Gray) (string, error) {
+	minX, minY, maxX, _, ok := darkBounds(img)
+	if !ok {
+		return "", fmt.Errorf("no barcode found")
+	}
+	y := minY + 2
+	var runs []int
+	for x := minX; x <= maxX; {
+		start, dark := x, isDarkPixel(img, x, y)
+		for x <= maxX && isDarkPixel(img, x, y) == dark {
+			x++
+		}
+		runs = append(runs, x-start)
+	}
+	if len(runs) < 6 {
+		return "", fmt.Errorf("too few bars")
+	}
+	unit := 0.0
+	for _, w := range runs[:6] {
+		unit += float64(w)
+	}
+	unit /= 11
+
+	lookup := map[string]int{}
+	for v, p := range code128Patterns {
+		lookup[p] = v
+	}
+	var values []int
+	for i := 0; i < len(runs); {
+		size := 6
+		if len(runs)-i == 7 {
+			size = 7 // ストップ
+		}
+		if i+size > len(runs) {
+			return "", fmt.Errorf("truncated symbol at %d", i)
+		}
+		var p strings.Builder
+		for _, w := range runs[i : i+size] {
+			fmt.Fprintf(&p, "%d", int(math.Round(float64(w)/unit)))
+		}
+		v, ok := lookup[p.String()]
+		if !ok {
+			return "", fmt.Errorf("unknown symbol %s", p.String())
+		}
+		values = append(values, v)
+		i += size
+	}
+	if len(values) < 3 || values[len(values)-1] != 106 {
+		return "", fmt.Errorf("missing stop symbol")
+	}
+	start, data, check := values[0], values[1:len(values)-2], values[len(values)-2]
+	sum := start
+	for i, v := range data {
+		sum += (i + 1) * v
+	}
+	if sum%103 != check {
+		return "", fmt.Errorf("checksum mismatch")
+	}
+
+	set := map[int]byte{103: 'A', 104: 'B', 105: 'C'}[start]
+	var out strings.Builder
+	for _, v := range data {
+		switch {
+		case set == 'C' && v < 100:
+			fmt.Fprintf(&out, "%02d", v)
+		case v == 99 && set != 'C':
+			set = 'C'
+		case v == 100 && set != 'B':
+			set = 'B'
+		case v == 101 && set != 'A':
+			set = 'A'
+		case v == 102: // FNC1
+		case set == 'A' && v < 64, set == 'B' && v < 96:
+			out.WriteByte(byte(v + 32))
+		case set == 'A' && v < 96:
+			out.WriteByte(byte(v - 64))
+		default:
+			return "", fmt.Errorf("unsupported symbol %d in set %c", v, set)
+		}
+	}
+	return out.String(), nil
+}
+
+// scanBarcode - バーコードを枠に配置して画像にし、読み取る
+func scanBarcode(t *testing.T, kind, content string, box fitBox) string {
+	t.Helper()
+	bc, err := encodeBarcode(kind, content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rects, module, err := layoutBarcode(kind, bc, box)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := rasterizeRects(rects, box, 4/module) // 1モジュール4ピクセル
+	if kind == BarcodeCode128 {
+		got, err := decodeCode128(img)
+		if err != nil {
+			t.Fatalf("decodeCode128(%q): %v", content, err)
+		}
+		return got
+	}
+	got, err := decodeQR(img)
+	if err != nil {
+		t.Fatalf("decodeQR(%q): %v", content, err)
+	}
+	return got
+}
+
+func TestBarcodeScan(t *testing.T) {
+	longURL := "https://expense.example.com/records/R-2025-0001?payDay=2025-01-15&name=" + url.QueryEscape("山田　太郎") + "&office=tokyo-head"
+	tests := []struct {
+		name    string
+		kind    string
+		content string
+		box     fitBox
+	}{
+		{"QRコード（URL）", BarcodeQR, "https://expense.example.com/records/R-2025-0001", barcodeRemarksBox},
+		{"QRコード（長いURL）", BarcodeQR, longURL, barcodeRemarksBox},
+		{"QRコード（英数字）", BarcodeQR, "R-2025-0001", barcodeRemarksBox},
+		{"QRコード（数字）", BarcodeQR, "20250115", barcodeRemarksBox},
+		{"QRコード（用紙の下）", BarcodeQR, "R-2025-0001", fitBox{barcodeFooterBox.X, barcodeFooterBox.Y, barcodeFooterBox.H, barcodeFooterBox.H}},
+		{"Code128（ID）", BarcodeCode128, "R-2025-0001", barcodeFooterBox},
+		{"Code128（数字）", BarcodeCode128, "20250115", barcodeFooterBox},
+		{"Code128（小文字）", BarcodeCode128, "rec/2025/abc?p=1", barcodeFooterBox},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scanBarcode(t, tt.kind, tt.content, tt.box); got != tt.content {
+				t.Errorf("scanned %q, want %q", got, tt.content)
+			}
+		})
+	}
+}
+
+func TestLayoutBarcodeTooLong(t *testing.T) {
+	tests := []struct {
+		kind string
+		box  fitBox
+	}{
+		{BarcodeQR, barcodeRemarksBox},
+		{BarcodeQR, barcodeFooterBox},
+	}
+	long := strings.Repeat("https://expense.example.com/", 10) // Code128は80文字までのため、用紙の下の枠には常に収まる
+	for _, tt := range tests {
+		bc, err := encodeBarcode(tt.kind, long)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := layoutBarcode(tt.kind, bc, tt.box); err == nil {
+			t.Errorf("layoutBarcode(%s) with %d characters should fail", tt.kind, len(long))
+		}
+	}
+}
+
+func TestBarcodeContent(t *testing.T) {
+	item := Item{
+		ID:        StringPtr(" R-2025-0001 "),
+		Name:      "山田　太郎",
+		PayDay:    StringPtr("2025/01/15"),
+		StartDate: StringPtr("2025-01-06T09:00:00+09:00"),
+	}
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"https://expense.example.com/records/{{.ID}}", "https://expense.example.com/records/R-2025-0001"},
+		{"{{.ID}}-{{.PayDay}}-p{{.Page}}", "R-2025-0001-2025-01-15-p2"},
+		{"?name={{.Name | urlquery}}&from={{.StartDate}}", "?name=" + url.QueryEscape("山田　太郎") + "&from=2025-01-06"},
+		{"{{.EndDate}}", ""},
+	}
+	for _, tt := range tests {
+		got, err := barcodeContent(BarcodeConfig{Type: BarcodeQR, Content: tt.content}, item, 2)
+		if err != nil || got != tt.want {
+			t.Errorf("barcodeContent(%q) = %q, %v, want %q", tt.content, got, err, tt.want)
+		}
+	}
+}
+
+func TestBarcodeContentEscapesURLValues(t *testing.T) {
+	item := Item{
+		ID:     StringPtr("A&B #1?x/y"),
+		Name:   "山田 太郎",
+		Office: StringPtr("東京本社&大阪支店"),
+	}
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"https://expense.example.com/records/{{.ID}}?office={{.Office}}&p={{.Page}}",
+			"https://expense.example.com/records/A%26B%20%231%3Fx%2Fy?office=" + escapeURLComponent("東京本社&大阪支店") + "&p=3"},
+		{"HTTPS://example.com/?name={{.Name | urlquery}}", "HTTPS://example.com/?name=" + url.QueryEscape("山田 太郎")},
+		{"https://example.com/{{raw .ID}}", "https://example.com/A&B #1?x/y"},
+		{"https://example.com/{{if .ID}}{{.ID}}{{else}}none{{end}}{{with .Office}}/{{.}}{{end}}",
+			"https://example.com/A%26B%20%231%3Fx%2Fy/" + escapeURLComponent("東京本社&大阪支店")},
+		{"{{.ID}} {{.Name}}", "A&B #1?x/y 山田 太郎"}, // URL以外はそのまま
+	}
+	for _, tt := range tests {
+		got, err := barcodeContent(BarcodeConfig{Type: BarcodeQR, Content: tt.content}, item, 3)
+		if err != nil || got != tt.want {
+			t.Errorf("barcodeContent(%q) = %q, %v, want %q", tt.content, got, err, tt.want)
+		}
+	}
+
+	// 読み取った側で元の値に戻る
+	got, err := barcodeContent(BarcodeConfig{Type: BarcodeQR, Content: tests[0].content}, item, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Path != "/records/A&B #1?x/y" || u.Query().Get("office") != "東京本社&大阪支店" || u.Query().Get("p") != "3" || u.Fragment != "" {
+		t.Errorf("parsed URL = path %q, query %v, fragment %q", u.Path, u.Query(), u.Fragment)
+	}
+}
+
+func TestLoadConfigBarcode(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       string
+		wantPosition string
+		wantErr      string
+	}{
+		{name: "既定は印字しない", config: `{}`, wantPosition: BarcodePositionRemarks},
+		{name: "QRコード", config: `{"pdf":{"barcode":{"type":"qr","content":"{{.ID}}"}}}`, wantPosition: BarcodePositionRemarks},
+		{name: "Code128", config: `{"pdf":{"barcode":{"type":"code128","content":"{{.ID}}"}}}`, wantPosition: BarcodePositionFooter},
+		{name: "位置の指定", config: `{"pdf":{"barcode":{"type":"qr","content":"{{.ID}}","position":"footer"}}}`, wantPosition: BarcodePositionFooter},
+		{name: "不明な種類", config: `{"pdf":{"barcode":{"type":"ean13","content":"{{.ID}}"}}}`, wantErr: "バーコードの種類"},
+		{name: "不明な位置", config: `{"pdf":{"barcode":{"type":"qr","content":"{{.ID}}","position":"top"}}}`, wantErr: "バーコードの位置"},
+		{name: "内容がない", config: `{"pdf":{"barcode":{"type":"qr"}}}`, wantErr: "content"},
+		{name: "テンプレートの構文エラー", config: `{"pdf":{"barcode":{"type":"qr","content":"{{.ID"}}}`, wantErr: "テンプレートが不正"},
+		{name: "存在しない項目", config: `{"pdf":{"barcode":{"type":"qr","content":"{{.RecordID}}"}}}`, wantErr: "テンプレートが不正"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), configFileName)
+			if err := os.WriteFile(path, []byte(tt.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := loadConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := cfg.PDF.Barcode.position(); got != tt.wantPosition {
+				t.Errorf("position = %q, want %q", got, tt.wantPosition)
+			}
+		})
+	}
+}
+
+func TestRenderPDFWithBarcode(t *testing.T) {
+	original := currentConfig()
+	t.Cleanup(func() { setConfig(original) })
+
+	items := []Item{
+		{ID: StringPtr("R-2025-0001"), Name: "山田　太郎", Description: StringPtr(strings.Repeat("備考", 60))},
+		{Name: "IDなし"},                    // 内容が空のため印字しない
+		{ID: StringPtr("山田"), Name: "佐藤"}, // Code128では印字できない文字
+	}
+	for _, bc := range []BarcodeConfig{
+		{Type: BarcodeQR, Content: "https://expense.example.com/records/{{.ID}}?page={{.Page}}"},
+		{Type: BarcodeQR, Content: "{{.ID}}", Position: BarcodePositionFooter},
+		{Type: BarcodeCode128, Content: "{{.ID}}"},
+	} {
+		cfg := defaultConfig()
+		cfg.PDF.Barcode = bc
+		setConfig(cfg)
+
+		var buf bytes.Buffer
+		pages, err := RenderPDF(&buf, "", items)
+		if err != nil {
+			t.Fatalf("%+v: %v", bc, err)
+		}
+		if pages != len(items) {
+			t.Errorf("%+v: pages = %d, want %d", bc, pages, len(items))
+		}
+	}
+}
@@ -0,0 +1,486 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// コマンドライン
+//   print_pdf                       サービスマネージャーから起動された場合はサービス、それ以外はコンソールで実行
+//   print_pdf serve [-config path]  同上（サービス登録時はこの形で登録する）
+//   print_pdf service <action>      サービスの登録・削除・開始・停止・状態確認
+//   print_pdf render / print        サーバーを起動せずにPDFを生成・印刷（cli_render.go）
+//   print_pdf version
+
+// サービス説明（サービスマネージャーに登録する）
+const serviceDescription = "PDF Generator HTTP API Service"
+
+// 障害時の回復設定（異常終了した場合に再起動する間隔。失敗回数は1日でリセット）
+var (
+	serviceRestartDelays = []time.Duration{5 * time.Second, 30 * time.Second, 60 * time.Second}
+	serviceResetPeriod   = 24 * time.Hour
+)
+
+// selfHealthURL - 実行中のサーバーの /v1/health（ウォッチドッグ・状態表示で使用。テストで差し替え可能）
+var selfHealthURL = "http://127.0.0.1" + httpPort + "/v1/health"
+
+// cliCommand - サブコマンドの定義
+type cliCommand struct {
+	name    string
+	args    string // 使い方に表示する引数
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) error
+}
+
+// usageError - 引数の誤り（使い方を表示して終了コード2）
+type usageError struct{ msg string }
+
+func (e usageError) Error() string { return e.msg }
+
+// exitCodeError - メッセージを出さずに終了コードだけを返す（status で停止中の場合など）
+type exitCodeError struct{ code int }
+
+func (e exitCodeError) Error() string { return fmt.Sprintf("exit status %d", e.code) }
+
+// serviceInstallOptions - サービス登録時の設定
+type serviceInstallOptions struct {
+	Executable string // 実行ファイルの絶対パス
+	ConfigFile string // 設定ファイル（空の場合は実行ファイルと同じフォルダ）
+	User       string // 実行アカウント（空の場合は既定のアカウント）
+	Password   string
+}
+
+// serviceState - サービスの状態
+type serviceState struct {
+	Installed   bool
+	State       string // running, stopped, paused, start-pending 等
+	PID         int
+	StartType   string // auto, manual, disabled 等
+	Command     string // 登録されているコマンドライン
+	Account     string
+	Recovery    string // 障害時の回復設定
+	LogSource   string // ログの出力先（イベントログのソース等）
+	LogReady    bool   // ログの出力先が登録済みか
+	UnitFile    string // systemd のユニットファイル（Linuxのみ）
+	Description string
+}
+
+// serviceControl - プラットフォームごとのサービス管理（Windows: SCM、Linux: systemd）
+type serviceControl interface {
+	Install(opts serviceInstallOptions) error
+	Uninstall() error
+	Start() error
+	Stop() error
+	Status() (serviceState, error)
+}
+
+// newServiceControl - サービス管理の実装を取得（テストで差し替え可能）
+var newServiceControl = newPlatformServiceControl
+
+// errServiceNotInstalled - サービスが登録されていない
+var errServiceNotInstalled = errors.New("service is not installed")
+
+// cliCommands - トップレベルのサブコマンド
+func cliCommands() []cliCommand {
+	return []cliCommand{
+		{"serve", "[-config path]", "Run the HTTP server (as a service when started by the service manager)", runServeCommand},
+		{"service", "<install|uninstall|start|stop|status>", "Manage the system service", runServiceCommand},
+		{"render", "[-in request.json] [-out out.pdf] [-template id]", "Render a PDF from JSON without starting the server (stdin/stdout by default)", runRenderCommand},
+		{"print", "[-printer name] file.pdf", "Print a PDF file with the same backend as the server", runPrintCommand},
+		{"version", "", "Print the version", runVersionCommand},
+		{"help", "", "Show this help", runHelpCommand},
+	}
+}
+
+// serviceCommands - service のサブコマンド
+func serviceCommands() []cliCommand {
+	return []cliCommand{
+		{"install", "[-config path] [-user name [-password-stdin]] [-no-start]", "Register the service, recovery options and log source, then start it", runServiceInstall},
+		{"uninstall", "", "Stop and remove the service and its log source", runServiceUninstall},
+		{"start", "", "Start the service and wait until it is running", runServiceStart},
+		{"stop", "", "Stop the service and wait for pending print jobs", runServiceStop},
+		{"status", "", "Show the service state (exit code 3 when not running)", runServiceStatus},
+	}
+}
+
+// runCLI - コマンドラインを解析して実行し、終了コードを返す
+func runCLI(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+	err := dispatchCommand("print_pdf", cliCommands(), args, stdout, stderr)
+	return cliExitCode(err, stderr)
+}
+
+// dispatchCommand - 先頭の引数に一致するサブコマンドを実行
+func dispatchCommand(prefix string, commands []cliCommand, args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		printUsage(stderr, prefix, commands)
+		return usageError{fmt.Sprintf("%s: missing command", prefix)}
+	}
+	name := args[0]
+	if name == "-h" || name == "-help" || name == "--help" {
+		printUsage(stdout, prefix, commands)
+		return nil
+	}
+	for _, c := range commands {
+		if c.name == name {
+			return c.run(args[1:], stdout, stderr)
+		}
+	}
+	printUsage(stderr, prefix, commands)
+	return usageError{fmt.Sprintf("%s: unknown command %q", prefix, name)}
+}
+
+// cliExitCode - エラーを終了コードに変換（0: 成功、1: 失敗、2: 引数の誤り、その他: exitCodeError の値）
+func cliExitCode(err error, stderr io.Writer) int {
+	var usage usageError
+	var exit exitCodeError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &exit):
+		return exit.code
+	case errors.As(err, &usage):
+		fmt.Fprintln(stderr, usage.msg)
+		return 2
+	default:
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+}
+
+// printUsage - サブコマンドの一覧を表示
+func printUsage(w io.Writer, prefix string, commands []cliCommand) {
+	fmt.Fprintf(w, "Usage: %s <command> [arguments]\n\nCommands:\n", prefix)
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.summary)
+		if c.args != "" {
+			fmt.Fprintf(w, "  %-10s   %s %s %s\n", "", prefix, c.name, c.args)
+		}
+	}
+}
+
+// newFlagSet - サブコマンド用のフラグ定義（-h で使い方を表示）
+func newFlagSet(name, args string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: %s %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags - フラグを解析（余分な引数は誤り）
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError{fmt.Sprintf("%s: %v", fs.Name(), err)}
+	}
+	if fs.NArg() > 0 {
+		return usageError{fmt.Sprintf("%s: unexpected argument %q", fs.Name(), fs.Arg(0))}
+	}
+	return nil
+}
+
+// runServeCommand - 設定を読み込んでサーバーを実行
+func runServeCommand(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("print_pdf serve", "[-config path]", stderr)
+	config := fs.String("config", "", "configuration file (default: print_pdf_config.json next to the executable, or $PRINT_PDF_CONFIG)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *config != "" {
+		// 設定の再読み込みでも同じファイルを使うよう環境変数で渡す
+		os.Setenv("PRINT_PDF_CONFIG", *config)
+	}
+
+	// 設定を読み込んでロガーを初期化
+	cfg, err := loadConfig(configPath())
+	if err != nil {
+		log.Printf("%v（既定値で継続）", err)
+	}
+	setConfig(cfg)
+	if err := setupLogger(cfg.Log); err != nil {
+		log.Printf("ロガー設定エラー: %v", err)
+	}
+
+	// サービス（Windows SCM / systemd）またはコンソールアプリケーションとして実行
+	runService()
+	return nil
+}
+
+// loadCLIConfig - サーバー以外のコマンドで設定を読み込む（読み込めない場合は既定値）
+func loadCLIConfig() {
+	cfg, _ := loadConfig(configPath())
+	setConfig(cfg)
+}
+
+// useCLILogger - ログを stderr に出力（標準出力はPDFやコマンドの結果に使う）
+// 通常は警告以上のみ、verbose の場合はデバッグログも出力
+func useCLILogger(w io.Writer, verbose bool) {
+	level := slog.LevelWarn
+	if verbose {
+		level = slog.LevelDebug
+	}
+	logLevel.Set(level)
+	logger.Store(slog.New(&requestContextHandler{Handler: newConsoleHandler(w, logLevel)}))
+}
+
+// runVersionCommand - バージョンを表示
+func runVersionCommand(args []string, stdout, stderr io.Writer) error {
+	if err := parseFlags(newFlagSet("print_pdf version", "", stderr), args); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "print_pdf %s\n", Version)
+	return nil
+}
+
+// runHelpCommand - 使い方を表示
+func runHelpCommand(args []string, stdout, stderr io.Writer) error {
+	printUsage(stdout, "print_pdf", cliCommands())
+	fmt.Fprintln(stdout)
+	printUsage(stdout, "print_pdf service", serviceCommands())
+	return nil
+}
+
+// runServiceCommand - print_pdf service <action>
+func runServiceCommand(args []string, stdout, stderr io.Writer) error {
+	// 停止を待つ時間等に設定を使う
+	loadCLIConfig()
+	return dispatchCommand("print_pdf service", serviceCommands(), args, stdout, stderr)
+}
+
+// runServiceInstall - サービスを登録して開始
+func runServiceInstall(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("print_pdf service install", "[-config path] [-user name [-password-stdin]] [-no-start]", stderr)
+	config := fs.String("config", "", "configuration file the service should use")
+	user := fs.String("user", "", "account to run the service as (needed to reach per-user printers)")
+	passwordStdin := fs.Bool("password-stdin", false, "read the password of -user from the first line of stdin (Windows only)")
+	password := fs.String("password", "", "password of -user (visible in process listings; prefer -password-stdin or "+servicePasswordEnv+")")
+	noStart := fs.Bool("no-start", false, "register the service without starting it")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	pw, err := servicePassword(*password, *passwordStdin, stderr)
+	if err != nil {
+		return err
+	}
+
+	exe, err := executablePath()
+	if err != nil {
+		return fmt.Errorf("cannot locate executable: %w", err)
+	}
+	opts := serviceInstallOptions{Executable: exe, User: *user, Password: pw}
+	if *config != "" {
+		if opts.ConfigFile, err = filepath.Abs(*config); err != nil {
+			return err
+		}
+		cfg, err := loadConfig(opts.ConfigFile)
+		if err != nil {
+			return err
+		}
+		setConfig(cfg)
+	}
+
+	ctl := newServiceControl()
+	if err := ctl.Install(opts); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Service %q installed.\n", serviceName)
+	if !*noStart {
+		if err := ctl.Start(); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Service %q started.\n", serviceName)
+	}
+	fmt.Fprintln(stdout)
+	_, err = printServiceStatus(stdout, ctl)
+	return err
+}
+
+// servicePasswordEnv - サービスの実行アカウントのパスワードを渡す環境変数
+const servicePasswordEnv = "PRINT_PDF_SERVICE_PASSWORD"
+
+// servicePassword - 実行アカウントのパスワード（-password-stdin、環境変数、-password の順）
+// -password はプロセス一覧やシェルの履歴に残るため、使った場合は警告する
+func servicePassword(flagValue string, fromStdin bool, stderr io.Writer) (string, error) {
+	if fromStdin {
+		if flagValue != "" {
+			return "", usageError{"print_pdf service install: -password and -password-stdin cannot be used together"}
+		}
+		line, err := bufio.NewReader(cliStdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("cannot read password from stdin: %w", err)
+		}
+		password := strings.TrimRight(line, "\r\n")
+		if password == "" {
+			return "", errors.New("-password-stdin: no password on stdin")
+		}
+		return password, nil
+	}
+	if flagValue != "" {
+		fmt.Fprintf(stderr, "warning: -password is visible in process listings and shell history; use -password-stdin or %s instead\n", servicePasswordEnv)
+		return flagValue, nil
+	}
+	return os.Getenv(servicePasswordEnv), nil
+}
+
+// runServiceUninstall - サービスを停止して削除
+func runServiceUninstall(args []string, stdout, stderr io.Writer) error {
+	if err := parseFlags(newFlagSet("print_pdf service uninstall", "", stderr), args); err != nil {
+		return err
+	}
+	if err := newServiceControl().Uninstall(); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Service %q removed.\n", serviceName)
+	return nil
+}
+
+// runServiceStart - サービスを開始
+func runServiceStart(args []string, stdout, stderr io.Writer) error {
+	if err := parseFlags(newFlagSet("print_pdf service start", "", stderr), args); err != nil {
+		return err
+	}
+	ctl := newServiceControl()
+	if err := ctl.Start(); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Service %q started.\n\n", serviceName)
+	_, err := printServiceStatus(stdout, ctl)
+	return err
+}
+
+// runServiceStop - サービスを停止（印刷中のジョブの完了を待つ）
+func runServiceStop(args []string, stdout, stderr io.Writer) error {
+	if err := parseFlags(newFlagSet("print_pdf service stop", "", stderr), args); err != nil {
+		return err
+	}
+	if err := newServiceControl().Stop(); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Service %q stopped.\n", serviceName)
+	return nil
+}
+
+// runServiceStatus - サービスの状態を表示（実行中でなければ終了コード3）
+func runServiceStatus(args []string, stdout, stderr io.Writer) error {
+	if err := parseFlags(newFlagSet("print_pdf service status", "", stderr), args); err != nil {
+		return err
+	}
+	state, err := printServiceStatus(stdout, newServiceControl())
+	if err != nil {
+		return err
+	}
+	if state.State != "running" {
+		return exitCodeError{3}
+	}
+	return nil
+}
+
+// printServiceStatus - サービスマネージャーの情報と /v1/health の応答を表示
+func printServiceStatus(w io.Writer, ctl serviceControl) (serviceState, error) {
+	state, err := ctl.Status()
+	if err != nil {
+		return state, err
+	}
+	var health *HealthResponse
+	if state.State == "running" {
+		if h, err := fetchSelfHealth(2 * time.Second); err == nil {
+			health = &h
+		}
+	}
+	writeServiceStatus(w, state, health)
+	return state, nil
+}
+
+// writeServiceStatus - 状態を整形して出力
+func writeServiceStatus(w io.Writer, state serviceState, health *HealthResponse) {
+	row := func(label, value string) {
+		if value != "" {
+			fmt.Fprintf(w, "%-13s %s\n", label+":", value)
+		}
+	}
+	row("Service", serviceName)
+	if !state.Installed {
+		row("State", "not installed")
+		return
+	}
+	status := state.State
+	if state.PID > 0 {
+		status += fmt.Sprintf(" (pid %d)", state.PID)
+	}
+	row("State", status)
+	row("Startup", state.StartType)
+	row("Command", state.Command)
+	row("Account", state.Account)
+	row("Recovery", state.Recovery)
+	row("Unit file", state.UnitFile)
+	if state.LogSource != "" {
+		registered := "registered"
+		if !state.LogReady {
+			registered = "not registered"
+		}
+		row("Log", fmt.Sprintf("%s (%s)", state.LogSource, registered))
+	}
+
+	switch {
+	case state.State != "running":
+	case health == nil:
+		row("HTTP", fmt.Sprintf("not responding at %s", selfHealthURL))
+	default:
+		api := fmt.Sprintf("%s, version %s", health.Status, health.Version)
+		if health.Paused {
+			api += ", paused (not accepting print jobs)"
+		}
+		row("HTTP", fmt.Sprintf("%s at %s", api, strings.TrimSuffix(selfHealthURL, "/v1/health")))
+	}
+}
+
+// formatRecovery - 回復設定を表示用に整形
+func formatRecovery(delays []time.Duration, reset time.Duration) string {
+	if len(delays) == 0 {
+		return "none"
+	}
+	steps := make([]string, len(delays))
+	for i, d := range delays {
+		steps[i] = fmt.Sprintf("restart after %v", d)
+	}
+	return fmt.Sprintf("%s; reset after %v", strings.Join(steps, ", "), reset)
+}
+
+// fetchSelfHealth - 実行中のサーバーの /v1/health を取得
+func fetchSelfHealth(timeout time.Duration) (HealthResponse, error) {
+	var health HealthResponse
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", selfHealthURL, nil)
+	if err != nil {
+		return health, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return health, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return health, errors.New(resp.Status)
+	}
+	return health, json.NewDecoder(resp.Body).Decode(&health)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// サーバーを起動せずにPDFを生成・印刷するコマンド
+//   print_pdf render -in request.json -out out.pdf [-template id]
+//   print_pdf print -printer X file.pdf
+
+// cliStdin - 標準入力（テストで差し替え可能）
+var cliStdin io.Reader = os.Stdin
+
+// runRenderCommand - JSON（PrintRequest または Item 配列）からPDFを生成
+func runRenderCommand(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("print_pdf render", "[-in request.json] [-out out.pdf] [-template id] [-v]", stderr)
+	in := fs.String("in", "-", "input JSON: a PrintRequest object or an array of items (- for stdin)")
+	out := fs.String("out", "-", "output PDF file (- for stdout)")
+	templateID := fs.String("template", defaultTemplateID, "template id ("+strings.Join(pdfTemplateIDs(), ", ")+")")
+	verbose := fs.Bool("v", false, "log rendering details to stderr")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	useCLILogger(stderr, *verbose)
+	loadCLIConfig()
+
+	if _, err := findPDFTemplate(*templateID); err != nil {
+		return usageError{"print_pdf render: " + err.Error()}
+	}
+	body, err := readCLIInput(*in)
+	if err != nil {
+		return err
+	}
+	req, _, err := decodeLegacyPrintRequest(body)
+	if err != nil {
+		return fmt.Errorf("%s: invalid JSON (expected a PrintRequest object or an array of items): %w", inputName(*in), err)
+	}
+	if errs := validatePrintRequest(req); len(errs) > 0 {
+		return fmt.Errorf("%s: %s", inputName(*in), formatFieldErrors(errs))
+	}
+
+	if *out == "-" {
+		pages, err := RenderPDFWithOptions(stdout, *templateID, req.Items, req.renderOptions())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "Rendered %d page(s) with template %s\n", pages, *templateID)
+		return nil
+	}
+
+	var buf bytes.Buffer
+	pages, err := RenderPDFWithOptions(&buf, *templateID, req.Items, req.renderOptions())
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(*out, buf.Bytes()); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Rendered %d page(s) with template %s to %s (%d bytes)\n", pages, *templateID, *out, buf.Len())
+	return nil
+}
+
+// runPrintCommand - PDFファイルをサーバーと同じ方法で印刷（Windows: SumatraPDF、Linux: lp）
+func runPrintCommand(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("print_pdf print", "[-printer name] file.pdf", stderr)
+	printer := fs.String("printer", "", "printer name (default printer when omitted)")
+	verbose := fs.Bool("v", false, "log printing details to stderr")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError{fmt.Sprintf("%s: %v", fs.Name(), err)}
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return usageError{"print_pdf print: exactly one PDF file (or - for stdin) is required"}
+	}
+	useCLILogger(stderr, *verbose)
+	loadCLIConfig()
+
+	path := fs.Arg(0)
+	data, err := readCLIInput(path)
+	if err != nil {
+		return err
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return fmt.Errorf("%s: not a PDF document", inputName(path))
+	}
+
+	// 標準入力の場合は一時ファイルに保存してから印刷
+	if path == "-" {
+		tmp, err := os.CreateTemp("", "print_pdf_*.pdf")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		_, err = tmp.Write(data)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		path = tmp.Name()
+	}
+
+	if err := printPDF(path, *printer); err != nil {
+		return fmt.Errorf("printing failed: %w", err)
+	}
+	printerName := *printer
+	if printerName == "" {
+		printerName = "the default printer"
+	}
+	fmt.Fprintf(stdout, "Printed %s on %s\n", inputName(fs.Arg(0)), printerName)
+	return nil
+}
+
+// readCLIInput - ファイル（- の場合は標準入力）を読み込む
+func readCLIInput(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(cliStdin)
+	}
+	return os.ReadFile(path)
+}
+
+// inputName - メッセージに表示する入力名
+func inputName(path string) string {
+	if path == "-" {
+		return "stdin"
+	}
+	return path
+}
+
+// formatFieldErrors - 入力検証エラーを1行にまとめる
+func formatFieldErrors(errs []FieldError) string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Field + ": " + e.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// writeFileAtomic - 同じフォルダの一時ファイルに書き込んでから置き換える（途中で失敗しても壊れたPDFを残さない）
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
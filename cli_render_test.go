@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withCLIEnv - CLIコマンドが変更するロガー・設定・標準入力を元に戻す
+func withCLIEnv(t *testing.T, stdin string) {
+	t.Helper()
+	originalLogger, originalLevel, originalConfig, originalStdin := logger.Load(), logLevel.Level(), currentConfig(), cliStdin
+	cliStdin = strings.NewReader(stdin)
+	t.Setenv("PRINT_PDF_CONFIG", filepath.Join(t.TempDir(), "missing.json"))
+	t.Cleanup(func() {
+		logger.Store(originalLogger)
+		logLevel.Set(originalLevel)
+		setConfig(originalConfig)
+		cliStdin = originalStdin
+	})
+}
+
+func TestRenderCommand(t *testing.T) {
+	dir := t.TempDir()
+	fixture := func(name string) string {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		out        string // 出力ファイル（空の場合は標準出力にPDF）
+		wantCode   int
+		wantPages  string
+		wantStderr string
+	}{
+		{name: "item array file", args: []string{"-in", "test_items.json"}, out: "items.pdf", wantPages: "Rendered 2 page(s)"},
+		{name: "print request file", args: []string{"-in", "test_print_request.json"}, out: "request.pdf", wantPages: "Rendered 1 page(s)"},
+		{name: "stdin to stdout", stdin: fixture("test_print_request.json"), wantPages: "Rendered 1 page(s)"},
+		{name: "explicit template", args: []string{"-in", "test_items.json", "-template", defaultTemplateID}, out: "template.pdf", wantPages: "Rendered 2 page(s)"},
+		{name: "unknown template", args: []string{"-in", "test_items.json", "-template", "invoice"}, wantCode: 2, wantStderr: `unknown template "invoice" (available: travel-expense)`},
+		{name: "not an item shape", args: []string{"-in", "test_request.json"}, wantCode: 1, wantStderr: "test_request.json: items: at least one item is required"},
+		{name: "no items", stdin: `[]`, wantCode: 1, wantStderr: "stdin: items: at least one item is required"},
+		{name: "no items in a request", stdin: `{"items":[]}`, wantCode: 1, wantStderr: "stdin: items: at least one item is required"},
+		{name: "wrong type in a request", stdin: `{"items":[{"name":1}]}`, wantCode: 1, wantStderr: "PrintRequest.items.0.name"},
+		{name: "missing file", args: []string{"-in", filepath.Join(dir, "missing.json")}, wantCode: 1, wantStderr: "missing.json"},
+		{name: "positional argument", args: []string{"test_items.json"}, wantCode: 2, wantStderr: `unexpected argument "test_items.json"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withCLIEnv(t, tt.stdin)
+			args := append([]string{"render"}, tt.args...)
+			outPath := ""
+			if tt.out != "" {
+				outPath = filepath.Join(dir, tt.out)
+				args = append(args, "-out", outPath)
+			}
+
+			var stdout, stderr bytes.Buffer
+			code := runCLI(args, &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Fatalf("exit code = %d, want %d (stderr: %s)", code, tt.wantCode, stderr.String())
+			}
+			if tt.wantStderr != "" && !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr missing %q:\n%s", tt.wantStderr, stderr.String())
+			}
+			if tt.wantCode != 0 {
+				return
+			}
+
+			pdf, summary := stdout.Bytes(), stderr.String()
+			if outPath != "" {
+				var err error
+				if pdf, err = os.ReadFile(outPath); err != nil {
+					t.Fatal(err)
+				}
+				summary = stdout.String()
+			}
+			if !bytes.HasPrefix(pdf, []byte("%PDF-")) {
+				t.Errorf("output is not a PDF: %q", pdf[:min(len(pdf), 20)])
+			}
+			if !strings.Contains(summary, tt.wantPages) {
+				t.Errorf("summary %q does not contain %q", summary, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestRenderCommandKeepsExistingFileOnError(t *testing.T) {
+	withCLIEnv(t, "not json")
+	out := filepath.Join(t.TempDir(), "out.pdf")
+	if err := os.WriteFile(out, []byte("previous"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI([]string{"render", "-out", out}, &stdout, &stderr); code != 1 {
+		t.Fatalf("exit code = %d, want 1", code)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "previous" {
+		t.Errorf("existing output was overwritten: %q", data)
+	}
+}
+
+func TestPrintCommand(t *testing.T) {
+	dir := t.TempDir()
+	pdfFile := filepath.Join(dir, "doc.pdf")
+	textFile := filepath.Join(dir, "doc.txt")
+	os.WriteFile(pdfFile, []byte("%PDF-1.3 test"), 0644)
+	os.WriteFile(textFile, []byte("hello"), 0644)
+
+	tests := []struct {
+		name        string
+		args        []string
+		stdin       string
+		wantCode    int
+		wantPrinter string
+		wantStdout  string
+		wantStderr  string
+	}{
+		{name: "default printer", args: []string{pdfFile}, wantStdout: "on the default printer"},
+		{name: "named printer", args: []string{"-printer", "Office", pdfFile}, wantPrinter: "Office", wantStdout: "on Office"},
+		{name: "stdin", args: []string{"-printer", "Office", "-"}, stdin: "%PDF-1.3 piped", wantPrinter: "Office", wantStdout: "Printed stdin"},
+		{name: "not a PDF", args: []string{textFile}, wantCode: 1, wantStderr: "not a PDF document"},
+		{name: "missing file argument", args: []string{"-printer", "Office"}, wantCode: 2, wantStderr: "exactly one PDF file"},
+		{name: "two files", args: []string{pdfFile, pdfFile}, wantCode: 2, wantStderr: "exactly one PDF file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withCLIEnv(t, tt.stdin)
+			var printedPath, printedOn, printedContent string
+			stubPrinter(t, func(pdfPath, printerName string) error {
+				printedPath, printedOn = pdfPath, printerName
+				data, _ := os.ReadFile(pdfPath)
+				printedContent = string(data)
+				return nil
+			})
+
+			var stdout, stderr bytes.Buffer
+			code := runCLI(append([]string{"print"}, tt.args...), &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Fatalf("exit code = %d, want %d (stderr: %s)", code, tt.wantCode, stderr.String())
+			}
+			if tt.wantStderr != "" && !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr missing %q:\n%s", tt.wantStderr, stderr.String())
+			}
+			if tt.wantCode != 0 {
+				if printedPath != "" {
+					t.Errorf("printPDF should not be called, got %s", printedPath)
+				}
+				return
+			}
+			if printedOn != tt.wantPrinter {
+				t.Errorf("printer = %q, want %q", printedOn, tt.wantPrinter)
+			}
+			if !strings.HasPrefix(printedContent, "%PDF-") {
+				t.Errorf("printed content = %q", printedContent)
+			}
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("stdout missing %q:\n%s", tt.wantStdout, stdout.String())
+			}
+		})
+	}
+}
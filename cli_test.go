@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeServiceControl - サービスマネージャーの代わりに呼び出しを記録する
+type fakeServiceControl struct {
+	calls  []string
+	opts   serviceInstallOptions
+	state  serviceState
+	failOn string
+}
+
+func (f *fakeServiceControl) call(name string) error {
+	f.calls = append(f.calls, name)
+	if f.failOn == name {
+		return errors.New(name + " failed")
+	}
+	return nil
+}
+
+func (f *fakeServiceControl) Install(opts serviceInstallOptions) error {
+	f.opts = opts
+	if err := f.call("install"); err != nil {
+		return err
+	}
+	f.state = serviceState{Installed: true, State: "stopped", StartType: "auto"}
+	return nil
+}
+
+func (f *fakeServiceControl) Uninstall() error { return f.call("uninstall") }
+
+func (f *fakeServiceControl) Start() error {
+	if err := f.call("start"); err != nil {
+		return err
+	}
+	f.state.State = "running"
+	return nil
+}
+
+func (f *fakeServiceControl) Stop() error { return f.call("stop") }
+
+func (f *fakeServiceControl) Status() (serviceState, error) {
+	return f.state, f.call("status")
+}
+
+// withFakeServiceControl - サービス管理・実行ファイル・/v1/health を差し替える
+func withFakeServiceControl(t *testing.T, ctl *fakeServiceControl) {
+	t.Helper()
+	health := httptest.NewServer(http.HandlerFunc(healthHandler))
+	t.Cleanup(health.Close)
+
+	originalControl, originalExe, originalURL, originalConfig := newServiceControl, executablePath, selfHealthURL, currentConfig()
+	newServiceControl = func() serviceControl { return ctl }
+	executablePath = func() (string, error) { return "/opt/print_pdf/print_pdf", nil }
+	selfHealthURL = health.URL + "/v1/health"
+	t.Setenv("PRINT_PDF_CONFIG", filepath.Join(t.TempDir(), "missing.json"))
+	t.Cleanup(func() {
+		newServiceControl, executablePath, selfHealthURL = originalControl, originalExe, originalURL
+		setConfig(originalConfig)
+	})
+}
+
+func TestRunCLI(t *testing.T) {
+	running := serviceState{Installed: true, State: "running", PID: 42, StartType: "auto", Recovery: "restart after 5s"}
+	stopped := serviceState{Installed: true, State: "stopped", StartType: "auto"}
+
+	tests := []struct {
+		name       string
+		args       []string
+		state      serviceState
+		failOn     string
+		wantCode   int
+		wantCalls  string
+		wantStdout []string
+		wantStderr []string
+	}{
+		{name: "version", args: []string{"version"}, wantStdout: []string{"print_pdf " + Version}},
+		{name: "help", args: []string{"help"}, wantStdout: []string{"serve", "service", "install", "uninstall", "status"}},
+		{name: "service help", args: []string{"service", "-h"}, wantStdout: []string{"print_pdf service install"}},
+		{name: "unknown command", args: []string{"bogus"}, wantCode: 2, wantStderr: []string{`unknown command "bogus"`, "Usage: print_pdf"}},
+		{name: "missing service action", args: []string{"service"}, wantCode: 2, wantStderr: []string{"missing command"}},
+		{name: "unknown service action", args: []string{"service", "restart"}, wantCode: 2, wantStderr: []string{`unknown command "restart"`}},
+		{name: "extra argument", args: []string{"service", "start", "now"}, wantCode: 2, wantStderr: []string{`unexpected argument "now"`}},
+		{name: "unknown flag", args: []string{"service", "install", "-force"}, wantCode: 2, wantStderr: []string{"-force"}},
+		{
+			name:       "install starts service",
+			args:       []string{"service", "install"},
+			wantCalls:  "install,start,status",
+			wantStdout: []string{"installed", "started", "State:        running", "HTTP:         ok, version"},
+		},
+		{
+			name:       "install without start",
+			args:       []string{"service", "install", "-no-start"},
+			wantCalls:  "install,status",
+			wantStdout: []string{"installed", "State:        stopped"},
+		},
+		{name: "install failure", args: []string{"service", "install"}, failOn: "install", wantCode: 1, wantCalls: "install", wantStderr: []string{"Error: install failed"}},
+		{name: "uninstall", args: []string{"service", "uninstall"}, state: running, wantCalls: "uninstall", wantStdout: []string{"removed"}},
+		{name: "start", args: []string{"service", "start"}, state: stopped, wantCalls: "start,status", wantStdout: []string{"started", "State:        running"}},
+		{name: "stop", args: []string{"service", "stop"}, state: running, wantCalls: "stop", wantStdout: []string{"stopped"}},
+		{name: "stop failure", args: []string{"service", "stop"}, state: running, failOn: "stop", wantCode: 1, wantCalls: "stop", wantStderr: []string{"stop failed"}},
+		{
+			name:       "status running",
+			args:       []string{"service", "status"},
+			state:      running,
+			wantCalls:  "status",
+			wantStdout: []string{"State:        running (pid 42)", "Startup:      auto", "Recovery:     restart after 5s", "HTTP:         ok"},
+		},
+		{name: "status stopped", args: []string{"service", "status"}, state: stopped, wantCode: 3, wantCalls: "status", wantStdout: []string{"State:        stopped"}},
+		{name: "status not installed", args: []string{"service", "status"}, wantCode: 3, wantCalls: "status", wantStdout: []string{"not installed"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := &fakeServiceControl{state: tt.state, failOn: tt.failOn}
+			withFakeServiceControl(t, ctl)
+
+			var stdout, stderr bytes.Buffer
+			code := runCLI(tt.args, &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d (stderr: %s)", code, tt.wantCode, stderr.String())
+			}
+			if got := strings.Join(ctl.calls, ","); got != tt.wantCalls {
+				t.Errorf("calls = %q, want %q", got, tt.wantCalls)
+			}
+			for _, want := range tt.wantStdout {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("stdout missing %q:\n%s", want, stdout.String())
+				}
+			}
+			for _, want := range tt.wantStderr {
+				if !strings.Contains(stderr.String(), want) {
+					t.Errorf("stderr missing %q:\n%s", want, stderr.String())
+				}
+			}
+		})
+	}
+}
+
+func TestServiceInstallOptions(t *testing.T) {
+	ctl := &fakeServiceControl{}
+	withFakeServiceControl(t, ctl)
+	config := filepath.Join(t.TempDir(), "custom.json")
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI([]string{"service", "install", "-config", config, "-user", `DOMAIN\printer`, "-password", "pw", "-no-start"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code = %d (stderr: %s)", code, stderr.String())
+	}
+	want := serviceInstallOptions{Executable: "/opt/print_pdf/print_pdf", ConfigFile: config, User: `DOMAIN\printer`, Password: "pw"}
+	if ctl.opts != want {
+		t.Errorf("options = %+v, want %+v", ctl.opts, want)
+	}
+}
+
+func TestServiceInstallPassword(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		stdin       string
+		env         string
+		want        string
+		wantCode    int
+		wantWarning bool
+	}{
+		{name: "stdin", args: []string{"-password-stdin"}, stdin: "p@ss word\r\nignored\n", want: "p@ss word"},
+		{name: "stdin without newline", args: []string{"-password-stdin"}, stdin: "pw", want: "pw"},
+		{name: "stdin takes precedence over env", args: []string{"-password-stdin"}, stdin: "from-stdin\n", env: "from-env", want: "from-stdin"},
+		{name: "empty stdin", args: []string{"-password-stdin"}, wantCode: 1},
+		{name: "env", env: "from-env", want: "from-env"},
+		{name: "flag warns", args: []string{"-password", "pw"}, env: "from-env", want: "pw", wantWarning: true},
+		{name: "flag and stdin", args: []string{"-password", "pw", "-password-stdin"}, stdin: "pw\n", wantCode: 2},
+		{name: "none", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctl := &fakeServiceControl{}
+			withFakeServiceControl(t, ctl)
+			withCLIEnv(t, tt.stdin)
+			t.Setenv(servicePasswordEnv, tt.env)
+
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"service", "install", "-user", "printer", "-no-start"}, tt.args...)
+			if code := runCLI(args, &stdout, &stderr); code != tt.wantCode {
+				t.Fatalf("exit code = %d, want %d (stderr: %s)", code, tt.wantCode, stderr.String())
+			}
+			if tt.wantCode != 0 {
+				return
+			}
+			if ctl.opts.Password != tt.want {
+				t.Errorf("password = %q, want %q", ctl.opts.Password, tt.want)
+			}
+			if got := strings.Contains(stderr.String(), "warning: -password"); got != tt.wantWarning {
+				t.Errorf("warning = %v, want %v (stderr: %s)", got, tt.wantWarning, stderr.String())
+			}
+		})
+	}
+}
+
+func TestWriteServiceStatusPaused(t *testing.T) {
+	var buf bytes.Buffer
+	writeServiceStatus(&buf, serviceState{Installed: true, State: "running", LogSource: "journald", LogReady: true},
+		&HealthResponse{Status: "ok", Version: "v1.2.3", Paused: true})
+	for _, want := range []string{"Log:          journald (registered)", "version v1.2.3, paused"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	writeServiceStatus(&buf, serviceState{Installed: true, State: "running"}, nil)
+	if !strings.Contains(buf.String(), "not responding") {
+		t.Errorf("output should report unresponsive server:\n%s", buf.String())
+	}
+}
+
+func TestFormatRecovery(t *testing.T) {
+	got := formatRecovery(serviceRestartDelays, serviceResetPeriod)
+	want := "restart after 5s, restart after 30s, restart after 1m0s; reset after 24h0m0s"
+	if got != want {
+		t.Errorf("formatRecovery = %q, want %q", got, want)
+	}
+	if got := formatRecovery(nil, 0); got != "none" {
+		t.Errorf("formatRecovery(nil) = %q, want none", got)
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// 設定ファイル名（実行ファイルと同じディレクトリに配置）
+const configFileName = "print_pdf_config.json"
+
+// Config - アプリケーション設定
+type Config struct {
+	Log    LogConfig    `json:"log"`
+	Server ServerConfig `json:"server"`
+	Print  PrintConfig  `json:"print"`
+	Update UpdateConfig `json:"update"`
+	Admin  AdminConfig  `json:"admin"`
+	PDF    PDFConfig    `json:"pdf"`
+}
+
+// LogConfig - ログ出力設定
+type LogConfig struct {
+	Level      string `json:"level"`      // 最低出力レベル (DEBUG, INFO, WARN, ERROR)
+	File       string `json:"file"`       // ログファイルパス
+	MaxSizeMB  int    `json:"maxSizeMB"`  // このサイズを超えたらローテーション
+	MaxAgeDays int    `json:"maxAgeDays"` // この日数を経過したらローテーション
+	MaxBackups int    `json:"maxBackups"` // 保持するローテーション済みファイル数
+}
+
+// ServerConfig - HTTPサーバー設定
+type ServerConfig struct {
+	DrainTimeoutSec int `json:"drainTimeoutSec"` // 停止・アップデート時に実行中の処理を待つ最大秒数
+}
+
+// PrintConfig - 印刷キュー設定
+type PrintConfig struct {
+	MaxConcurrent int `json:"maxConcurrent"` // 同時に実行する印刷ジョブ数
+	QueueSize     int `json:"queueSize"`     // 待機中を含むジョブ数の上限（超えると503）
+
+	PauseMode           string `json:"pauseMode"`           // サービス一時停止中の印刷リクエスト: reject（503）, queue（再開まで待たせる）
+	GenerateWhilePaused bool   `json:"generateWhilePaused"` // 一時停止中も印刷なしのPDF生成を受け付けるか
+}
+
+// AdminConfig - 管理API設定
+type AdminConfig struct {
+	Token string `json:"token"` // /admin/* の Bearer トークン（空の場合は管理APIを無効化）
+}
+
+// PDFConfig - PDFのレイアウト設定
+type PDFConfig struct {
+	Detail ColumnConfig `json:"detail"` // 摘要欄
+	Kukan  ColumnConfig `json:"kukan"`  // 区間欄（空欄の交通機関・運賃・特別料金欄にはみ出して印字する）
+
+	KukanRules     []KukanRule `json:"kukanRules"`     // 区間テキストの正規化ルール（順に適用。指定すると既定のルールを置き換える）
+	KukanSeparator string      `json:"kukanSeparator"` // 区間の区切り文字（正規表現。空の場合は既定）
+
+	Templates map[string]TemplateConfig `json:"templates"` // テンプレートID → テンプレートごとの設定
+	Timezone  string                    `json:"timezone"`  // 業務のタイムゾーン（時差付きの日時はこのタイムゾーンの日付として印字）
+
+	Tax     TaxConfig     `json:"tax"`     // 消費税の計算方法（Item.Tax を指定した場合）
+	Remarks RemarksConfig `json:"remarks"` // 備考欄に自動で追加する内容
+
+	Barcode   BarcodeConfig   `json:"barcode"`   // ページごとに印字する元の記録へのQRコード・バーコード
+	Watermark WatermarkConfig `json:"watermark"` // リクエストで指定した透かし（下書き・再発行・控え）の見た目
+
+	ApprovalColumns []ApprovalColumn `json:"approvalColumns"` // 承認欄の列（左から順。指定すると既定の 社長・会計・所属 を置き換える）
+}
+
+// ColumnConfig - 折り返して印字する列の設定
+type ColumnConfig struct {
+	WidthMM     float64 `json:"widthMm"`     // 折り返し幅（mm。実際のフォントで測った幅で折り返す）
+	FontSize    float64 `json:"fontSize"`    // 文字サイズ（pt）
+	Overflow    string  `json:"overflow"`    // 1区切りで幅を超える場合: wrap（文字単位で折り返す）, shrink（文字サイズを縮小）
+	MinFontSize float64 `json:"minFontSize"` // shrink で縮小する最小の文字サイズ（pt）
+}
+
+// withDefaults - 0以下の項目を既定値で補う
+func (c ColumnConfig) withDefaults(def ColumnConfig) ColumnConfig {
+	if c.WidthMM <= 0 {
+		c.WidthMM = def.WidthMM
+	}
+	if c.FontSize <= 0 {
+		c.FontSize = def.FontSize
+	}
+	if c.Overflow == "" {
+		c.Overflow = def.Overflow
+	}
+	if c.MinFontSize <= 0 || c.MinFontSize > c.FontSize {
+		c.MinFontSize = min(def.MinFontSize, c.FontSize)
+	}
+	return c
+}
+
+// UpdateConfig - 自動アップデート設定
+type UpdateConfig struct {
+	Enabled               bool               `json:"enabled"`               // 自動アップデートを行うか
+	Source                UpdateSourceConfig `json:"source"`                // アップデートの取得元
+	Channel               string             `json:"channel"`               // stable, beta, pinned
+	PinnedVersion         string             `json:"pinnedVersion"`         // channel が pinned の場合に使うバージョン
+	CheckIntervalMinutes  int                `json:"checkIntervalMinutes"`  // 定期チェックの間隔（0以下なら起動時のみ）
+	MaintenanceWindow     MaintenanceWindow  `json:"maintenanceWindow"`     // 適用してよい時間帯（省略時は常に適用）
+	HealthCheckTimeoutSec int                `json:"healthCheckTimeoutSec"` // 更新後の起動確認の待ち時間（超えるとロールバック）
+}
+
+// UpdateSourceConfig - アップデートの取得元
+type UpdateSourceConfig struct {
+	Type       string `json:"type"`       // github, http, dir
+	Repository string `json:"repository"` // github: owner/name
+	URL        string `json:"url"`        // github: APIのベースURL（省略可） / http: マニフェストのURL
+	Path       string `json:"path"`       // dir: ディレクトリまたはUNCパス（例: \\fileserver\print_pdf）
+}
+
+// defaultConfig - 設定ファイルがない場合の既定値
+func defaultConfig() Config {
+	return Config{
+		Log: LogConfig{
+			Level:      "INFO",
+			File:       "pdf_generator_service.log",
+			MaxSizeMB:  10,
+			MaxAgeDays: 7,
+			MaxBackups: 10,
+		},
+		Server: ServerConfig{
+			DrainTimeoutSec: 120,
+		},
+		Print: PrintConfig{
+			MaxConcurrent:       1,
+			QueueSize:           10,
+			PauseMode:           PauseModeReject,
+			GenerateWhilePaused: true,
+		},
+		Update: UpdateConfig{
+			Enabled:               true,
+			Source:                UpdateSourceConfig{Type: UpdateSourceGitHub, Repository: defaultUpdateRepository},
+			Channel:               UpdateChannelStable,
+			CheckIntervalMinutes:  360,
+			HealthCheckTimeoutSec: 60,
+		},
+		PDF: PDFConfig{
+			Detail:    ColumnConfig{WidthMM: 38, FontSize: 10, Overflow: OverflowWrap, MinFontSize: 6},   // 40mmの列から左右1mmの余白を除く
+			Kukan:     ColumnConfig{WidthMM: 73, FontSize: 10, Overflow: OverflowShrink, MinFontSize: 6}, // 区間〜特別料金の75mmから余白を除く
+			Timezone:  defaultTimezone,
+			Tax:       TaxConfig{Rounding: TaxRoundFloor, PriceIncludesTax: true},
+			Remarks:   RemarksConfig{Truncation: true},
+			Watermark: WatermarkConfig{FontSize: 72, Opacity: 0.15, Angle: 30, Color: [3]int{128, 128, 128}, DateStyle: DateStyleYMD},
+		},
+	}
+}
+
+var (
+	configMu  sync.RWMutex
+	appConfig = defaultConfig()
+)
+
+// currentConfig - 現在の設定を取得
+func currentConfig() Config {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return appConfig
+}
+
+// setConfig - 設定を差し替え
+func setConfig(cfg Config) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	appConfig = cfg
+}
+
+// configPath - 設定ファイルのパスを取得（環境変数 PRINT_PDF_CONFIG で上書き可能）
+func configPath() string {
+	if path := os.Getenv("PRINT_PDF_CONFIG"); path != "" {
+		return path
+	}
+	if exe, err := os.Executable(); err == nil {
+		return filepath.Join(filepath.Dir(exe), configFileName)
+	}
+	return configFileName
+}
+
+// loadConfig - 設定ファイルを読み込み（ファイルがない場合は既定値）
+func loadConfig(path string) (Config, error) {
+	cfg := defaultConfig()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("設定ファイル読み込みエラー: %v", err)
+	}
+
+	// 既定値の上に読み込むことで、省略された項目は既定値のまま
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return defaultConfig(), fmt.Errorf("設定ファイル解析エラー: %v", err)
+	}
+	if _, err := newKukanNormalizer(cfg.PDF.kukanRules(), cfg.PDF.KukanSeparator); err != nil {
+		return defaultConfig(), fmt.Errorf("設定ファイルの区間正規化ルールエラー: %v", err)
+	}
+	if _, err := loadBusinessLocation(cfg.PDF.Timezone); err != nil {
+		return defaultConfig(), fmt.Errorf("設定ファイルのタイムゾーン設定エラー: %v", err)
+	}
+	if err := cfg.PDF.Barcode.validate(); err != nil {
+		return defaultConfig(), fmt.Errorf("設定ファイルのバーコード設定エラー: %v", err)
+	}
+	if err := cfg.PDF.Watermark.validate(); err != nil {
+		return defaultConfig(), fmt.Errorf("設定ファイルの透かし設定エラー: %v", err)
+	}
+	if err := validateApprovalColumns(cfg.PDF.ApprovalColumns); err != nil {
+		return defaultConfig(), fmt.Errorf("設定ファイルの承認欄設定エラー: %v", err)
+	}
+	if err := validateTaxRounding(cfg.PDF.Tax.Rounding); err != nil {
+		return defaultConfig(), fmt.Errorf("設定ファイルの消費税設定エラー: %v", err)
+	}
+	for id, t := range cfg.PDF.Templates {
+		if _, err := findPDFTemplate(id); err != nil {
+			return defaultConfig(), fmt.Errorf("設定ファイルのテンプレート設定エラー: %v", err)
+		}
+		if err := t.Dates.validate(); err != nil {
+			return defaultConfig(), fmt.Errorf("設定ファイルのテンプレート設定エラー: %s: %v", id, err)
+		}
+	}
+	return cfg, nil
+}
+
+// reloadConfig - 設定ファイルを読み直してログ設定に反映（失敗した場合は現在の設定のまま）
+// 印刷キューの大きさは再起動後に反映される
+func reloadConfig() error {
+	cfg, err := loadConfig(configPath())
+	if err != nil {
+		return err
+	}
+	setConfig(cfg)
+	return setupLogger(cfg.Log)
+}
+
+// reloadConfigAndLog - 設定を再読み込みして結果をログに記録
+func reloadConfigAndLog() {
+	writeEventLog("INFO", fmt.Sprintf("設定ファイルを再読み込みします: %s", configPath()))
+	if err := reloadConfig(); err != nil {
+		writeEventLog("ERROR", fmt.Sprintf("設定ファイルの再読み込みに失敗しました（現在の設定で継続）: %v", err))
+		return
+	}
+	writeEventLog("INFO", "設定ファイルを再読み込みしました")
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	cfg, err := loadConfig(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("missing config should not be an error: %v", err)
+	}
+	if cfg.Log != defaultConfig().Log {
+		t.Errorf("cfg.Log = %+v, expected defaults", cfg.Log)
+	}
+}
+
+func TestLoadConfigOverridesDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), configFileName)
+	if err := os.WriteFile(path, []byte(`{"log":{"level":"DEBUG","maxSizeMB":50}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Log.Level != "DEBUG" || cfg.Log.MaxSizeMB != 50 {
+		t.Errorf("cfg.Log = %+v, expected overridden level and size", cfg.Log)
+	}
+	if cfg.Log.File != defaultConfig().Log.File {
+		t.Errorf("omitted file should keep default, got %q", cfg.Log.File)
+	}
+}
+
+func TestLoadConfigPDFColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), configFileName)
+	os.WriteFile(path, []byte(`{"pdf":{"kukan":{"widthMm":60}}}`), 0644)
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.PDF.Kukan != (ColumnConfig{WidthMM: 60, FontSize: 10, Overflow: OverflowShrink, MinFontSize: 6}) {
+		t.Errorf("cfg.PDF.Kukan = %+v, expected overridden width and default font size", cfg.PDF.Kukan)
+	}
+	if cfg.PDF.Detail != defaultConfig().PDF.Detail {
+		t.Errorf("omitted detail column should keep defaults, got %+v", cfg.PDF.Detail)
+	}
+	if got := (ColumnConfig{FontSize: -1}).withDefaults(cfg.PDF.Detail); got != cfg.PDF.Detail {
+		t.Errorf("withDefaults = %+v, want %+v", got, cfg.PDF.Detail)
+	}
+	if got := (ColumnConfig{FontSize: 5}).withDefaults(cfg.PDF.Detail); got.MinFontSize != 5 {
+		t.Errorf("minFontSize should not exceed fontSize, got %v", got.MinFontSize)
+	}
+}
+
+func TestLoadConfigInvalidJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), configFileName)
+	os.WriteFile(path, []byte(`{"log":`), 0644)
+
+	cfg, err := loadConfig(path)
+	if err == nil {
+		t.Error("invalid JSON should return an error")
+	}
+	if cfg.Log != defaultConfig().Log {
+		t.Errorf("invalid config should fall back to defaults, got %+v", cfg.Log)
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	original := currentConfig()
+	t.Cleanup(func() { setConfig(original) })
+
+	path := filepath.Join(t.TempDir(), configFileName)
+	t.Setenv("PRINT_PDF_CONFIG", path)
+	os.WriteFile(path, []byte(`{"log":{"level":"WARN","file":""},"print":{"pauseMode":"queue"}}`), 0644)
+	if err := reloadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg := currentConfig(); cfg.Log.Level != "WARN" || cfg.Print.PauseMode != PauseModeQueue {
+		t.Errorf("config after reload = %+v", cfg)
+	}
+
+	// 壊れた設定ファイルでは現在の設定を維持
+	os.WriteFile(path, []byte(`{"log":`), 0644)
+	if err := reloadConfig(); err == nil {
+		t.Error("reload of invalid config should fail")
+	}
+	if currentConfig().Log.Level != "WARN" {
+		t.Error("invalid config must not replace the current configuration")
+	}
+}
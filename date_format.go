@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // Windowsにはタイムゾーンのデータベースがないため埋め込む
+)
+
+// 日付の書式
+// 帳票に印字する日付はすべてここで整形する。欄ごとの書式はテンプレートの既定値を pdf.templates.<id>.dates で変更できる
+
+// 日付の書式名
+const (
+	DateStyleSlash  = "slash"  // 01/06（旅費の日付欄）
+	DateStyleSlots  = "slots"  // 01　 06（用紙の「　　月　　日」欄に月と日を入れる）
+	DateStyleMD     = "md"     // 1月6日
+	DateStyleISO    = "iso"    // 2025-01-06
+	DateStyleYMD    = "ymd"    // 2025年 01月 06日
+	DateStyleWareki = "wareki" // 令和7年1月6日（和暦。元年は「元年」）
+	DateStyleSeal   = "seal"   // 25.01.06（承認欄のデータ印）
+)
+
+// dateFormatters - 書式名 → 整形
+var dateFormatters = map[string]func(t time.Time) string{
+	DateStyleSlash:  func(t time.Time) string { return t.Format("01/02") },
+	DateStyleSlots:  func(t time.Time) string { return t.Format("01　 02") },
+	DateStyleMD:     func(t time.Time) string { return fmt.Sprintf("%d月%d日", t.Month(), t.Day()) },
+	DateStyleISO:    func(t time.Time) string { return t.Format("2006-01-02") },
+	DateStyleYMD:    func(t time.Time) string { return t.Format("2006年 01月 02日") },
+	DateStyleWareki: formatWareki,
+	DateStyleSeal:   func(t time.Time) string { return t.Format("06.01.02") },
+}
+
+// dateStyleNames - 書式名の一覧（昇順、エラーメッセージ用）
+func dateStyleNames() []string {
+	names := make([]string, 0, len(dateFormatters))
+	for name := range dateFormatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateDateStyle - 書式名が正しいか確認（空は既定の書式）
+func validateDateStyle(style string) error {
+	if _, ok := dateFormatters[style]; style != "" && !ok {
+		return fmt.Errorf("不明な日付の書式です: %q（%s）", style, strings.Join(dateStyleNames(), ", "))
+	}
+	return nil
+}
+
+// formatDate - 日付を書式で整形（不明な書式は iso）
+func formatDate(t time.Time, style string) string {
+	if f, ok := dateFormatters[style]; ok {
+		return f(t)
+	}
+	return t.Format("2006-01-02")
+}
+
+// dateLayouts - 受け付ける日付の形式（時差を含む形式は業務のタイムゾーンに変換する）
+var dateLayouts = []string{
+	time.RFC3339Nano,      // 2024-12-17T00:00:00+09:00、2024-12-16T15:00:00Z
+	"2006-01-02T15:04:05", // 2024-12-17T09:30:00（時差なしは業務のタイムゾーンの時刻）
+	"2006-01-02 15:04:05", // 2024-12-17 09:30:00
+	"2006-01-02T15:04",    // 2024-12-17T09:30
+	"2006-1-2",            // 2024-12-17、2024-1-5
+	"2006/1/2",            // 2024/12/17、2024/1/5
+	"2006/1/2 15:04:05",   // 2024/12/17 09:30:00
+	"20060102",            // 20241217
+	"2006年1月2日",           // 2024年12月17日
+}
+
+// parseItemDate - リクエストの日付を解析して業務のタイムゾーン（pdf.timezone）の日時にする
+func parseItemDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	loc := businessLocation()
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t.In(loc), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q (expected YYYY-MM-DD, YYYY/MM/DD or an RFC 3339 timestamp)", s)
+}
+
+// monthDayPattern - 年のない月日（MM/DD）。旅費の日付は従来どおりそのまま印字する
+var monthDayPattern = regexp.MustCompile(`^(\d{1,2})/(\d{1,2})$`)
+
+// isMonthDay - 年のない月日として正しいか
+func isMonthDay(s string) bool {
+	m := monthDayPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return false
+	}
+	_, err := time.Parse("2006-1-2", "2000-"+m[1]+"-"+m[2]) // うるう年で2月29日も受け付ける
+	return err == nil
+}
+
+// validateItemDates - Item・Ryohi のすべての日付を検証（空は省略と同じ扱い）
+func validateItemDates(items []Item) []FieldError {
+	var errs []FieldError
+	check := func(field string, value *string, allowMonthDay bool) {
+		if value == nil || strings.TrimSpace(*value) == "" {
+			return
+		}
+		if allowMonthDay && isMonthDay(*value) {
+			return
+		}
+		if _, err := parseItemDate(*value); err != nil {
+			errs = append(errs, FieldError{Field: field, Message: err.Error()})
+		}
+	}
+
+	for i, item := range items {
+		prefix := fmt.Sprintf("items[%d].", i)
+		check(prefix+"startDate", item.StartDate, false)
+		check(prefix+"endDate", item.EndDate, false)
+		check(prefix+"payDay", item.PayDay, false)
+		for j, ryohi := range item.Ryohi {
+			rp := fmt.Sprintf("%sryohi[%d].", prefix, j)
+			check(rp+"date", ryohi.Date, true)
+			for k := range ryohi.DateAr {
+				check(fmt.Sprintf("%sdateAr[%d]", rp, k), &ryohi.DateAr[k], true)
+			}
+		}
+	}
+	return errs
+}
+
+// defaultTimezone - 業務のタイムゾーンの既定値
+const defaultTimezone = "Asia/Tokyo"
+
+// タイムゾーンのキャッシュ（名前 → *time.Location）
+var locationCache sync.Map
+
+// loadBusinessLocation - タイムゾーンを読み込む（空の場合は既定値。tzdata を埋め込んでいるのでWindowsでも使える）
+func loadBusinessLocation(name string) (*time.Location, error) {
+	if name == "" {
+		name = defaultTimezone
+	}
+	if loc, ok := locationCache.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("タイムゾーンが不正です: %q: %v", name, err)
+	}
+	locationCache.Store(name, loc)
+	return loc, nil
+}
+
+// businessLocation - 日付を変換する業務のタイムゾーン（不正な場合は既定値）
+func businessLocation() *time.Location {
+	loc, err := loadBusinessLocation(currentConfig().PDF.Timezone)
+	if err != nil {
+		loc, _ = loadBusinessLocation(defaultTimezone)
+	}
+	return loc
+}
+
+// formatDateText - リクエストの日付を書式で整形（解析できない場合はそのまま）
+func formatDateText(s, style string) string {
+	t, err := parseItemDate(s)
+	if err != nil {
+		return s
+	}
+	return formatDate(t, style)
+}
+
+// era - 元号と開始日
+type era struct {
+	name  string
+	start time.Time
+}
+
+// eras - 元号（新しい順）
+var eras = []era{
+	{"令和", time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)},
+	{"平成", time.Date(1989, 1, 8, 0, 0, 0, 0, time.UTC)},
+	{"昭和", time.Date(1926, 12, 25, 0, 0, 0, 0, time.UTC)},
+	{"大正", time.Date(1912, 7, 30, 0, 0, 0, 0, time.UTC)},
+	{"明治", time.Date(1868, 10, 23, 0, 0, 0, 0, time.UTC)},
+}
+
+// formatWareki - 和暦で整形（明治より前は西暦）
+func formatWareki(t time.Time) string {
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	for _, e := range eras {
+		if date.Before(e.start) {
+			continue
+		}
+		year := fmt.Sprintf("%d年", t.Year()-e.start.Year()+1)
+		if t.Year() == e.start.Year() {
+			year = "元年"
+		}
+		return fmt.Sprintf("%s%s%d月%d日", e.name, year, t.Month(), t.Day())
+	}
+	return fmt.Sprintf("%d年%d月%d日", t.Year(), t.Month(), t.Day())
+}
+
+// DateStyles - 帳票の欄ごとの日付の書式
+type DateStyles struct {
+	PayDay string `json:"payDay"` // 精算日
+	Trip   string `json:"trip"`   // 出発日・帰着日
+	Row    string `json:"row"`    // 旅費の日付
+	Seal   string `json:"seal"`   // 承認欄のデータ印の日付
+}
+
+// override - 指定された書式で上書き（空の欄は元のまま）
+func (d DateStyles) override(o DateStyles) DateStyles {
+	if o.PayDay != "" {
+		d.PayDay = o.PayDay
+	}
+	if o.Trip != "" {
+		d.Trip = o.Trip
+	}
+	if o.Row != "" {
+		d.Row = o.Row
+	}
+	if o.Seal != "" {
+		d.Seal = o.Seal
+	}
+	return d
+}
+
+// validate - すべての欄の書式名を確認
+func (d DateStyles) validate() error {
+	for field, style := range map[string]string{"payDay": d.PayDay, "trip": d.Trip, "row": d.Row, "seal": d.Seal} {
+		if err := validateDateStyle(style); err != nil {
+			return fmt.Errorf("%s: %v", field, err)
+		}
+	}
+	return nil
+}
+
+// TemplateConfig - テンプレートごとの設定
+type TemplateConfig struct {
+	Dates DateStyles `json:"dates"` // 日付の書式（省略した欄はテンプレートの既定値）
+}
+
+// templateDateStyles - テンプレートの日付の書式（設定で上書き）
+func templateDateStyles(id string) DateStyles {
+	t := pdfTemplates[id]
+	return t.Dates.override(currentConfig().PDF.Templates[id].Dates)
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatDate(t *testing.T) {
+	date := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		style string
+		want  string
+	}{
+		{DateStyleSlash, "01/06"},
+		{DateStyleSlots, "01　 06"},
+		{DateStyleMD, "1月6日"},
+		{DateStyleISO, "2025-01-06"},
+		{DateStyleYMD, "2025年 01月 06日"},
+		{DateStyleWareki, "令和7年1月6日"},
+		{DateStyleSeal, "25.01.06"},
+		{"unknown", "2025-01-06"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			if got := formatDate(date, tt.style); got != tt.want {
+				t.Errorf("formatDate(%s) = %q, want %q", tt.style, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatWareki(t *testing.T) {
+	tests := []struct {
+		date string
+		want string
+	}{
+		{"2025-01-06", "令和7年1月6日"},
+		{"2019-05-01", "令和元年5月1日"},
+		{"2019-04-30", "平成31年4月30日"},
+		{"1989-01-08", "平成元年1月8日"},
+		{"1989-01-07", "昭和64年1月7日"},
+		{"1926-12-25", "昭和元年12月25日"},
+		{"1926-12-24", "大正15年12月24日"},
+		{"1912-07-30", "大正元年7月30日"},
+		{"1868-01-01", "1868年1月1日"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			d, err := parseItemDate(tt.date)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := formatWareki(d); got != tt.want {
+				t.Errorf("formatWareki(%s) = %q, want %q", tt.date, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatDateText(t *testing.T) {
+	tests := []struct {
+		text  string
+		style string
+		want  string
+	}{
+		{"2024-12-17", DateStyleSlash, "12/17"},
+		{" 2024-12-17 ", DateStyleWareki, "令和6年12月17日"},
+		{"01/15", DateStyleWareki, "01/15"}, // 解析できない場合はそのまま
+		{"", DateStyleSlash, ""},
+	}
+	for _, tt := range tests {
+		if got := formatDateText(tt.text, tt.style); got != tt.want {
+			t.Errorf("formatDateText(%q, %s) = %q, want %q", tt.text, tt.style, got, tt.want)
+		}
+	}
+}
+
+func TestTemplateDateStyles(t *testing.T) {
+	original := currentConfig()
+	t.Cleanup(func() { setConfig(original) })
+
+	tests := []struct {
+		name    string
+		config  string
+		want    DateStyles
+		wantErr string
+	}{
+		{name: "テンプレートの既定値", config: `{}`, want: DateStyles{PayDay: DateStyleYMD, Trip: DateStyleSlots, Row: DateStyleSlash, Seal: DateStyleSeal}},
+		{
+			name:   "和暦に変更",
+			config: `{"pdf":{"templates":{"travel-expense":{"dates":{"payDay":"wareki","row":"md","seal":"slash"}}}}}`,
+			want:   DateStyles{PayDay: DateStyleWareki, Trip: DateStyleSlots, Row: DateStyleMD, Seal: DateStyleSlash},
+		},
+		{name: "不明な書式", config: `{"pdf":{"templates":{"travel-expense":{"dates":{"trip":"reiwa"}}}}}`, wantErr: `travel-expense: trip: 不明な日付の書式です: "reiwa"`},
+		{name: "不明なテンプレート", config: `{"pdf":{"templates":{"invoice":{}}}}`, wantErr: `unknown template "invoice"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), configFileName)
+			if err := os.WriteFile(path, []byte(tt.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := loadConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			setConfig(cfg)
+			if got := templateDateStyles(defaultTemplateID); got != tt.want {
+				t.Errorf("templateDateStyles() = %+v, want %+v", got, tt.want)
+			}
+			if got := newReportLabStylePdfClient().dates; got != tt.want {
+				t.Errorf("client dates = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// pdfTextRun - 圧縮していないPDFの Tj の文字列を描画順につなげる（欄の中で折り返した文字も続けて比較できる）
+var pdfTextRun = regexp.MustCompile(`\((.*?)\) Tj`)
+
+// pdfHasText - 圧縮していないPDFに文字列が描画されているか（埋め込みフォントはUTF-16BE、標準フォントはそのまま）
+func pdfHasText(pdf []byte, text string) bool {
+	var run []byte
+	for _, m := range pdfTextRun.FindAllSubmatch(pdf, -1) {
+		run = append(run, m[1]...)
+	}
+	var utf16 []byte
+	for _, r := range text {
+		utf16 = append(utf16, byte(r>>8), byte(r))
+	}
+	return bytes.Contains(run, []byte(text)) || bytes.Contains(run, utf16)
+}
+
+func TestRenderRowDateStyle(t *testing.T) {
+	original := currentConfig()
+	t.Cleanup(func() { setConfig(original) })
+
+	items := []Item{{Name: "山田", Ryohi: []Ryohi{{Date: StringPtr("2025-01-06"), Dest: StringPtr("大阪")}}}}
+	tests := []struct {
+		style   string
+		want    string
+		notWant string
+	}{
+		{style: DateStyleSlash, want: "01/06", notWant: "2025-01-06"},
+		{style: DateStyleISO, want: "2025-01-06", notWant: "01/06"},
+		{style: DateStyleMD, want: "1月6日", notWant: "01/06"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			cfg := defaultConfig()
+			cfg.PDF.Templates = map[string]TemplateConfig{defaultTemplateID: {Dates: DateStyles{Row: tt.style}}}
+			setConfig(cfg)
+
+			client := newReportLabStylePdfClient()
+			client.pdf.SetCompression(false)
+			client.render(items, RenderOptions{})
+			var buf bytes.Buffer
+			if err := client.pdf.Output(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if !pdfHasText(buf.Bytes(), tt.want) {
+				t.Errorf("row date %q is not printed", tt.want)
+			}
+			if pdfHasText(buf.Bytes(), tt.notWant) {
+				t.Errorf("row date is printed as %q", tt.notWant)
+			}
+		})
+	}
+}
+
+func TestParseItemDate(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string // 業務のタイムゾーン（Asia/Tokyo）での日付
+		wantErr bool
+	}{
+		{input: "2024-12-17", want: "2024-12-17"},
+		{input: "2024-12-17T00:00:00+09:00", want: "2024-12-17"},
+		{input: "2024-12-16T15:00:00Z", want: "2024-12-17"},
+		{input: "2024-12-17T23:30:00-05:00", want: "2024-12-18"},
+		{input: "2024-12-17T10:00:00.123+09:00", want: "2024-12-17"},
+		{input: "2024-12-17T23:59:59", want: "2024-12-17"},
+		{input: "2024-12-17 08:00:00", want: "2024-12-17"},
+		{input: "2024/12/17", want: "2024-12-17"},
+		{input: "2024/1/5", want: "2024-01-05"},
+		{input: "2024-1-5", want: "2024-01-05"},
+		{input: "20241217", want: "2024-12-17"},
+		{input: "2024年12月17日", want: "2024-12-17"},
+		{input: " 2024-12-17 ", want: "2024-12-17"},
+		{input: "2024-13-01", wantErr: true},
+		{input: "2024-02-30", wantErr: true},
+		{input: "12/17", wantErr: true},
+		{input: "tomorrow", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseItemDate(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseItemDate(%q) = %v, want error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Location().String() != defaultTimezone || got.Format("2006-01-02") != tt.want {
+				t.Errorf("parseItemDate(%q) = %v, want %s in %s", tt.input, got, tt.want, defaultTimezone)
+			}
+		})
+	}
+}
+
+func TestBusinessTimezoneConfig(t *testing.T) {
+	original := currentConfig()
+	t.Cleanup(func() { setConfig(original) })
+
+	tests := []struct {
+		name    string
+		config  string
+		want    string // 2024-12-16T15:00:00Z の日付
+		wantErr string
+	}{
+		{name: "既定は日本時間", config: `{}`, want: "12/17"},
+		{name: "UTC", config: `{"pdf":{"timezone":"UTC"}}`, want: "12/16"},
+		{name: "不正なタイムゾーン", config: `{"pdf":{"timezone":"Asia/Nowhere"}}`, wantErr: "タイムゾーンが不正です"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), configFileName)
+			if err := os.WriteFile(path, []byte(tt.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := loadConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			setConfig(cfg)
+			if got := formatDateText("2024-12-16T15:00:00Z", DateStyleSlash); got != tt.want {
+				t.Errorf("formatDateText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateItemDates(t *testing.T) {
+	items := []Item{
+		{
+			StartDate: StringPtr("2024-12-17T00:00:00+09:00"),
+			EndDate:   StringPtr("2024/12/18"),
+			PayDay:    StringPtr("  "),
+			Ryohi:     []Ryohi{{Date: StringPtr("12/17"), DateAr: []string{"2024-12-17"}}},
+		},
+		{
+			StartDate: StringPtr("2024-12-32"),
+			PayDay:    StringPtr("next month"),
+			Ryohi: []Ryohi{
+				{Date: StringPtr("13/01")},
+				{Date: StringPtr("2024-12-17"), DateAr: []string{"12/18", "yesterday"}},
+			},
+		},
+	}
+
+	errs := validateItemDates(items)
+	want := []string{"items[1].startDate", "items[1].payDay", "items[1].ryohi[0].date", "items[1].ryohi[1].dateAr[1]"}
+	if len(errs) != len(want) {
+		t.Fatalf("errors = %v, want fields %v", errs, want)
+	}
+	for i, field := range want {
+		if errs[i].Field != field || !strings.Contains(errs[i].Message, "invalid date") {
+			t.Errorf("errs[%d] = %+v, want field %s", i, errs[i], field)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// 枠に合わせた文字描画
+// 枠の幅に収まるまで文字サイズを縮小し、最小サイズでも収まらない場合は折り返す（改行は段落の区切り）。それでも枠の高さを超える場合は末尾を省略する
+
+// textAlign - 枠内の横位置
+type textAlign int
+
+const (
+	alignLeft textAlign = iota
+	alignCenter
+	alignRight
+)
+
+// fitBox - 文字を収める枠（mm）
+type fitBox struct {
+	X, Y, W, H float64
+}
+
+// fitStyle - 枠に収める文字の配置と文字サイズの範囲（pt）
+type fitStyle struct {
+	Align   textAlign
+	MinSize float64
+	MaxSize float64
+}
+
+// fitLayout - 枠に収めた結果
+type fitLayout struct {
+	Size      float64  // 文字サイズ（pt）
+	Lines     []string // 描画する行
+	Truncated bool     // 枠に収まらず末尾を省略した
+}
+
+const (
+	ptToMM          = 25.4 / 72 // 1ptのmm
+	fitLineSpacing  = 1.1       // 行の高さ（文字サイズに対する倍率）
+	fitSizeStep     = 0.5       // 文字サイズを縮小する刻み（pt）
+	fitEllipsis     = "…"       // 省略記号
+	fitBaselineRise = 0.35      // 行の中央からベースラインまで（文字サイズに対する倍率）
+)
+
+// fitLineHeight - 文字サイズ（pt）での行の高さ（mm）
+func fitLineHeight(size float64) float64 {
+	return size * ptToMM * fitLineSpacing
+}
+
+// layoutFitText - 枠に収まる最大の文字サイズと行を決める
+// 大きい文字サイズから fitSizeStep ずつ、最後に最小サイズで、1行で収まるか、折り返して枠の高さに収まるかを試す
+// measure は指定した文字サイズで幅を測るもの
+func layoutFitText(text string, w, h float64, style fitStyle, measure func(size float64) textMeasurer) fitLayout {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return fitLayout{Size: style.MaxSize}
+	}
+	minSize := math.Min(style.MinSize, style.MaxSize)
+
+	for size := style.MaxSize; ; size -= fitSizeStep {
+		size = math.Max(size, minSize) // 刻みに乗らない最小サイズも試す
+		if lines, ok := fitLines(text, w, h, size, measure(size)); ok {
+			return fitLayout{Size: size, Lines: lines}
+		}
+		if size == minSize {
+			break
+		}
+	}
+
+	// 最小サイズでも収まらない場合は、枠の高さに入る行まで描画し、行を省いた・幅を超えて短くした場合だけ末尾を省略
+	m := measure(minSize)
+	lines := wrapParagraphs(text, w, m)
+	truncated := false
+	if maxLines := max(1, int(h/fitLineHeight(minSize))); len(lines) > maxLines {
+		lines = lines[:maxLines]
+		lines[maxLines-1] = ellipsizeLine(lines[maxLines-1], w, m)
+		truncated = true
+	}
+	for i, line := range lines {
+		if m.GetStringWidth(line) > w {
+			lines[i] = ellipsizeLine(line, w, m)
+			truncated = true
+		}
+	}
+	return fitLayout{Size: minSize, Lines: lines, Truncated: truncated}
+}
+
+// fitLines - 文字サイズ size（m はそのサイズで測るもの）で枠に収まる行（1行で収まらなければ折り返す）
+func fitLines(text string, w, h, size float64, m textMeasurer) ([]string, bool) {
+	if !strings.Contains(text, "\n") && m.GetStringWidth(text) <= w && fitLineHeight(size) <= h {
+		return []string{text}, true
+	}
+	lines := wrapParagraphs(text, w, m)
+	return lines, float64(len(lines))*fitLineHeight(size) <= h && fitsWidth(lines, w, m)
+}
+
+// ellipsizeLine - 省略記号を付けて幅に収まるまで末尾を削る
+func ellipsizeLine(line string, w float64, m textMeasurer) string {
+	runes := []rune(line)
+	for len(runes) > 0 && m.GetStringWidth(string(runes)+fitEllipsis) > w {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimRightFunc(string(runes), isWrapSpace) + fitEllipsis
+}
+
+// wrapParagraphs - 改行で段落に分けてから、段落ごとに幅で折り返す（空の段落は空行）
+func wrapParagraphs(text string, w float64, m textMeasurer) []string {
+	var lines []string
+	for _, para := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		wrapped := wrapTextWidth(strings.TrimRightFunc(para, isWrapSpace), w, m)
+		if len(wrapped) == 0 {
+			wrapped = []string{""}
+		}
+		lines = append(lines, wrapped...)
+	}
+	return lines
+}
+
+// fitsWidth - すべての行が幅に収まるか（1文字で幅を超える行がないか）
+func fitsWidth(lines []string, w float64, m textMeasurer) bool {
+	for _, line := range lines {
+		if m.GetStringWidth(line) > w {
+			return false
+		}
+	}
+	return true
+}
+
+// fitText - 枠に収まるよう文字サイズを調整して描画（label は省略した場合のログ用）
+// 行は枠の上下中央に、横位置は style.Align に従って配置する
+func (c *ReportLabStylePdfClient) fitText(box fitBox, text string, style fitStyle, label string) fitLayout {
+	layout := layoutFitText(text, box.W, box.H, style, func(size float64) textMeasurer {
+		return fontSizeMeasurer{c.pdf, size}
+	})
+	if len(layout.Lines) == 0 {
+		return layout
+	}
+	if layout.Truncated {
+		writeEventLog("WARN", fmt.Sprintf("%sが枠に収まらないため末尾を省略しました: %q", label, text))
+	}
+
+	c.pdf.SetFontSize(layout.Size)
+	lineHeight := fitLineHeight(layout.Size)
+	top := box.Y + (box.H-float64(len(layout.Lines))*lineHeight)/2
+	for i, line := range layout.Lines {
+		x := box.X
+		switch style.Align {
+		case alignCenter:
+			x += (box.W - c.pdf.GetStringWidth(line)) / 2
+		case alignRight:
+			x += box.W - c.pdf.GetStringWidth(line)
+		}
+		baseline := top + lineHeight*(float64(i)+0.5) + layout.Size*ptToMM*fitBaselineRise
+		c.pdf.Text(x, baseline, line)
+	}
+	return layout
+}
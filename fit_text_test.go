@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// scaledMeasurer - monoMeasurer（10pt）を文字サイズに合わせて拡大・縮小
+type scaledMeasurer float64
+
+func (s scaledMeasurer) GetStringWidth(text string) float64 {
+	return monoMeasurer{}.GetStringWidth(text) * float64(s) / 10
+}
+
+func measureAt(size float64) textMeasurer { return scaledMeasurer(size) }
+
+func TestLayoutFitText(t *testing.T) {
+	style := fitStyle{MinSize: 6, MaxSize: 10}
+	tests := []struct {
+		name          string
+		text          string
+		w, h          float64
+		style         fitStyle
+		wantSize      float64
+		wantLines     []string
+		wantTruncated bool
+	}{
+		{name: "空", text: "  ", w: 28, h: 7, style: style, wantSize: 10},
+		{name: "そのまま収まる", text: "山田　太郎", w: 28, h: 7, style: style, wantSize: 10, wantLines: []string{"山田　太郎"}},
+		{name: "1行の枠では縮小", text: "品川500あ1234", w: 20, h: 4, style: style, wantSize: 8.5, wantLines: []string{"品川500あ1234"}},
+		{name: "高さがあれば折り返す", text: "東京本社営業部第一課", w: 20, h: 9, style: style, wantSize: 10, wantLines: []string{"東京本社営", "業部第一課"}},
+		{name: "縮小と折り返し", text: "東京本社営業部第一課長", w: 20, h: 7, style: style, wantSize: 9, wantLines: []string{"東京本社営業", "部第一課長"}},
+		{name: "最小サイズでも収まらない", text: "非常に長い名称です", w: 10, h: 3, style: style, wantSize: 6, wantLines: []string{"非常に…"}, wantTruncated: true},
+		{name: "刻みに乗らない最小サイズで収まる", text: "東京本社", w: 8.8, h: 3, style: fitStyle{MinSize: 6.25, MaxSize: 10}, wantSize: 6.25, wantLines: []string{"東京本社"}},
+		{name: "最小サイズで行を省く", text: "東京本社営業部第一課", w: 9, h: 5, style: fitStyle{MinSize: 6.25, MaxSize: 10}, wantSize: 6.25, wantLines: []string{"東京本社", "営業部…"}, wantTruncated: true},
+		{name: "最小が最大より大きい", text: "福岡", w: 20, h: 5, style: fitStyle{MinSize: 12, MaxSize: 10}, wantSize: 10, wantLines: []string{"福岡"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := layoutFitText(tt.text, tt.w, tt.h, tt.style, measureAt)
+			if got.Size != tt.wantSize || !reflect.DeepEqual(got.Lines, tt.wantLines) || got.Truncated != tt.wantTruncated {
+				t.Errorf("layoutFitText(%q) = %+v, want size %v lines %q truncated %v", tt.text, got, tt.wantSize, tt.wantLines, tt.wantTruncated)
+			}
+			m := measureAt(got.Size)
+			for _, line := range got.Lines {
+				if m.GetStringWidth(line) > tt.w {
+					t.Errorf("line %q is wider than %vmm", line, tt.w)
+				}
+			}
+			if float64(len(got.Lines))*fitLineHeight(got.Size) > tt.h {
+				t.Errorf("%d lines at %vpt are taller than %vmm", len(got.Lines), got.Size, tt.h)
+			}
+		})
+	}
+}
+
+func TestLayoutFitTextShortBox(t *testing.T) {
+	// 1行の高さも足りない枠では行を省かずに最小サイズで描画し、省略記号を付けない
+	got := layoutFitText("福岡", 20, 1, fitStyle{MinSize: 6, MaxSize: 10}, measureAt)
+	if got.Size != 6 || !reflect.DeepEqual(got.Lines, []string{"福岡"}) || got.Truncated {
+		t.Errorf("layoutFitText() = %+v, want size 6 lines [福岡] not truncated", got)
+	}
+}
+
+func TestLayoutFitTextParagraphs(t *testing.T) {
+	got := layoutFitText("会議資料\n\n別添", 40, 20, fitStyle{MinSize: 6, MaxSize: 10}, measureAt)
+	if want := []string{"会議資料", "", "別添"}; strings.Join(got.Lines, "|") != strings.Join(want, "|") {
+		t.Errorf("layoutFitText() lines = %q, want %q", got.Lines, want)
+	}
+}
+
+func TestRenderPDFWithLongFields(t *testing.T) {
+	long := strings.Repeat("長い文字列", 10)
+	items := []Item{{
+		Name:    long,
+		Car:     long,
+		Purpose: StringPtr(long),
+		Office:  StringPtr(long),
+		Price:   123456789,
+		Ryohi: []Ryohi{
+			{Date: StringPtr("2024-12-17"), Dest: StringPtr(long), Detail: []string{long}, Kukan: StringPtr(long), Price: IntPtr(99999999)},
+		},
+	}}
+
+	var buf bytes.Buffer
+	if _, err := RenderPDF(&buf, "", items); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Error("output is not a PDF")
+	}
+}
@@ -0,0 +1,15 @@
+//go:build linux
+
+package main
+
+// japaneseFonts - PDFに埋め込む日本語フォントの候補（先に見つかったものを使う）
+// 帳票は "yumin" の名前で描画するため、明朝体のIPAフォントを同じ名前で登録する
+// （Debian/Ubuntu: fonts-ipafont-mincho, RHEL/Fedora: ipa-pmincho-fonts）
+var japaneseFonts = []struct {
+	name string
+	path string
+}{
+	{"yumin", "/usr/share/fonts/opentype/ipafont-mincho/ipam.ttf"},
+	{"yumin", "/usr/share/fonts/truetype/fonts-japanese-mincho.ttf"},
+	{"yumin", "/usr/share/fonts/ipa-mincho/ipam.ttf"},
+}
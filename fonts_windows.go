@@ -0,0 +1,13 @@
+//go:build windows
+
+package main
+
+// japaneseFonts - PDFに埋め込む日本語フォントの候補（先に見つかったものを使う）
+var japaneseFonts = []struct {
+	name string
+	path string
+}{
+	{"yumin", "C:/Windows/Fonts/yumin.ttf"},
+	{"yugothm", "C:/Windows/Fonts/yugothm.ttf"},
+	{"meiryo", "C:/Windows/Fonts/meiryo.ttf"},
+}
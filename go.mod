@@ -4,4 +4,6 @@ go 1.24.4
 
 require github.com/jung-kurt/gofpdf v1.16.2
 
-require golang.org/x/sys v0.34.0 // indirect
+require golang.org/x/sys v0.34.0
+
+require github.com/boombuler/barcode v1.1.0
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// 区間テキストの正規化ルール
+// 配車システムの料金表記が増えてもリリースせずに対応できるよう、置換と区切り文字を設定ファイル（pdf.kukanRules・pdf.kukanSeparator）で変更できる
+
+// 正規化ルールの種類
+const (
+	KukanRuleLiteral = "literal" // 文字列をそのまま置換
+	KukanRuleRegex   = "regex"   // 正規表現で置換（replace で $1 などを使える）
+)
+
+// KukanRule - 区間テキストの正規化ルール（上から順に適用）
+type KukanRule struct {
+	Type        string `json:"type"`        // literal（既定）または regex
+	Pattern     string `json:"pattern"`     // 置換する文字列・正規表現
+	Replace     string `json:"replace"`     // 置換後の文字列
+	Description string `json:"description"` // 説明（ログ・エラーメッセージ用）
+}
+
+// defaultKukanSeparator - 区間の区切り文字 (全角スペース、｜、半角スペース+|、など)
+const defaultKukanSeparator = `[　｜]| \||\|`
+
+// defaultKukanRules - 既定の正規化ルール
+func defaultKukanRules() []KukanRule {
+	return []KukanRule{
+		{Type: KukanRuleLiteral, Pattern: "_九州外空車適用", Replace: "　九州外空車適用", Description: "九州外空車適用を別の区間に分ける"},
+		{Type: KukanRuleLiteral, Pattern: "適用*   追加", Replace: "適用*　追加", Description: "適用*と追加の間の空白を1つの区切りにする"},
+		{Type: KukanRuleLiteral, Pattern: " ", Replace: "　", Description: "半角スペースを全角スペースに変換"},
+	}
+}
+
+// kukanNormalizer - コンパイル済みの正規化ルールと区切り文字
+type kukanNormalizer struct {
+	rules     []func(string) string
+	separator *regexp.Regexp
+}
+
+// newKukanNormalizer - ルールを検証してコンパイル
+func newKukanNormalizer(rules []KukanRule, separator string) (*kukanNormalizer, error) {
+	n := &kukanNormalizer{}
+	for i, rule := range rules {
+		name := fmt.Sprintf("ルール%d", i+1)
+		if rule.Description != "" {
+			name += "（" + rule.Description + "）"
+		}
+		if rule.Pattern == "" {
+			return nil, fmt.Errorf("%s: pattern が空です", name)
+		}
+		switch rule.Type {
+		case "", KukanRuleLiteral:
+			n.rules = append(n.rules, func(s string) string {
+				return strings.ReplaceAll(s, rule.Pattern, rule.Replace)
+			})
+		case KukanRuleRegex:
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("%s: 正規表現が不正です: %v", name, err)
+			}
+			n.rules = append(n.rules, func(s string) string {
+				return re.ReplaceAllString(s, rule.Replace)
+			})
+		default:
+			return nil, fmt.Errorf("%s: type は literal か regex を指定してください: %q", name, rule.Type)
+		}
+	}
+
+	if separator == "" {
+		separator = defaultKukanSeparator
+	}
+	re, err := regexp.Compile(separator)
+	if err != nil {
+		return nil, fmt.Errorf("区切り文字の正規表現が不正です: %v", err)
+	}
+	n.separator = re
+	return n, nil
+}
+
+// normalize - ルールを順に適用
+func (n *kukanNormalizer) normalize(kukan string) string {
+	for _, rule := range n.rules {
+		kukan = rule(kukan)
+	}
+	return kukan
+}
+
+// split - 正規化して区切り文字で分割
+func (n *kukanNormalizer) split(kukan string) []string {
+	return n.separator.Split(n.normalize(kukan), -1)
+}
+
+// kukanRules - 設定のルール（省略した場合は既定のルール）
+func (c PDFConfig) kukanRules() []KukanRule {
+	if c.KukanRules == nil {
+		return defaultKukanRules()
+	}
+	return c.KukanRules
+}
+
+// 設定からコンパイルしたルールのキャッシュ（設定が変わったときだけコンパイルし直す）
+var kukanCache struct {
+	sync.Mutex
+	rules      []KukanRule
+	separator  string
+	normalizer *kukanNormalizer
+}
+
+// currentKukanNormalizer - 現在の設定の正規化ルール（不正な場合は既定のルール）
+func currentKukanNormalizer() *kukanNormalizer {
+	pdf := currentConfig().PDF
+	rules := pdf.kukanRules()
+
+	kukanCache.Lock()
+	defer kukanCache.Unlock()
+	if kukanCache.normalizer != nil && kukanCache.separator == pdf.KukanSeparator && reflect.DeepEqual(kukanCache.rules, rules) {
+		return kukanCache.normalizer
+	}
+
+	n, err := newKukanNormalizer(rules, pdf.KukanSeparator)
+	if err != nil {
+		writeEventLog("WARN", fmt.Sprintf("区間の正規化ルールが不正です（既定のルールを使用）: %v", err))
+		n, _ = newKukanNormalizer(defaultKukanRules(), defaultKukanSeparator)
+	}
+	kukanCache.rules, kukanCache.separator, kukanCache.normalizer = rules, pdf.KukanSeparator, n
+	return n
+}
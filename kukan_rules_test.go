@@ -0,0 +1,151 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKukanNormalizer(t *testing.T) {
+	tests := []struct {
+		name      string
+		rules     []KukanRule
+		separator string
+		kukan     string
+		want      []string
+	}{
+		{name: "既定: 九州外空車適用を分ける", rules: defaultKukanRules(), kukan: "長崎_九州外空車適用", want: []string{"長崎", "九州外空車適用"}},
+		{name: "既定: 適用*と追加", rules: defaultKukanRules(), kukan: "基本料金適用*   追加", want: []string{"基本料金適用*", "追加"}},
+		{name: "既定: 半角スペース", rules: defaultKukanRules(), kukan: "長崎 佐世保", want: []string{"長崎", "佐世保"}},
+		{name: "既定: 縦棒", rules: defaultKukanRules(), kukan: "長崎｜佐世保|福岡", want: []string{"長崎", "佐世保", "福岡"}},
+		{name: "ルールなし", rules: []KukanRule{}, kukan: "長崎_九州外空車適用", want: []string{"長崎_九州外空車適用"}},
+		{
+			name:  "文字列の置換",
+			rules: []KukanRule{{Pattern: "→", Replace: "　"}},
+			kukan: "東京→大阪", want: []string{"東京", "大阪"},
+		},
+		{
+			name:  "正規表現の置換",
+			rules: []KukanRule{{Type: KukanRuleRegex, Pattern: `\((\d+)km\)`, Replace: "　${1}キロ"}},
+			kukan: "長崎(120km)", want: []string{"長崎", "120キロ"},
+		},
+		{
+			name: "上から順に適用",
+			rules: []KukanRule{
+				{Pattern: "高速道路", Replace: "高速"},
+				{Type: KukanRuleRegex, Pattern: `高速(料金|代)`, Replace: "高速代"},
+			},
+			kukan: "高速道路料金", want: []string{"高速代"},
+		},
+		{
+			name:  "区切り文字の変更",
+			rules: []KukanRule{}, separator: `/|、`,
+			kukan: "東京/大阪、京都", want: []string{"東京", "大阪", "京都"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := newKukanNormalizer(tt.rules, tt.separator)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, part := range n.split(tt.kukan) {
+				if part != "" {
+					got = append(got, part)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("split(%q) = %q, want %q", tt.kukan, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewKukanNormalizerErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		rules     []KukanRule
+		separator string
+		wantErr   string
+	}{
+		{name: "空のパターン", rules: []KukanRule{{Replace: "　", Description: "空白"}}, wantErr: "ルール1（空白）: pattern が空です"},
+		{name: "不明な種類", rules: []KukanRule{{Pattern: "a"}, {Type: "glob", Pattern: "*"}}, wantErr: "ルール2: type は literal か regex"},
+		{name: "不正な正規表現", rules: []KukanRule{{Type: KukanRuleRegex, Pattern: "(適用"}}, wantErr: "ルール1: 正規表現が不正です"},
+		{name: "不正な区切り文字", rules: nil, separator: "[", wantErr: "区切り文字の正規表現が不正です"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newKukanNormalizer(tt.rules, tt.separator)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKukanRulesFromConfig(t *testing.T) {
+	original := currentConfig()
+	t.Cleanup(func() { setConfig(original) })
+
+	tests := []struct {
+		name    string
+		config  string
+		kukan   string
+		want    []string
+		wantErr string
+	}{
+		{name: "省略時は既定のルール", config: `{}`, kukan: "長崎_九州外空車適用", want: []string{"長崎", "九州外空車適用"}},
+		{
+			name:   "ルールを置き換える",
+			config: `{"pdf":{"kukanRules":[{"type":"regex","pattern":"_(九州外|九州内)","replace":"　$1","description":"九州外・九州内を分ける"}]}}`,
+			kukan:  "長崎_九州内空車", want: []string{"長崎", "九州内空車"},
+		},
+		{
+			name:   "区切り文字を置き換える",
+			config: `{"pdf":{"kukanSeparator":"→"}}`,
+			kukan:  "東京→大阪", want: []string{"東京", "大阪"},
+		},
+		{name: "不正なルール", config: `{"pdf":{"kukanRules":[{"type":"regex","pattern":"(","replace":""}]}}`, wantErr: "区間正規化ルールエラー"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), configFileName)
+			if err := os.WriteFile(path, []byte(tt.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := loadConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			setConfig(cfg)
+			if got := splitKukan(tt.kukan); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitKukan(%q) = %q, want %q", tt.kukan, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrentKukanNormalizerFallsBackToDefaults(t *testing.T) {
+	original := currentConfig()
+	t.Cleanup(func() { setConfig(original) })
+
+	cfg := defaultConfig()
+	cfg.PDF.KukanSeparator = "["
+	setConfig(cfg)
+	if got := splitKukan("長崎　佐世保"); !reflect.DeepEqual(got, []string{"長崎", "佐世保"}) {
+		t.Errorf("invalid separator should fall back to defaults, got %q", got)
+	}
+}
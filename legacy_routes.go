@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// 旧バージョンのルート（/v1 導入前のパス）
+// PHPクライアントが Item 配列をそのまま /generate-pdf に送信しているため互換性を維持する。
+// 新しいクライアントは /v1 のルートを使うこと。
+
+// deprecatedRoute - 旧ルートに非推奨ヘッダーを付与
+func deprecatedRoute(successor string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+		w.Header().Add("Access-Control-Expose-Headers", "Deprecation, Link")
+		writeRequestLog(r, "DEBUG", fmt.Sprintf("非推奨ルートへのアクセス: %s (移行先: %s)", r.URL.Path, successor))
+		h(w, r)
+	}
+}
+
+// decodeLegacyPrintRequest - 旧形式のリクエストを解析
+// 最初の空白以外の文字が [ なら Item 配列、それ以外は PrintRequest として解析する（もう一方の形式では解析しない）。
+// items が空の PrintRequest はそのまま返し、検証で items のエラーにする。
+// Item 配列形式の場合は印刷しない。
+func decodeLegacyPrintRequest(body []byte) (req PrintRequest, legacyArray bool, err error) {
+	if trimmed := bytes.TrimLeft(body, " \t\r\n"); len(trimmed) == 0 || trimmed[0] != '[' {
+		if err := json.Unmarshal(body, &req); err != nil {
+			return PrintRequest{}, false, err
+		}
+		return req, false, nil
+	}
+
+	var items []Item
+	if err := json.Unmarshal(body, &items); err != nil {
+		return PrintRequest{}, false, err
+	}
+	return PrintRequest{Items: items}, true, nil
+}
+
+// HTTPハンドラー: PDF生成エンドポイント（旧形式、/v1/generate-pdf を推奨）
+func generatePDFHandler(w http.ResponseWriter, r *http.Request) {
+	if !acceptPost(w, r) {
+		return
+	}
+	writeRequestLog(r, "INFO", fmt.Sprintf("PDF生成リクエストを受信 from %s", r.RemoteAddr))
+
+	body, ok := readRequestBody(w, r)
+	if !ok {
+		return
+	}
+
+	printRequest, legacyArray, err := decodeLegacyPrintRequest(body)
+	if err != nil {
+		writeInvalidJSON(w, r, err)
+		return
+	}
+	if legacyArray {
+		writeRequestLog(r, "INFO", "従来形式で受信（印刷なし）")
+	}
+
+	servePDFRequest(w, r, printRequest, printRequest.Print)
+}
+
+// HTTPハンドラー: PDF印刷専用エンドポイント（旧形式、/v1/print-pdf を推奨）
+func printPDFHandler(w http.ResponseWriter, r *http.Request) {
+	if !acceptPost(w, r) {
+		return
+	}
+	writeRequestLog(r, "INFO", fmt.Sprintf("PDF印刷リクエストを受信 from %s", r.RemoteAddr))
+
+	body, ok := readRequestBody(w, r)
+	if !ok {
+		return
+	}
+
+	// 未知のフィールドは無視する（旧クライアント互換）
+	var printRequest PrintRequest
+	if err := json.Unmarshal(body, &printRequest); err != nil {
+		writeInvalidJSON(w, r, err)
+		return
+	}
+
+	servePDFRequest(w, r, printRequest, true)
+}
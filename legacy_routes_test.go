@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodeLegacyPrintRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantItems   int
+		wantPrint   bool
+		wantArray   bool
+		wantErr     bool
+		wantErrMsg  string // エラーに含まれる文字（PrintRequest を配列として解析したエラーにならない）
+		wantPrinter string
+	}{
+		{name: "PrintRequest形式", body: `{"items":[{"name":"a"}],"print":true,"printerName":"Canon"}`, wantItems: 1, wantPrint: true, wantPrinter: "Canon"},
+		{name: "Item配列形式", body: `[{"name":"a"},{"name":"b"}]`, wantItems: 2, wantArray: true},
+		{name: "Item配列形式は印刷しない", body: `[{"name":"a","print":true}]`, wantItems: 1, wantArray: true},
+		{name: "未知のフィールドは無視", body: `{"items":[{"name":"a","extra":1}],"copies":2}`, wantItems: 1},
+		{name: "空の配列", body: `[]`, wantItems: 0, wantArray: true},
+		{name: "itemsが空のオブジェクト", body: `{"items":[]}`, wantItems: 0},
+		{name: "itemsのないオブジェクト", body: ` {"print":true}`, wantItems: 0, wantPrint: true},
+		{name: "前後の空白", body: "\n\t [{\"name\":\"a\"}]\n", wantItems: 1, wantArray: true},
+		{name: "不正なJSON", body: `{not json`, wantErr: true},
+		{name: "空の本文", body: ``, wantErr: true},
+		{name: "PrintRequestの型エラー", body: `{"items":[{"name":1}]}`, wantErr: true, wantErrMsg: "PrintRequest.items.0.name"},
+		{name: "Item配列の型エラー", body: `[{"name":1}]`, wantErr: true, wantErrMsg: "cannot unmarshal number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, legacyArray, err := decodeLegacyPrintRequest([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !strings.Contains(err.Error(), tt.wantErrMsg) {
+					t.Errorf("err = %v, want %q", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if len(req.Items) != tt.wantItems || req.Print != tt.wantPrint || legacyArray != tt.wantArray {
+				t.Errorf("got items=%d print=%v array=%v, want items=%d print=%v array=%v",
+					len(req.Items), req.Print, legacyArray, tt.wantItems, tt.wantPrint, tt.wantArray)
+			}
+			printer := ""
+			if req.PrinterName != nil {
+				printer = *req.PrinterName
+			}
+			if printer != tt.wantPrinter {
+				t.Errorf("printer = %q, want %q", printer, tt.wantPrinter)
+			}
+		})
+	}
+}
+
+func TestDeprecationHeaders(t *testing.T) {
+	tests := []struct {
+		path     string
+		wantLink string
+	}{
+		{"/generate-pdf", `</v1/generate-pdf>; rel="successor-version"`},
+		{"/print-pdf", `</v1/print-pdf>; rel="successor-version"`},
+		{"/print", `</v1/print>; rel="successor-version"`},
+		{"/v1/generate-pdf", ""},
+		{"/v1/print-pdf", ""},
+		{"/v1/print", ""},
+		{"/health", ""},
+	}
+
+	mux := newServeMux()
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("OPTIONS", tt.path, bytes.NewReader(nil)))
+
+			wantDeprecation := ""
+			if tt.wantLink != "" {
+				wantDeprecation = "true"
+			}
+			if got := rec.Header().Get("Deprecation"); got != wantDeprecation {
+				t.Errorf("Deprecation = %q, want %q", got, wantDeprecation)
+			}
+			if got := rec.Header().Get("Link"); got != tt.wantLink {
+				t.Errorf("Link = %q, want %q", got, tt.wantLink)
+			}
+		})
+	}
+}
@@ -0,0 +1,472 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LevelFatal - 致命的エラー（slog標準にはないレベル）
+const LevelFatal = slog.Level(12)
+
+// ログ出力の状態
+var (
+	logger     atomic.Pointer[slog.Logger]
+	logLevel   = new(slog.LevelVar)
+	logFile    *rotatingWriter
+	logSetupMu sync.Mutex
+)
+
+// eventLogger - Windowsイベントログなどの外部出力先
+type eventLogger interface {
+	Info(eid uint32, msg string) error
+	Warning(eid uint32, msg string) error
+	Error(eid uint32, msg string) error
+}
+
+// parseLogLevel - 文字列のログレベルを変換
+func parseLogLevel(level string) (slog.Level, error) {
+	switch strings.ToUpper(strings.TrimSpace(level)) {
+	case "DEBUG":
+		return slog.LevelDebug, nil
+	case "", "INFO":
+		return slog.LevelInfo, nil
+	case "WARN", "WARNING":
+		return slog.LevelWarn, nil
+	case "ERROR":
+		return slog.LevelError, nil
+	case "FATAL":
+		return LevelFatal, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("不明なログレベル: %s", level)
+}
+
+// replaceLevelAttr - FATALレベルを名前付きで出力
+func replaceLevelAttr(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.LevelKey && len(groups) == 0 {
+		if level, ok := a.Value.Any().(slog.Level); ok && level >= LevelFatal {
+			return slog.String(slog.LevelKey, "FATAL")
+		}
+	}
+	return a
+}
+
+func newConsoleHandler(w io.Writer, level slog.Leveler) slog.Handler {
+	return slog.NewTextHandler(w, &slog.HandlerOptions{Level: level, ReplaceAttr: replaceLevelAttr})
+}
+
+func newJSONLogHandler(w io.Writer, level slog.Leveler) slog.Handler {
+	return slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level, ReplaceAttr: replaceLevelAttr})
+}
+
+// setupLogger - 設定に従ってロガーを構築
+// 出力先: JSONファイル（ローテーション付き）、コンソール（サービス以外）、Windowsイベントログ（サービス時）
+func setupLogger(cfg LogConfig) error {
+	logSetupMu.Lock()
+	defer logSetupMu.Unlock()
+
+	level, levelErr := parseLogLevel(cfg.Level)
+	logLevel.Set(level)
+
+	// 古いファイルは新しいロガーに切り替えてから閉じる（再読み込み中に書き込まれたログを失わないように）
+	// 同じファイルの場合は開き直さず、ローテーションの設定だけを変更する
+	oldFile := logFile
+	logFile = nil
+	var handlers []slog.Handler
+	var fileErr error
+	if cfg.File != "" {
+		if oldFile != nil && oldFile.path == cfg.File {
+			oldFile.configure(cfg.MaxSizeMB, cfg.MaxAgeDays, cfg.MaxBackups)
+			logFile, oldFile = oldFile, nil
+		} else if writer, err := newRotatingWriter(cfg.File, cfg.MaxSizeMB, cfg.MaxAgeDays, cfg.MaxBackups); err != nil {
+			fileErr = err
+		} else {
+			logFile = writer
+		}
+		if logFile != nil {
+			handlers = append(handlers, newJSONLogHandler(logFile, logLevel))
+		}
+	}
+	handlers = append(handlers,
+		&conditionalHandler{Handler: newConsoleHandler(os.Stdout, logLevel), enabled: func() bool { return elog == nil }},
+		&eventLogHandler{level: logLevel, sink: func() eventLogger {
+			if elog == nil {
+				return nil
+			}
+			return elog
+		}},
+	)
+
+	logger.Store(slog.New(&requestContextHandler{Handler: &multiHandler{handlers: handlers}}))
+	if oldFile != nil {
+		oldFile.Close()
+	}
+
+	if levelErr != nil {
+		return levelErr
+	}
+	return fileErr
+}
+
+// writeEventLog - ログ書き込み関数（Windowsサービス対応）
+func writeEventLog(level string, message string) {
+	logWithContext(context.Background(), level, message)
+}
+
+// writeRequestLog - リクエストIDを付けてログを書き込み
+func writeRequestLog(r *http.Request, level string, message string) {
+	logWithContext(r.Context(), level, message)
+}
+
+func logWithContext(ctx context.Context, level string, message string) {
+	lv, err := parseLogLevel(level)
+	if err != nil {
+		lv = slog.LevelInfo
+	}
+	currentLogger().Log(ctx, lv, message)
+}
+
+// currentLogger - 現在のロガーを取得（未設定時はコンソール出力）
+func currentLogger() *slog.Logger {
+	if l := logger.Load(); l != nil {
+		return l
+	}
+	return slog.New(&requestContextHandler{Handler: newConsoleHandler(os.Stdout, logLevel)})
+}
+
+// requestContextHandler - コンテキストのリクエストIDをログ属性に追加
+type requestContextHandler struct {
+	slog.Handler
+}
+
+func (h *requestContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := requestIDFromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *requestContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &requestContextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *requestContextHandler) WithGroup(name string) slog.Handler {
+	return &requestContextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// multiHandler - 複数の出力先へ同じログを送る
+type multiHandler struct {
+	handlers []slog.Handler
+}
+
+func (m *multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m.handlers {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var firstErr error
+	for _, h := range m.handlers {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, r.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (m *multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(m.handlers))
+	for i, h := range m.handlers {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return &multiHandler{handlers: handlers}
+}
+
+func (m *multiHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(m.handlers))
+	for i, h := range m.handlers {
+		handlers[i] = h.WithGroup(name)
+	}
+	return &multiHandler{handlers: handlers}
+}
+
+// conditionalHandler - 条件を満たすときだけ出力するハンドラー
+type conditionalHandler struct {
+	slog.Handler
+	enabled func() bool
+}
+
+func (h *conditionalHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.enabled() && h.Handler.Enabled(ctx, level)
+}
+
+func (h *conditionalHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &conditionalHandler{Handler: h.Handler.WithAttrs(attrs), enabled: h.enabled}
+}
+
+func (h *conditionalHandler) WithGroup(name string) slog.Handler {
+	return &conditionalHandler{Handler: h.Handler.WithGroup(name), enabled: h.enabled}
+}
+
+// eventLogHandler - Windowsイベントログへの出力
+type eventLogHandler struct {
+	level slog.Leveler
+	sink  func() eventLogger
+	attrs []slog.Attr
+}
+
+func (h *eventLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.sink() != nil && level >= h.level.Level()
+}
+
+func (h *eventLogHandler) Handle(_ context.Context, r slog.Record) error {
+	sink := h.sink()
+	if sink == nil {
+		return nil
+	}
+
+	// メッセージの後に属性を key=value 形式で付加
+	var sb strings.Builder
+	sb.WriteString(r.Message)
+	appendAttr := func(a slog.Attr) bool {
+		fmt.Fprintf(&sb, " %s=%v", a.Key, a.Value)
+		return true
+	}
+	for _, a := range h.attrs {
+		appendAttr(a)
+	}
+	r.Attrs(appendAttr)
+
+	switch {
+	case r.Level >= slog.LevelError:
+		return sink.Error(1, sb.String())
+	case r.Level >= slog.LevelWarn:
+		return sink.Warning(1, sb.String())
+	default:
+		return sink.Info(1, sb.String())
+	}
+}
+
+func (h *eventLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &eventLogHandler{level: h.level, sink: h.sink, attrs: append(append([]slog.Attr{}, h.attrs...), attrs...)}
+}
+
+func (h *eventLogHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// rotatingWriter - サイズと経過日数でローテーションするログファイル
+type rotatingWriter struct {
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	closed   bool
+	now      func() time.Time
+}
+
+func newRotatingWriter(path string, maxSizeMB, maxAgeDays, maxBackups int) (*rotatingWriter, error) {
+	w := &rotatingWriter{path: path, now: time.Now}
+	w.configure(maxSizeMB, maxAgeDays, maxBackups)
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// configure - ローテーションの設定を変更（開いているファイルはそのまま）
+func (w *rotatingWriter) configure(maxSizeMB, maxAgeDays, maxBackups int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.maxSize = int64(maxSizeMB) * 1024 * 1024
+	w.maxAge = time.Duration(maxAgeDays) * 24 * time.Hour
+	w.maxBackups = maxBackups
+}
+
+func (w *rotatingWriter) open() error {
+	if dir := filepath.Dir(w.path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("ログディレクトリ作成エラー: %v", err)
+		}
+	}
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return fmt.Errorf("ログファイルオープンエラー: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("ログファイル情報取得エラー: %v", err)
+	}
+	w.file = file
+	w.size = info.Size()
+	w.openedAt = w.now()
+	return nil
+}
+
+// Write - 必要であればローテーションしてから書き込み
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			log.Printf("ログローテーションエラー: %v", err)
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *rotatingWriter) shouldRotate(incoming int64) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.maxSize > 0 && w.size+incoming > w.maxSize {
+		return true
+	}
+	return w.maxAge > 0 && w.now().Sub(w.openedAt) >= w.maxAge
+}
+
+// rotate - 現在のファイルを日時付きの名前に変更して新しいファイルを開く
+func (w *rotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+
+	ext := filepath.Ext(w.path)
+	base := strings.TrimSuffix(w.path, ext)
+	backup := fmt.Sprintf("%s-%s%s", base, w.now().Format("20060102T150405.000"), ext)
+	if err := os.Rename(w.path, backup); err != nil {
+		return w.open()
+	}
+	w.removeOldBackups()
+	return w.open()
+}
+
+// backups - ローテーション済みファイル一覧（古い順）
+func (w *rotatingWriter) backups() []string {
+	ext := filepath.Ext(w.path)
+	base := strings.TrimSuffix(w.path, ext)
+	matches, _ := filepath.Glob(base + "-*" + ext)
+	sort.Strings(matches)
+	return matches
+}
+
+func (w *rotatingWriter) removeOldBackups() {
+	if w.maxBackups <= 0 {
+		return
+	}
+	backups := w.backups()
+	for len(backups) > w.maxBackups {
+		os.Remove(backups[0])
+		backups = backups[1:]
+	}
+}
+
+// Close - ファイルを閉じる
+func (w *rotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// リクエストIDのコンテキストキー
+type contextKey int
+
+const requestIDKey contextKey = iota
+
+// requestIDFromContext - コンテキストからリクエストIDを取得
+func requestIDFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDKey).(string); ok {
+		return id
+	}
+	return ""
+}
+
+// newRequestID - ランダムなリクエストIDを生成
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// validRequestID - クライアント指定のリクエストIDとして受け入れ可能か
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// requestIDMiddleware - リクエストIDを付与し X-Request-ID ヘッダーで返す
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", id)
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
+
+		r = r.WithContext(context.WithValue(r.Context(), requestIDKey, id))
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rec, r)
+
+		currentLogger().LogAttrs(r.Context(), slog.LevelDebug, "request completed",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("remote", r.RemoteAddr))
+	})
+}
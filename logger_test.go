@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected slog.Level
+		wantErr  bool
+	}{
+		{"DEBUG", slog.LevelDebug, false},
+		{"info", slog.LevelInfo, false},
+		{"", slog.LevelInfo, false},
+		{"WARN", slog.LevelWarn, false},
+		{"warning", slog.LevelWarn, false},
+		{"ERROR", slog.LevelError, false},
+		{"FATAL", LevelFatal, false},
+		{"VERBOSE", slog.LevelInfo, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			level, err := parseLogLevel(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLogLevel(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if level != tt.expected {
+				t.Errorf("parseLogLevel(%q) = %v, expected %v", tt.input, level, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRotatingWriterRotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.log")
+	w, err := newRotatingWriter(path, 0, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.maxSize = 10
+
+	current := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	w.now = func() time.Time {
+		current = current.Add(time.Second)
+		return current
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := w.Write([]byte("0123456789\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if backups := w.backups(); len(backups) != 2 {
+		t.Errorf("backups = %v, expected 2 files (maxBackups)", backups)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "0123456789\n" {
+		t.Errorf("active file = %q, expected only the last line", data)
+	}
+}
+
+func TestRotatingWriterRotatesByAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.log")
+	w, err := newRotatingWriter(path, 100, 1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	current := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return current }
+	w.openedAt = current
+
+	w.Write([]byte("day1\n"))
+	current = current.Add(25 * time.Hour)
+	w.Write([]byte("day2\n"))
+
+	if backups := w.backups(); len(backups) != 1 {
+		t.Fatalf("backups = %v, expected 1", backups)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "day2\n" {
+		t.Errorf("active file = %q, expected %q", data, "day2\n")
+	}
+}
+
+func TestSetupLoggerWritesJSONWithRequestID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.log")
+	if err := setupLogger(LogConfig{Level: "INFO", File: path, MaxSizeMB: 1}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		setupLogger(LogConfig{Level: "INFO"})
+	}()
+
+	handler := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeRequestLog(r, "INFO", "テストメッセージ")
+		writeRequestLog(r, "DEBUG", "出力されないメッセージ")
+	}))
+	req := httptest.NewRequest("GET", "/health", nil)
+	req.Header.Set("X-Request-ID", "abc-123")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("X-Request-ID"); got != "abc-123" {
+		t.Errorf("X-Request-ID = %q, expected the client supplied ID", got)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("log line is not JSON: %s", scanner.Text())
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("entries = %v, expected exactly 1 (DEBUG filtered)", entries)
+	}
+	if entries[0]["msg"] != "テストメッセージ" || entries[0]["level"] != "INFO" {
+		t.Errorf("unexpected entry: %v", entries[0])
+	}
+	if entries[0]["request_id"] != "abc-123" {
+		t.Errorf("request_id = %v, expected abc-123", entries[0]["request_id"])
+	}
+}
+
+func TestSetupLoggerReloadKeepsConcurrentLogs(t *testing.T) {
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first.log"), filepath.Join(dir, "second.log")
+	if err := setupLogger(LogConfig{Level: "INFO", File: first}); err != nil {
+		t.Fatal(err)
+	}
+	defer setupLogger(LogConfig{Level: "INFO"})
+
+	// 再読み込みの間も書き込みを続け、すべての行がどちらかのファイルに残ることを確認
+	const writers, lines = 4, 200
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < lines; j++ {
+				writeEventLog("INFO", "reload test")
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		if err := setupLogger(LogConfig{Level: "INFO", File: first, MaxSizeMB: 10 + i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+
+	// 別のファイルに切り替えた後は、古いファイルを閉じて新しいファイルに書き込む
+	if err := setupLogger(LogConfig{Level: "INFO", File: second}); err != nil {
+		t.Fatal(err)
+	}
+	writeEventLog("INFO", "after switch")
+
+	count := func(path, msg string) int {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Count(string(data), `"msg":"`+msg+`"`)
+	}
+	if got := count(first, "reload test"); got != writers*lines {
+		t.Errorf("logged %d lines during reload, want %d", got, writers*lines)
+	}
+	if count(first, "after switch") != 0 || count(second, "after switch") != 1 {
+		t.Error("log was not switched to the new file")
+	}
+}
+
+func TestRequestIDMiddlewareGeneratesID(t *testing.T) {
+	handler := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requestIDFromContext(r.Context()) == "" {
+			t.Error("request ID missing from context")
+		}
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Request-ID", strings.Repeat("x", 200)) // 長すぎるIDは置き換え
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	id := rec.Header().Get("X-Request-ID")
+	if len(id) != 16 {
+		t.Errorf("generated X-Request-ID = %q, expected 16 hex characters", id)
+	}
+}
+
+type recordingEventLog struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (r *recordingEventLog) record(kind, msg string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, kind+":"+msg)
+	return nil
+}
+
+func (r *recordingEventLog) Info(_ uint32, msg string) error    { return r.record("info", msg) }
+func (r *recordingEventLog) Warning(_ uint32, msg string) error { return r.record("warning", msg) }
+func (r *recordingEventLog) Error(_ uint32, msg string) error   { return r.record("error", msg) }
+
+func TestEventLogHandler(t *testing.T) {
+	sink := &recordingEventLog{}
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelWarn)
+	l := slog.New(&eventLogHandler{level: level, sink: func() eventLogger { return sink }})
+
+	l.Info("ignored")
+	l.Warn("プリンター応答なし", "printer", "LBP221")
+	l.Log(context.Background(), LevelFatal, "停止")
+
+	expected := []string{"warning:プリンター応答なし printer=LBP221", "error:停止"}
+	if strings.Join(sink.messages, "|") != strings.Join(expected, "|") {
+		t.Errorf("messages = %v, expected %v", sink.messages, expected)
+	}
+}
@@ -1,73 +1,29 @@
 package main
 
 import (
-	"archive/zip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
 	"os/signal"
 	"path/filepath"
-	"runtime"
-	"strings"
 	"syscall"
 	"time"
-
-	"golang.org/x/sys/windows/svc"
-	"golang.org/x/sys/windows/svc/debug"
-	"golang.org/x/sys/windows/svc/eventlog"
 )
 
+// HTTPサーバーの待ち受けポート
+const httpPort = ":8081"
+
 // Version information (set during build with -ldflags)
 // Development builds use "dev", production builds are set via CI
 var Version = "dev"
 
 // グローバル変数
-var (
-	httpServer *http.Server
-	elog       debug.Log
-)
-
-// Windowsサービスハンドラー
-type service struct{}
-
-func (m *service) Execute(args []string, r <-chan svc.ChangeRequest, changes chan<- svc.Status) (ssec bool, errno uint32) {
-	const cmdsAccepted = svc.AcceptStop | svc.AcceptShutdown | svc.AcceptPauseAndContinue
-	changes <- svc.Status{State: svc.StartPending}
-
-	// HTTPサーバーを起動
-	go startHTTPServer()
-
-	changes <- svc.Status{State: svc.Running, Accepts: cmdsAccepted}
-
-	// サービス制御メッセージを待機
-	for c := range r {
-		switch c.Cmd {
-		case svc.Interrogate:
-			changes <- c.CurrentStatus
-		case svc.Stop, svc.Shutdown:
-			writeEventLog("INFO", "サービス停止要求を受信")
-			if httpServer != nil {
-				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-				defer cancel()
-				httpServer.Shutdown(ctx)
-			}
-			changes <- svc.Status{State: svc.StopPending}
-			return
-		case svc.Pause:
-			changes <- svc.Status{State: svc.Paused, Accepts: cmdsAccepted}
-		case svc.Continue:
-			changes <- svc.Status{State: svc.Running, Accepts: cmdsAccepted}
-		default:
-			elog.Error(1, fmt.Sprintf("unexpected control request #%d", c))
-		}
-	}
-	return
-}
+var httpServer *http.Server
 
 // HTTPサーバー起動関数
 func startHTTPServer() {
@@ -75,63 +31,32 @@ func startHTTPServer() {
 	writeEventLog("INFO", fmt.Sprintf("バージョン: %s", Version))
 	writeEventLog("INFO", "Windowsフォント対応")
 
-	// 起動時に自動アップデートをチェック（dev環境では無効）
-	if Version != "dev" {
-		go func() {
-			// 少し待ってから実行（サーバー起動後）
-			time.Sleep(5 * time.Second)
-			checkForUpdates()
-		}()
-	} else {
+	// 起動時と定期的に自動アップデートをチェック（dev環境では無効）
+	if Version == "dev" {
 		writeEventLog("INFO", "開発環境のため自動アップデートを無効にしています")
+	} else if !selfUpdateSupported {
+		writeEventLog("INFO", "このプラットフォームでは自動アップデートに対応していません")
+	} else {
+		go runUpdateLoop(context.Background())
 	}
 
-	// HTTPルートの設定
-	http.HandleFunc("/generate-pdf", generatePDFHandler)
-	http.HandleFunc("/print-pdf", printPDFHandler)
-	http.HandleFunc("/print", envelopePrintHandler) // 新しい封筒印刷エンドポイント
-	http.HandleFunc("/health", healthHandler)
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		writeEventLog("INFO", fmt.Sprintf("ルートアクセス from %s", r.RemoteAddr))
-		fmt.Fprintf(w, `
-PDF Generator API Server %s
-
-Available endpoints:
-- POST /generate-pdf : Generate PDF from JSON data
-- POST /print-pdf    : Generate and print PDF
-- POST /print        : Print PDF file (envelope printing)
-- GET  /health       : Health check
-
-Example request (generate only):
-curl -X POST http://localhost:8081/generate-pdf \
-  -H "Content-Type: application/json" \
-  -d '[{"car":"test","name":"テスト","ryohi":[]}]'
-
-Example request (generate and print):
-curl -X POST http://localhost:8081/print-pdf \
-  -H "Content-Type: application/json" \
-  -d '{"items":[{"car":"test","name":"テスト","ryohi":[]}],"print":true}'
-
-Example request (generate and print to specific printer):
-curl -X POST http://localhost:8081/generate-pdf \
-  -H "Content-Type: application/json" \
-  -d '{"items":[{"car":"test","name":"テスト","ryohi":[]}],"print":true,"printerName":"Canon Printer"}'
-
-Version: %s
-Platform: %s %s
-`, Version, Version, runtime.GOOS, runtime.GOARCH)
-	})
+	// 印刷キューを設定値で作成
+	printCfg := currentConfig().Print
+	jobQueue = newPrintQueue(printCfg.MaxConcurrent, printCfg.QueueSize)
 
 	// サーバー起動
-	port := ":8081"
+	port := httpPort
 	writeEventLog("INFO", fmt.Sprintf("HTTPサーバーを起動中... http://localhost%s", port))
 	writeEventLog("INFO", "PDF生成エンドポイント: POST /generate-pdf")
 	writeEventLog("INFO", "PDF印刷エンドポイント: POST /print-pdf")
 	writeEventLog("INFO", "封筒印刷エンドポイント: POST /print")
 	writeEventLog("INFO", "ヘルスチェック: GET /health")
+	writeEventLog("INFO", "メトリクス: GET /metrics")
+	writeEventLog("INFO", "API仕様: GET /openapi.json")
 
 	httpServer = &http.Server{
-		Addr: port,
+		Addr:    port,
+		Handler: requestIDMiddleware(newServeMux()),
 	}
 
 	// サーバー起動
@@ -141,58 +66,6 @@ Platform: %s %s
 	}
 }
 
-// GitHubリリース情報
-type GitHubRelease struct {
-	TagName string `json:"tag_name"`
-	Name    string `json:"name"`
-	Assets  []struct {
-		Name               string `json:"name"`
-		BrowserDownloadURL string `json:"browser_download_url"`
-	} `json:"assets"`
-}
-
-// イベントログ書き込み関数（Windowsサービス対応）
-func writeEventLog(level string, message string) {
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	logMessage := fmt.Sprintf("[%s] %s: %s", timestamp, level, message)
-
-	// コンソール出力（デバッグモード時のみ）
-	if elog != nil {
-		switch level {
-		case "ERROR", "FATAL":
-			elog.Error(1, message)
-		case "WARN":
-			elog.Warning(1, message)
-		default:
-			elog.Info(1, message)
-		}
-	} else {
-		fmt.Println(logMessage)
-	}
-
-	// 標準ログ出力
-	log.Printf("%s: %s", level, message)
-
-	// ファイルログも出力
-	writeToLogFile(logMessage)
-}
-
-// ログファイル書き込み関数
-func writeToLogFile(message string) {
-	logFile := "pdf_generator_service.log"
-	file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		log.Printf("ログファイル書き込みエラー: %v", err)
-		return
-	}
-	defer file.Close()
-
-	_, err = file.WriteString(message + "\n")
-	if err != nil {
-		log.Printf("ログファイル書き込みエラー: %v", err)
-	}
-}
-
 // HTTPからデータを取得する関数
 func fetchDataFromAPI(url string) ([]Item, error) {
 	writeEventLog("INFO", fmt.Sprintf("APIからデータを取得開始: %s", url))
@@ -234,306 +107,262 @@ func fetchDataFromAPI(url string) ([]Item, error) {
 	return data, nil
 }
 
-// HTTPハンドラー: PDF生成エンドポイント
-func generatePDFHandler(w http.ResponseWriter, r *http.Request) {
+// acceptPost - CORSヘッダーを設定し、POST以外を拒否（処理を続ける場合はtrue）
+func acceptPost(w http.ResponseWriter, r *http.Request) bool {
 	// CORSヘッダーを設定
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Request-ID")
 
 	// OPTIONSリクエストの処理
 	if r.Method == "OPTIONS" {
 		w.WriteHeader(http.StatusOK)
-		return
+		return false
 	}
 
 	// POSTメソッドのみ許可
 	if r.Method != "POST" {
-		writeEventLog("WARN", fmt.Sprintf("不正なメソッドでのアクセス: %s from %s", r.Method, r.RemoteAddr))
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+		writeRequestLog(r, "WARN", fmt.Sprintf("不正なメソッドでのアクセス: %s from %s", r.Method, r.RemoteAddr))
+		writeMethodNotAllowed(w, r, "POST", "OPTIONS")
+		return false
 	}
+	return true
+}
 
-	writeEventLog("INFO", fmt.Sprintf("PDF生成リクエストを受信 from %s", r.RemoteAddr))
-
-	// リクエストボディを読み取り
+// readRequestBody - リクエストボディを読み取り（失敗時はエラーレスポンスを返してfalse）
+func readRequestBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	defer r.Body.Close()
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		writeEventLog("ERROR", fmt.Sprintf("リクエストボディ読み取りエラー: %v", err))
-		http.Error(w, "Failed to read request body", http.StatusBadRequest)
-		return
-	}
-	defer r.Body.Close()
-
-	// JSONをパース - まずPrintRequestとして試行
-	var printRequest PrintRequest
-	var requestData []Item
-	var shouldPrint bool
-	var printerName string
-
-	// PrintRequest形式での解析を試行
-	if err := json.Unmarshal(body, &printRequest); err == nil && len(printRequest.Items) > 0 {
-		// PrintRequest形式の場合
-		requestData = printRequest.Items
-		shouldPrint = printRequest.Print
-		if printRequest.PrinterName != nil {
-			printerName = *printRequest.PrinterName
-		}
-		writeEventLog("INFO", fmt.Sprintf("印刷リクエスト形式で受信: 印刷=%v, プリンター=%s", shouldPrint, printerName))
-	} else {
-		// 従来のItem配列形式の場合
-		if err := json.Unmarshal(body, &requestData); err != nil {
-			writeEventLog("ERROR", fmt.Sprintf("JSON パースエラー: %v", err))
-			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
-			return
-		}
-		shouldPrint = false // デフォルトは印刷しない
-		writeEventLog("INFO", "従来形式で受信（印刷なし）")
-	}
-
-	writeEventLog("INFO", fmt.Sprintf("受信データ: %d件のアイテム", len(requestData)))
-
-	// PDF生成処理
-	writeEventLog("INFO", "ReportLabスタイルPDF生成を開始")
-	reportlabClient := NewReportLabStylePdfClient(requestData)
-
-	if reportlabClient != nil {
-		writeEventLog("INFO", "ReportLabスタイルPDF生成完了")
-
-		// 印刷処理（リクエストされた場合）
-		var printMessage string
-		if shouldPrint {
-			actualPrinterName := printerName
-			if actualPrinterName == "" {
-				actualPrinterName = "デフォルトプリンター"
-			}
-			writeEventLog("INFO", fmt.Sprintf("PDF印刷を開始: %s", actualPrinterName))
-			pdfPath := "travel_expense_reportlab_style.pdf"
-			if err := PrintPDFWithSumatra(pdfPath, printerName); err != nil {
-				writeEventLog("ERROR", fmt.Sprintf("印刷エラー: %v", err))
-				printMessage = fmt.Sprintf("PDF生成成功、印刷エラー: %v", err)
-			} else {
-				writeEventLog("INFO", fmt.Sprintf("PDF印刷完了: %s", actualPrinterName))
-				printMessage = "PDF generated and printed successfully"
-			}
-		} else {
-			printMessage = "PDF generated successfully"
-		}
-
-		// 成功レスポンス
-		response := map[string]interface{}{
-			"status":  "success",
-			"message": printMessage,
-			"items":   len(requestData),
-			"printed": shouldPrint,
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(response)
-	} else {
-		writeEventLog("ERROR", "ReportLabスタイルPDF生成に失敗")
-
-		// エラーレスポンス
-		response := map[string]interface{}{
-			"status":  "error",
-			"message": "Failed to generate PDF",
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
+		writeRequestLog(r, "ERROR", fmt.Sprintf("リクエストボディ読み取りエラー: %v", err))
+		writeAPIError(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "Failed to read request body", nil)
+		return nil, false
 	}
+	return body, true
 }
 
-// ヘルスチェックエンドポイント
-func healthHandler(w http.ResponseWriter, r *http.Request) {
-	writeEventLog("INFO", fmt.Sprintf("ヘルスチェックアクセス from %s", r.RemoteAddr))
-	w.Header().Set("Content-Type", "application/json")
-	response := map[string]interface{}{
-		"status":    "ok",
-		"service":   "PDF Generator",
-		"version":   Version,
-		"timestamp": time.Now().Format(time.RFC3339),
-	}
-	json.NewEncoder(w).Encode(response)
+// writeInvalidJSON - JSON解析エラーを返す
+func writeInvalidJSON(w http.ResponseWriter, r *http.Request, err error) {
+	writeRequestLog(r, "ERROR", fmt.Sprintf("JSON パースエラー: %v", err))
+	writeAPIError(w, r, http.StatusBadRequest, ErrCodeInvalidJSON, "Invalid JSON format",
+		map[string]string{"error": err.Error()})
 }
 
-// HTTPハンドラー: PDF印刷専用エンドポイント
-func printPDFHandler(w http.ResponseWriter, r *http.Request) {
-	// CORSヘッダーを設定
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	// OPTIONSリクエストの処理
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
+// servePDFRequest - 解析済みリクエストからPDFを生成し、必要なら印刷して応答
+func servePDFRequest(w http.ResponseWriter, r *http.Request, printRequest PrintRequest, shouldPrint bool) {
+	// 入力検証
+	if errs := validatePrintRequest(printRequest); len(errs) > 0 {
+		writeRequestLog(r, "WARN", fmt.Sprintf("入力検証エラー: %v", errs))
+		writeAPIError(w, r, http.StatusUnprocessableEntity, ErrCodeValidationFailed, "Request validation failed", errs)
 		return
 	}
-
-	// POSTメソッドのみ許可
-	if r.Method != "POST" {
-		writeEventLog("WARN", fmt.Sprintf("不正なメソッドでのアクセス: %s from %s", r.Method, r.RemoteAddr))
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	if rejectWhilePaused(w, r, shouldPrint) {
 		return
 	}
 
-	writeEventLog("INFO", fmt.Sprintf("PDF印刷リクエストを受信 from %s", r.RemoteAddr))
+	requestData := printRequest.Items
+	printerName := ""
+	if printRequest.PrinterName != nil {
+		printerName = *printRequest.PrinterName
+	}
+	writeRequestLog(r, "INFO", fmt.Sprintf("受信データ: %d件のアイテム, 印刷=%v, プリンター=%s", len(requestData), shouldPrint, printerName))
 
-	// リクエストボディを読み取り
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		writeEventLog("ERROR", fmt.Sprintf("リクエストボディ読み取りエラー: %v", err))
-		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+	// PDF生成処理
+	// 印刷する場合はリクエストごとの一時ファイルに生成する（一時停止中などで待たされる間に、
+	// 後のリクエストが travel_expense_reportlab_style.pdf を上書きしても別の用紙が印刷されないように）
+	writeRequestLog(r, "INFO", "ReportLabスタイルPDF生成を開始")
+	var pdfPath string
+	if shouldPrint {
+		path, err := renderPDFTempFile(requestData, printRequest.renderOptions())
+		if err != nil {
+			writeRequestLog(r, "ERROR", fmt.Sprintf("ReportLabスタイルPDF生成に失敗: %v", err))
+			writeAPIError(w, r, http.StatusInternalServerError, ErrCodePDFGenerationFailed, "Failed to generate PDF", nil)
+			return
+		}
+		pdfPath = path
+		defer removePrintedFile(r, pdfPath)
+	} else if NewReportLabStylePdfClientWithOptions(requestData, printRequest.renderOptions()) == nil {
+		writeRequestLog(r, "ERROR", "ReportLabスタイルPDF生成に失敗")
+		writeAPIError(w, r, http.StatusInternalServerError, ErrCodePDFGenerationFailed, "Failed to generate PDF", nil)
 		return
 	}
-	defer r.Body.Close()
+	writeRequestLog(r, "INFO", "ReportLabスタイルPDF生成完了")
 
-	// PrintRequest形式でJSONをパース
-	var printRequest PrintRequest
-	if err := json.Unmarshal(body, &printRequest); err != nil {
-		writeEventLog("ERROR", fmt.Sprintf("JSON パースエラー: %v", err))
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
-		return
+	// 印刷処理（リクエストされた場合）
+	printMessage := "PDF generated successfully"
+	if shouldPrint {
+		if !printGeneratedPDF(w, r, pdfPath, printerName, len(requestData)) {
+			return
+		}
+		printMessage = "PDF generated and printed successfully"
 	}
 
-	requestData := printRequest.Items
-	printerName := ""
-	if printRequest.PrinterName != nil {
-		printerName = *printRequest.PrinterName
+	// 成功レスポンス
+	writeJSON(w, http.StatusOK, PDFResponse{
+		Status:  "success",
+		Message: printMessage,
+		Items:   len(requestData),
+		Printed: shouldPrint,
+	})
+}
+
+// printGeneratedPDF - 生成済みPDFを印刷（失敗時はエラーレスポンスを返してfalse）
+func printGeneratedPDF(w http.ResponseWriter, r *http.Request, pdfPath string, printerName string, items int) bool {
+	actualPrinterName := printerName
+	if actualPrinterName == "" {
+		actualPrinterName = "デフォルトプリンター"
 	}
+	writeRequestLog(r, "INFO", fmt.Sprintf("PDF印刷を開始: %s", actualPrinterName))
 
-	writeEventLog("INFO", fmt.Sprintf("印刷リクエスト: %d件のアイテム, プリンター=%s", len(requestData), printerName))
+	err := printJob(r.Context(), pdfPath, printerName)
+	if err == nil {
+		writeRequestLog(r, "INFO", fmt.Sprintf("PDF印刷完了: %s", actualPrinterName))
+		return true
+	}
 
-	// PDF生成処理
-	writeEventLog("INFO", "ReportLabスタイルPDF生成を開始")
-	reportlabClient := NewReportLabStylePdfClient(requestData)
+	details := map[string]interface{}{
+		"pdfGenerated": true,
+		"items":        items,
+		"printer":      printerLabel(printerName),
+	}
+	if writePrintUnavailable(w, r, err, actualPrinterName, details) {
+		return false
+	}
 
-	if reportlabClient != nil {
-		writeEventLog("INFO", "ReportLabスタイルPDF生成完了")
+	writeRequestLog(r, "ERROR", fmt.Sprintf("印刷エラー: %v", err))
+	details["error"] = err.Error()
+	writeAPIError(w, r, http.StatusBadGateway, ErrCodePrintFailed, "PDF was generated but printing failed", details)
+	return false
+}
 
-		// 印刷処理
-		actualPrinterName := printerName
-		if actualPrinterName == "" {
-			actualPrinterName = "デフォルトプリンター"
-		}
-		writeEventLog("INFO", fmt.Sprintf("PDF印刷を開始: %s", actualPrinterName))
-		pdfPath := "travel_expense_reportlab_style.pdf"
-		var printMessage string
-		if err := PrintPDFWithSumatra(pdfPath, printerName); err != nil {
-			writeEventLog("ERROR", fmt.Sprintf("印刷エラー: %v", err))
-			printMessage = fmt.Sprintf("PDF生成成功、印刷エラー: %v", err)
-
-			// エラーレスポンス
-			response := map[string]interface{}{
-				"status":  "partial_success",
-				"message": printMessage,
-				"items":   len(requestData),
-				"printed": false,
+// removePrintedFile - 印刷を終えた一時ファイルを削除
+// 印刷アプリがファイルを開いたままで削除できない場合は、応答を待たせないようバックグラウンドでリトライする
+func removePrintedFile(r *http.Request, path string) {
+	if err := os.Remove(path); err == nil || errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	go func() {
+		const maxRetries = 5
+		for i := 1; i <= maxRetries; i++ {
+			time.Sleep(2 * time.Second)
+			err := os.Remove(path)
+			if err == nil || errors.Is(err, os.ErrNotExist) {
+				return
 			}
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusOK)
-			json.NewEncoder(w).Encode(response)
-		} else {
-			writeEventLog("INFO", fmt.Sprintf("PDF印刷完了: %s", actualPrinterName))
-			printMessage = "PDF generated and printed successfully"
-
-			// 成功レスポンス
-			response := map[string]interface{}{
-				"status":  "success",
-				"message": printMessage,
-				"items":   len(requestData),
-				"printed": true,
+			if i == maxRetries {
+				writeRequestLog(r, "WARN", fmt.Sprintf("一時ファイル削除最終エラー: %v", err))
 			}
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusOK)
-			json.NewEncoder(w).Encode(response)
 		}
-	} else {
-		writeEventLog("ERROR", "ReportLabスタイルPDF生成に失敗")
+	}()
+}
 
-		// エラーレスポンス
-		response := map[string]interface{}{
-			"status":  "error",
-			"message": "Failed to generate PDF",
-			"printed": false,
-		}
+// writePrintUnavailable - キュー満杯・停止処理中・一時停止中の場合は503を返してtrue
+func writePrintUnavailable(w http.ResponseWriter, r *http.Request, err error, printerName string, details map[string]interface{}) bool {
+	switch {
+	case errors.Is(err, errPrintQueueFull):
+		writeRequestLog(r, "WARN", fmt.Sprintf("印刷キューが満杯: %s", printerName))
+		w.Header().Set("Retry-After", "30")
+		writeAPIError(w, r, http.StatusServiceUnavailable, ErrCodePrintQueueFull, "Print queue is full, retry later", details)
+		return true
+	case errors.Is(err, errPrintQueueDraining):
+		writeRequestLog(r, "WARN", fmt.Sprintf("停止処理中のため印刷を受け付けません: %s", printerName))
+		w.Header().Set("Retry-After", "120")
+		writeAPIError(w, r, http.StatusServiceUnavailable, ErrCodeShuttingDown, "Service is shutting down, retry later", details)
+		return true
+	case errors.Is(err, errServicePaused):
+		writeRequestLog(r, "WARN", fmt.Sprintf("サービス一時停止中のため受け付けません: %s", printerName))
+		w.Header().Set("Retry-After", "60")
+		writeAPIError(w, r, http.StatusServiceUnavailable, ErrCodeServicePaused, "Service is paused, retry later", details)
+		return true
+	}
+	return false
+}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
-	}
+// ヘルスチェックエンドポイント
+func healthHandler(w http.ResponseWriter, r *http.Request) {
+	writeRequestLog(r, "INFO", fmt.Sprintf("ヘルスチェックアクセス from %s", r.RemoteAddr))
+	writeJSON(w, http.StatusOK, HealthResponse{
+		Status:    "ok",
+		Service:   "PDF Generator",
+		Version:   Version,
+		Paused:    jobQueue.Paused(),
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
 }
 
 // HTTPハンドラー: 封筒印刷専用エンドポイント（PHPからのマルチパート形式対応）
 func envelopePrintHandler(w http.ResponseWriter, r *http.Request) {
-	// CORSヘッダーを設定
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-	// OPTIONSリクエストの処理
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
+	if !acceptPost(w, r) {
 		return
 	}
 
-	// POSTメソッドのみ許可
-	if r.Method != "POST" {
-		writeEventLog("WARN", fmt.Sprintf("不正なメソッドでのアクセス: %s from %s", r.Method, r.RemoteAddr))
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	writeRequestLog(r, "INFO", fmt.Sprintf("封筒印刷リクエストを受信 from %s", r.RemoteAddr))
+	if rejectWhilePaused(w, r, true) {
 		return
 	}
 
-	writeEventLog("INFO", fmt.Sprintf("封筒印刷リクエストを受信 from %s", r.RemoteAddr))
-
 	// マルチパートフォームデータを解析
 	err := r.ParseMultipartForm(32 << 20) // 32MB制限
 	if err != nil {
-		writeEventLog("ERROR", fmt.Sprintf("マルチパートフォーム解析エラー: %v", err))
-		http.Error(w, "Failed to parse multipart form", http.StatusBadRequest)
+		writeRequestLog(r, "ERROR", fmt.Sprintf("マルチパートフォーム解析エラー: %v", err))
+		writeAPIError(w, r, http.StatusBadRequest, ErrCodeInvalidMultipart, "Failed to parse multipart form",
+			map[string]string{"error": err.Error()})
 		return
 	}
 
 	// プリンター名を取得
 	printerName := r.FormValue("printer")
 	if printerName == "" {
-		writeEventLog("WARN", "プリンター名が指定されていません。デフォルトプリンターを使用します。")
+		writeRequestLog(r, "WARN", "プリンター名が指定されていません。デフォルトプリンターを使用します。")
 		// printerNameを空文字列のままにしてデフォルトプリンターを使用
 	}
 
 	// PDFファイルを取得
 	file, header, err := r.FormFile("document")
 	if err != nil {
-		writeEventLog("ERROR", fmt.Sprintf("PDFファイル取得エラー: %v", err))
-		http.Error(w, "Failed to get PDF file", http.StatusBadRequest)
+		writeRequestLog(r, "ERROR", fmt.Sprintf("PDFファイル取得エラー: %v", err))
+		writeAPIError(w, r, http.StatusUnprocessableEntity, ErrCodeValidationFailed, "Request validation failed",
+			[]FieldError{{Field: "document", Message: "a PDF file is required"}})
 		return
 	}
 	defer file.Close()
 
-	writeEventLog("INFO", fmt.Sprintf("受信ファイル: %s, サイズ: %d bytes, プリンター: %s",
+	writeRequestLog(r, "INFO", fmt.Sprintf("受信ファイル: %s, サイズ: %d bytes, プリンター: %s",
 		header.Filename, header.Size, printerName))
 
+	// PDFファイルかどうかを先頭バイトで確認
+	magic := make([]byte, 5)
+	if n, _ := io.ReadFull(file, magic); n < len(magic) || string(magic) != "%PDF-" {
+		writeRequestLog(r, "WARN", fmt.Sprintf("PDF以外のファイル: %s", header.Filename))
+		writeAPIError(w, r, http.StatusUnprocessableEntity, ErrCodeValidationFailed, "Request validation failed",
+			[]FieldError{{Field: "document", Message: "file is not a PDF document"}})
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		writeRequestLog(r, "ERROR", fmt.Sprintf("PDFファイル読み取りエラー: %v", err))
+		writeAPIError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to read uploaded file", nil)
+		return
+	}
+
 	// 一時ファイルとして保存
 	tempDir := filepath.Join(os.TempDir(), "print_pdf_temp")
 	err = os.MkdirAll(tempDir, 0755)
 	if err != nil {
-		writeEventLog("ERROR", fmt.Sprintf("一時ディレクトリ作成エラー: %v", err))
-		http.Error(w, "Failed to create temp directory", http.StatusInternalServerError)
+		writeRequestLog(r, "ERROR", fmt.Sprintf("一時ディレクトリ作成エラー: %v", err))
+		writeAPIError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to create temp directory", nil)
 		return
 	}
 
 	// ファイル名を生成（タイムスタンプ付き）
 	timestamp := time.Now().Format("20060102_150405")
-	tempFilename := fmt.Sprintf("envelope_%s_%s", timestamp, header.Filename)
+	tempFilename := fmt.Sprintf("envelope_%s_%s", timestamp, filepath.Base(header.Filename))
 	tempFilePath := filepath.Join(tempDir, tempFilename)
 
 	// ファイルを保存
 	outFile, err := os.Create(tempFilePath)
 	if err != nil {
-		writeEventLog("ERROR", fmt.Sprintf("一時ファイル作成エラー: %v", err))
-		http.Error(w, "Failed to create temp file", http.StatusInternalServerError)
+		writeRequestLog(r, "ERROR", fmt.Sprintf("一時ファイル作成エラー: %v", err))
+		writeAPIError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to create temp file", nil)
 		return
 	}
 	defer outFile.Close()
@@ -541,20 +370,21 @@ func envelopePrintHandler(w http.ResponseWriter, r *http.Request) {
 	// ファイル内容をコピー
 	_, err = io.Copy(outFile, file)
 	if err != nil {
-		writeEventLog("ERROR", fmt.Sprintf("ファイルコピーエラー: %v", err))
-		http.Error(w, "Failed to save file", http.StatusInternalServerError)
+		writeRequestLog(r, "ERROR", fmt.Sprintf("ファイルコピーエラー: %v", err))
+		writeAPIError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to save file", nil)
 		return
 	}
+	outFile.Close()
 
-	writeEventLog("INFO", fmt.Sprintf("一時ファイル保存完了: %s", tempFilePath))
+	writeRequestLog(r, "INFO", fmt.Sprintf("一時ファイル保存完了: %s", tempFilePath))
 
 	// PDF印刷を実行
 	actualPrinterName := printerName
 	if actualPrinterName == "" {
 		actualPrinterName = "デフォルトプリンター"
 	}
-	writeEventLog("INFO", fmt.Sprintf("封筒印刷を開始: %s -> %s", tempFilePath, actualPrinterName))
-	err = PrintPDFWithSumatra(tempFilePath, printerName)
+	writeRequestLog(r, "INFO", fmt.Sprintf("封筒印刷を開始: %s -> %s", tempFilePath, actualPrinterName))
+	err = printJob(r.Context(), tempFilePath, printerName)
 
 	// 一時ファイルを削除（印刷後、少し待ってから）
 	defer func() {
@@ -566,338 +396,51 @@ func envelopePrintHandler(w http.ResponseWriter, r *http.Request) {
 		for i := 0; i < maxRetries; i++ {
 			if removeErr := os.Remove(tempFilePath); removeErr != nil {
 				if i < maxRetries-1 {
-					writeEventLog("INFO", fmt.Sprintf("一時ファイル削除リトライ %d/%d: %v", i+1, maxRetries, removeErr))
+					writeRequestLog(r, "INFO", fmt.Sprintf("一時ファイル削除リトライ %d/%d: %v", i+1, maxRetries, removeErr))
 					time.Sleep(2 * time.Second)
 					continue
 				} else {
-					writeEventLog("WARN", fmt.Sprintf("一時ファイル削除最終エラー: %v", removeErr))
+					writeRequestLog(r, "WARN", fmt.Sprintf("一時ファイル削除最終エラー: %v", removeErr))
 				}
 			} else {
-				writeEventLog("INFO", fmt.Sprintf("一時ファイル削除完了: %s", tempFilePath))
+				writeRequestLog(r, "INFO", fmt.Sprintf("一時ファイル削除完了: %s", tempFilePath))
 				break
 			}
 		}
 	}()
 
 	if err != nil {
-		writeEventLog("ERROR", fmt.Sprintf("封筒印刷エラー: %v", err))
-
-		// エラーレスポンス
-		response := map[string]interface{}{
-			"status":   "error",
-			"message":  fmt.Sprintf("印刷エラー: %v", err),
+		details := map[string]interface{}{
 			"filename": header.Filename,
 			"printer":  printerName,
 			"printed":  false,
 		}
+		if writePrintUnavailable(w, r, err, actualPrinterName, details) {
+			return
+		}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
+		writeRequestLog(r, "ERROR", fmt.Sprintf("封筒印刷エラー: %v", err))
+		details["error"] = err.Error()
+		writeAPIError(w, r, http.StatusBadGateway, ErrCodePrintFailed, "Envelope printing failed", details)
 		return
 	}
 
-	writeEventLog("INFO", fmt.Sprintf("封筒印刷完了: %s", actualPrinterName))
+	writeRequestLog(r, "INFO", fmt.Sprintf("封筒印刷完了: %s", actualPrinterName))
 
 	// 成功レスポンス
-	response := map[string]interface{}{
-		"status":   "success",
-		"message":  "封筒印刷が正常に完了しました",
-		"filename": header.Filename,
-		"printer":  printerName,
-		"printed":  true,
-		"fileSize": header.Size,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
-}
-
-// 自動アップデート機能
-func checkForUpdates() {
-	// dev環境では自動アップデートを実行しない
-	if Version == "dev" {
-		writeEventLog("INFO", "開発環境のため自動アップデートをスキップします")
-		return
-	}
-
-	writeEventLog("INFO", fmt.Sprintf("現在のバージョン: %s", Version))
-	writeEventLog("INFO", "GitHubリリースの最新バージョンをチェック中...")
-
-	// GitHub APIから最新リリース情報を取得
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Get("https://api.github.com/repos/ohishi-yhonda-org/print_pdf/releases/latest")
-	if err != nil {
-		writeEventLog("WARN", fmt.Sprintf("アップデートチェックに失敗: %v", err))
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		writeEventLog("WARN", fmt.Sprintf("GitHub API エラー: %d", resp.StatusCode))
-		return
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		writeEventLog("WARN", fmt.Sprintf("レスポンス読み取りエラー: %v", err))
-		return
-	}
-
-	var release GitHubRelease
-	if err := json.Unmarshal(body, &release); err != nil {
-		writeEventLog("WARN", fmt.Sprintf("JSON パースエラー: %v", err))
-		return
-	}
-
-	latestVersion := release.TagName
-	writeEventLog("INFO", fmt.Sprintf("最新バージョン: %s", latestVersion))
-
-	// バージョン比較
-	if Version == "dev" || Version != latestVersion {
-		writeEventLog("INFO", fmt.Sprintf("新しいバージョンが利用可能: %s -> %s", Version, latestVersion))
-
-		// 自動アップデートを実行
-		if performUpdate(release) {
-			writeEventLog("INFO", "アップデート完了。アプリケーションを再起動します...")
-			
-			// サービスとして実行中の場合は、サービスマネージャーに正常終了を通知
-			isWindowsService, err := svc.IsWindowsService()
-			if err == nil && isWindowsService {
-				writeEventLog("INFO", "サービスとして実行中のため、サービスマネージャーに終了を通知します")
-				// サービスの正常終了（サービスマネージャーが自動的に再起動する）
-				os.Exit(0)
-			} else {
-				writeEventLog("INFO", "コンソールアプリケーションとして実行中のため、バッチファイルで再起動します")
-				os.Exit(0)
-			}
-		}
-	} else {
-		writeEventLog("INFO", "最新バージョンを使用中です")
-	}
-}
-
-// アップデートを実行
-func performUpdate(release GitHubRelease) bool {
-	// Windows用のzipファイルを探す
-	var downloadURL string
-	for _, asset := range release.Assets {
-		if strings.Contains(asset.Name, ".zip") && strings.Contains(asset.Name, release.TagName) {
-			downloadURL = asset.BrowserDownloadURL
-			break
-		}
-	}
-
-	if downloadURL == "" {
-		writeEventLog("ERROR", "アップデート用のzipファイルが見つかりません")
-		return false
-	}
-
-	writeEventLog("INFO", fmt.Sprintf("アップデートファイルをダウンロード中: %s", downloadURL))
-
-	// ダウンロード
-	client := &http.Client{Timeout: 300 * time.Second} // 5分タイムアウト
-	resp, err := client.Get(downloadURL)
-	if err != nil {
-		writeEventLog("ERROR", fmt.Sprintf("ダウンロードエラー: %v", err))
-		return false
-	}
-	defer resp.Body.Close()
-
-	// 一時ファイルに保存
-	tempFile := "update_temp.zip"
-	file, err := os.Create(tempFile)
-	if err != nil {
-		writeEventLog("ERROR", fmt.Sprintf("一時ファイル作成エラー: %v", err))
-		return false
-	}
-	defer file.Close()
-	defer os.Remove(tempFile)
-
-	_, err = io.Copy(file, resp.Body)
-	if err != nil {
-		writeEventLog("ERROR", fmt.Sprintf("ファイル書き込みエラー: %v", err))
-		return false
-	}
-	file.Close()
-
-	writeEventLog("INFO", "ダウンロード完了。アップデートを適用中...")
-
-	// zipファイルを解凍
-	if err := extractUpdate(tempFile); err != nil {
-		writeEventLog("ERROR", fmt.Sprintf("アップデート適用エラー: %v", err))
-		return false
-	}
-
-	writeEventLog("INFO", "アップデート適用完了")
-	return true
-}
-
-// zipファイルを解凍してアップデートを適用
-func extractUpdate(zipPath string) error {
-	// 現在の実行ファイル名を取得
-	currentExe, err := os.Executable()
-	if err != nil {
-		return fmt.Errorf("実行ファイルパス取得エラー: %v", err)
-	}
-
-	// zipファイルを開く
-	reader, err := zip.OpenReader(zipPath)
-	if err != nil {
-		return fmt.Errorf("zip読み込みエラー: %v", err)
-	}
-	defer reader.Close()
-
-	// バックアップ作成
-	backupPath := currentExe + ".backup"
-	if err := copyFile(currentExe, backupPath); err != nil {
-		return fmt.Errorf("バックアップ作成エラー: %v", err)
-	}
-
-	// 解凍処理
-	for _, file := range reader.File {
-		if file.Name == "print_pdf.exe" {
-			// 実行ファイルを更新
-			if err := extractFile(file, currentExe+".new"); err != nil {
-				return fmt.Errorf("新しい実行ファイル展開エラー: %v", err)
-			}
-
-			// バッチファイルでファイル置換を実行（Windowsでは実行中のファイルを置換できないため）
-			// サービスとして実行中かどうかを判定して適切な再起動方法を選択
-			var startCommand string
-			isWindowsService, err := svc.IsWindowsService()
-			if err == nil && isWindowsService {
-				// サービスとして実行中の場合はサービス再起動
-				startCommand = `sc start "PDF Generator API Service"`
-			} else {
-				// コンソールアプリケーションとして実行中の場合は直接起動
-				startCommand = fmt.Sprintf(`start "" "%s"`, currentExe)
-			}
-
-			batchContent := fmt.Sprintf(`@echo off
-echo Waiting for service to stop...
-timeout /t 3 /nobreak >nul
-echo Moving new executable...
-move /Y "%s" "%s"
-if errorlevel 1 (
-    echo Failed to replace executable
-    exit /b 1
-)
-echo Moving backup...
-move /Y "%s" "%s"
-echo Starting service...
-%s
-if errorlevel 1 (
-    echo Failed to start service, retrying...
-    timeout /t 2 /nobreak >nul
-    %s
-)
-echo Update completed
-timeout /t 2 /nobreak >nul
-del "%%~f0"
-`, currentExe+".new", currentExe, backupPath, currentExe+".old", startCommand, startCommand)
-
-			batchFile := "update_replace.bat"
-			if err := os.WriteFile(batchFile, []byte(batchContent), 0755); err != nil {
-				return fmt.Errorf("バッチファイル作成エラー: %v", err)
-			}
-
-			// バッチファイルを実行（非同期）
-			cmd := exec.Command("cmd", "/c", batchFile)
-			if err := cmd.Start(); err != nil {
-				return fmt.Errorf("アップデートスクリプト実行エラー: %v", err)
-			}
-
-			return nil
-		}
-	}
-
-	return fmt.Errorf("アップデート用実行ファイルが見つかりません")
-}
-
-// ファイルをコピー
-func copyFile(src, dst string) error {
-	sourceFile, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer sourceFile.Close()
-
-	destFile, err := os.Create(dst)
-	if err != nil {
-		return err
-	}
-	defer destFile.Close()
-
-	_, err = io.Copy(destFile, sourceFile)
-	return err
-}
-
-// zipファイルから個別ファイルを展開
-func extractFile(file *zip.File, destPath string) error {
-	reader, err := file.Open()
-	if err != nil {
-		return err
-	}
-	defer reader.Close()
-
-	// ディレクトリを作成
-	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
-		return err
-	}
-
-	// ファイルを作成
-	writer, err := os.Create(destPath)
-	if err != nil {
-		return err
-	}
-	defer writer.Close()
-
-	_, err = io.Copy(writer, reader)
-	return err
+	writeJSON(w, http.StatusOK, EnvelopePrintResponse{
+		Status:   "success",
+		Message:  "Envelope printed successfully",
+		Filename: header.Filename,
+		Printer:  printerName,
+		Printed:  true,
+		FileSize: header.Size,
+	})
 }
 
 func main() {
-	// Windowsサービスとして実行されているかチェック
-	isWindowsService, err := svc.IsWindowsService()
-	if err != nil {
-		log.Fatalf("サービス状態確認エラー: %v", err)
-	}
-
-	if isWindowsService {
-		// Windowsサービスとして実行
-		runWindowsService()
-	} else {
-		// コンソールアプリケーションとして実行
-		runConsoleApp()
-	}
-}
-
-// Windowsサービスとして実行
-func runWindowsService() {
-	var err error
-
-	// イベントログを開く（失敗しても続行）
-	elog, err = eventlog.Open("PDF Generator API Service")
-	if err != nil {
-		// イベントログが開けない場合はファイルログのみ使用
-		elog = nil
-		log.Printf("イベントログを開けませんでした: %v", err)
-	}
-	defer func() {
-		if elog != nil {
-			elog.Close()
-		}
-	}()
-
-	writeEventLog("INFO", "PDF Generator API Service をWindowsサービスとして開始")
-
-	err = svc.Run("PDF Generator API Service", &service{})
-	if err != nil {
-		writeEventLog("FATAL", fmt.Sprintf("サービス実行エラー: %v", err))
-		log.Fatalf("サービス実行エラー: %v", err)
-	}
+	// サブコマンドを解析して実行（引数なしの場合は serve）
+	os.Exit(runCLI(os.Args[1:], os.Stdout, os.Stderr))
 }
 
 // コンソールアプリケーションとして実行
@@ -908,20 +451,23 @@ func runConsoleApp() {
 	// シグナルハンドリング
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	signal.Notify(sigChan, reloadSignals...)
 
 	// HTTPサーバーを別goroutineで起動
 	go startHTTPServer()
 
-	// 終了シグナルを待機
-	<-sigChan
+	// 終了シグナルを待機（設定再読み込みのシグナルでは終了しない）
+	for sig := range sigChan {
+		if sig == os.Interrupt || sig == syscall.SIGTERM {
+			break
+		}
+		reloadConfigAndLog()
+	}
 	writeEventLog("INFO", "終了シグナルを受信。サーバーを停止中...")
 
-	if httpServer != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-		if err := httpServer.Shutdown(ctx); err != nil {
-			writeEventLog("ERROR", fmt.Sprintf("サーバー停止エラー: %v", err))
-		}
+	if err := drainAndShutdown(); err != nil {
+		writeEventLog("ERROR", fmt.Sprintf("サーバー停止エラー: %v", err))
+		return
 	}
 
 	writeEventLog("INFO", "サーバーが正常に停止しました")
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Prometheus テキスト形式のメトリクス（外部ライブラリを使わない最小実装）
+
+// metricLabels - ラベル名と値の組
+type metricLabels []string
+
+// key - ラベル値をマップのキーに変換
+func (l metricLabels) key() string {
+	return strings.Join(l, "\xff")
+}
+
+// counterVec - ラベル付きカウンター
+type counterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64
+	keys   map[string]metricLabels
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	return &counterVec{
+		name:   name,
+		help:   help,
+		labels: labels,
+		values: make(map[string]float64),
+		keys:   make(map[string]metricLabels),
+	}
+}
+
+// Add - 指定ラベルのカウンターを加算
+func (c *counterVec) Add(v float64, labelValues ...string) {
+	l := metricLabels(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[l.key()] += v
+	c.keys[l.key()] = l
+}
+
+// Inc - 指定ラベルのカウンターを1加算
+func (c *counterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Value - 現在値を取得（テスト用）
+func (c *counterVec) Value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[metricLabels(labelValues).key()]
+}
+
+func (c *counterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	for _, k := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, c.keys[k]), formatFloat(c.values[k]))
+	}
+}
+
+// gauge - 単一値のゲージ
+type gauge struct {
+	name string
+	help string
+
+	mu    sync.Mutex
+	value float64
+}
+
+func newGauge(name, help string) *gauge {
+	return &gauge{name: name, help: help}
+}
+
+// Add - ゲージを加減算
+func (g *gauge) Add(v float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.value += v
+}
+
+// Set - ゲージに値を設定
+func (g *gauge) Set(v float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.value = v
+}
+
+// Value - 現在値を取得
+func (g *gauge) Value() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.value
+}
+
+func (g *gauge) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", g.name, g.help, g.name, g.name, formatFloat(g.Value()))
+}
+
+// histogramVec - ラベル付きヒストグラム
+type histogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labels metricLabels
+	counts []uint64 // 各バケット（上限以下）の累積ではない件数
+	count  uint64
+	sum    float64
+}
+
+func newHistogramVec(name, help string, buckets []float64, labels ...string) *histogramVec {
+	return &histogramVec{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*histogramSeries),
+	}
+}
+
+// Observe - 観測値を記録
+func (h *histogramVec) Observe(v float64, labelValues ...string) {
+	l := metricLabels(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[l.key()]
+	if !ok {
+		s = &histogramSeries{labels: l, counts: make([]uint64, len(h.buckets))}
+		h.series[l.key()] = s
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+			break
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+// Count - 観測件数を取得（テスト用）
+func (h *histogramVec) Count(labelValues ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s, ok := h.series[metricLabels(labelValues).key()]; ok {
+		return s.count
+	}
+	return 0
+}
+
+func (h *histogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	keys := make([]string, 0, len(h.series))
+	for k := range h.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s := h.series[k]
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			names := append(append([]string{}, h.labels...), "le")
+			values := append(append(metricLabels{}, s.labels...), formatFloat(upper))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(names, values), cumulative)
+		}
+		names := append(append([]string{}, h.labels...), "le")
+		values := append(append(metricLabels{}, s.labels...), "+Inf")
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(names, values), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, s.labels), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, s.labels), s.count)
+	}
+}
+
+// formatLabels - ラベルを {name="value",...} 形式に整形
+func formatLabels(names []string, values metricLabels) string {
+	if len(names) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(names))
+	for i, name := range names {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, name, escapeLabelValue(value)))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// escapeLabelValue - ラベル値のエスケープ（\ " 改行）
+func escapeLabelValue(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `"`, `\"`)
+	return strings.ReplaceAll(v, "\n", `\n`)
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// アプリケーションのメトリクス
+var (
+	httpRequestsTotal = newCounterVec("print_pdf_http_requests_total",
+		"Number of HTTP requests by endpoint and status code.", "endpoint", "status")
+	pdfRenderDuration = newHistogramVec("print_pdf_pdf_render_duration_seconds",
+		"Time spent rendering PDF documents.", []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10})
+	pdfPagesRendered = newCounterVec("print_pdf_pdf_pages_rendered_total",
+		"Number of PDF pages rendered.")
+	pdfBytesProduced = newCounterVec("print_pdf_pdf_bytes_total",
+		"Number of PDF bytes produced.")
+	pdfRenderFailures = newCounterVec("print_pdf_pdf_render_failures_total",
+		"Number of failed PDF renders.")
+	printDuration = newHistogramVec("print_pdf_print_duration_seconds",
+		"Time spent printing a document by printer.", []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120}, "printer")
+	printJobsTotal = newCounterVec("print_pdf_print_jobs_total",
+		"Number of print jobs by printer and result.", "printer", "result")
+	printFailuresTotal = newCounterVec("print_pdf_print_failures_total",
+		"Number of failed print jobs by printer.", "printer")
+	printQueueDepth = newGauge("print_pdf_print_queue_depth",
+		"Number of print jobs waiting or in progress.")
+	servicePaused = newGauge("print_pdf_service_paused",
+		"1 while the Windows service is paused, 0 otherwise.")
+)
+
+// writeMetrics - 全メトリクスをPrometheusテキスト形式で出力
+func writeMetrics(w io.Writer) {
+	fmt.Fprintf(w, "# HELP print_pdf_build_info Build information.\n# TYPE print_pdf_build_info gauge\n")
+	fmt.Fprintf(w, "print_pdf_build_info%s 1\n", formatLabels(
+		[]string{"version", "goversion", "goos", "goarch"},
+		metricLabels{Version, runtime.Version(), runtime.GOOS, runtime.GOARCH}))
+
+	httpRequestsTotal.write(w)
+	pdfRenderDuration.write(w)
+	pdfPagesRendered.write(w)
+	pdfBytesProduced.write(w)
+	pdfRenderFailures.write(w)
+	printDuration.write(w)
+	printJobsTotal.write(w)
+	printFailuresTotal.write(w)
+	printQueueDepth.write(w)
+	servicePaused.write(w)
+}
+
+// metricsHandler - GET /metrics
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeMethodNotAllowed(w, r, "GET")
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	writeMetrics(w)
+}
+
+// statusRecorder - レスポンスのステータスコードを記録するResponseWriter
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// instrumentHandler - エンドポイントごとのリクエスト数を計測
+func instrumentHandler(endpoint string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+		httpRequestsTotal.Inc(endpoint, strconv.Itoa(rec.status))
+	}
+}
+
+// observeRender - PDF生成結果をメトリクスに記録
+func observeRender(elapsed time.Duration, pages int, bytes int64, err error) {
+	if err != nil {
+		pdfRenderFailures.Inc()
+		return
+	}
+	pdfRenderDuration.Observe(elapsed.Seconds())
+	pdfPagesRendered.Add(float64(pages))
+	pdfBytesProduced.Add(float64(bytes))
+}
+
+// printerLabel - メトリクス用のプリンター名（未指定時は default）
+func printerLabel(printerName string) string {
+	if printerName == "" {
+		return "default"
+	}
+	return printerName
+}
+
+// maxPrinterLabels - printer ラベルに使うプリンター名の上限（リクエストのプリンター名で系列が増え続けないようにする）
+const maxPrinterLabels = 20
+
+// otherPrinterLabel - 上限を超えたプリンターの printer ラベル
+const otherPrinterLabel = "other"
+
+// labelSet - 値の種類に上限のあるラベル（最初の max 種類はそのまま、それ以降は other にまとめる）
+type labelSet struct {
+	max   int
+	other string
+
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func newLabelSet(max int, other string) *labelSet {
+	return &labelSet{max: max, other: other, seen: make(map[string]bool)}
+}
+
+// label - メトリクスに使うラベル値
+func (s *labelSet) label(value string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seen[value] {
+		return value
+	}
+	if len(s.seen) >= s.max {
+		return s.other
+	}
+	s.seen[value] = true
+	return value
+}
+
+// printerLabels - 印刷のメトリクスに使ったプリンター名
+var printerLabels = newLabelSet(maxPrinterLabels, otherPrinterLabel)
+
+// printerMetricLabel - 印刷のメトリクスの printer ラベル（未指定時は default、上限を超えたプリンターは other）
+func printerMetricLabel(printerName string) string {
+	return printerLabels.label(printerLabel(printerName))
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCounterVecWrite(t *testing.T) {
+	c := newCounterVec("test_requests_total", "Test counter.", "endpoint", "status")
+	c.Inc("/a", "200")
+	c.Inc("/a", "200")
+	c.Add(3, "/b", "500")
+
+	var buf bytes.Buffer
+	c.write(&buf)
+	out := buf.String()
+
+	expected := []string{
+		"# TYPE test_requests_total counter",
+		`test_requests_total{endpoint="/a",status="200"} 2`,
+		`test_requests_total{endpoint="/b",status="500"} 3`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(out, e) {
+			t.Errorf("output missing %q:\n%s", e, out)
+		}
+	}
+}
+
+func TestHistogramVecWrite(t *testing.T) {
+	h := newHistogramVec("test_duration_seconds", "Test histogram.", []float64{0.1, 1}, "printer")
+	h.Observe(0.05, "p1")
+	h.Observe(0.5, "p1")
+	h.Observe(5, "p1")
+
+	var buf bytes.Buffer
+	h.write(&buf)
+	out := buf.String()
+
+	expected := []string{
+		"# TYPE test_duration_seconds histogram",
+		`test_duration_seconds_bucket{printer="p1",le="0.1"} 1`,
+		`test_duration_seconds_bucket{printer="p1",le="1"} 2`,
+		`test_duration_seconds_bucket{printer="p1",le="+Inf"} 3`,
+		`test_duration_seconds_sum{printer="p1"} 5.55`,
+		`test_duration_seconds_count{printer="p1"} 3`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(out, e) {
+			t.Errorf("output missing %q:\n%s", e, out)
+		}
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"plain", "plain"},
+		{`Canon "LBP"`, `Canon \"LBP\"`},
+		{`\\server\printer`, `\\\\server\\printer`},
+		{"a\nb", `a\nb`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeLabelValue(tt.input); got != tt.expected {
+			t.Errorf("escapeLabelValue(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestInstrumentHandler(t *testing.T) {
+	before := httpRequestsTotal.Value("/test-instrument", "418")
+
+	handler := instrumentHandler("/test-instrument", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/test-instrument", nil))
+
+	if got := httpRequestsTotal.Value("/test-instrument", "418"); got != before+1 {
+		t.Errorf("request count = %v, expected %v", got, before+1)
+	}
+}
+
+func TestObserveRender(t *testing.T) {
+	beforeCount := pdfRenderDuration.Count()
+	beforePages := pdfPagesRendered.Value()
+	beforeFailures := pdfRenderFailures.Value()
+
+	observeRender(200*time.Millisecond, 3, 4096, nil)
+	observeRender(time.Second, 0, 0, errors.New("render failed"))
+
+	if got := pdfRenderDuration.Count(); got != beforeCount+1 {
+		t.Errorf("render count = %d, expected %d", got, beforeCount+1)
+	}
+	if got := pdfPagesRendered.Value(); got != beforePages+3 {
+		t.Errorf("pages = %v, expected %v", got, beforePages+3)
+	}
+	if got := pdfRenderFailures.Value(); got != beforeFailures+1 {
+		t.Errorf("failures = %v, expected %v", got, beforeFailures+1)
+	}
+}
+
+func TestLabelSet(t *testing.T) {
+	s := newLabelSet(2, "other")
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"p1", "p1"},
+		{"p2", "p2"},
+		{"p3", "other"}, // 上限を超えた
+		{"p1", "p1"},    // 記録済みはそのまま
+		{"p4", "other"},
+	}
+	for _, tt := range tests {
+		if got := s.label(tt.value); got != tt.want {
+			t.Errorf("label(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestPrinterMetricLabel(t *testing.T) {
+	original := printerLabels
+	t.Cleanup(func() { printerLabels = original })
+	printerLabels = newLabelSet(maxPrinterLabels, otherPrinterLabel)
+
+	if got := printerMetricLabel(""); got != "default" {
+		t.Errorf("printerMetricLabel(\"\") = %q, want default", got)
+	}
+	for i := 0; i < maxPrinterLabels*2; i++ {
+		printerMetricLabel(fmt.Sprintf("printer-%d", i))
+	}
+	if got := printerMetricLabel("printer-1000"); got != otherPrinterLabel {
+		t.Errorf("label over limit = %q, want %q", got, otherPrinterLabel)
+	}
+	if got := printerMetricLabel(""); got != "default" {
+		t.Errorf("recorded label = %q, want default", got)
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	printFailuresTotal.Inc("Canon LBP221")
+
+	rec := httptest.NewRecorder()
+	metricsHandler(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, expected 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	body := rec.Body.String()
+	expected := []string{
+		`print_pdf_build_info{version="` + Version + `"`,
+		`print_pdf_print_failures_total{printer="Canon LBP221"}`,
+		"# TYPE print_pdf_print_queue_depth gauge",
+		"# TYPE print_pdf_pdf_render_duration_seconds histogram",
+	}
+	for _, e := range expected {
+		if !strings.Contains(body, e) {
+			t.Errorf("metrics output missing %q", e)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	metricsHandler(rec, httptest.NewRequest("POST", "/metrics", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, expected 405", rec.Code)
+	}
+}
@@ -1,9 +1,5 @@
 package main
 
-import (
-	"time"
-)
-
 // Ryohi represents the expense data structure
 type Ryohi struct {
 	Date           *string   `json:"date"`
@@ -27,24 +23,83 @@ type Ryohi struct {
 
 // Item represents the main item data structure
 type Item struct {
-	Car         string   `json:"car"`
-	Name        string   `json:"name"`
-	Purpose     *string  `json:"purpose"`
-	StartDate   *string  `json:"startDate"`
-	EndDate     *string  `json:"endDate"`
-	Price       int      `json:"price"`
-	Tax         *float64 `json:"tax"`
-	Description *string  `json:"description"`
-	Ryohi       []Ryohi  `json:"ryohi"`
-	Office      *string  `json:"office"`
-	PayDay      *string  `json:"payDay"`
+	ID          *string    `json:"id"` // 元の記録のID（QRコード・バーコードの内容に使える）
+	Car         string     `json:"car"`
+	Name        string     `json:"name"`
+	Purpose     *string    `json:"purpose"`
+	StartDate   *string    `json:"startDate"`
+	EndDate     *string    `json:"endDate"`
+	Price       int        `json:"price"`
+	Tax         *float64   `json:"tax"`     // 消費税額（円）
+	TaxRate     *float64   `json:"taxRate"` // 消費税率（パーセント。tax がない場合は price から計算）
+	Description *string    `json:"description"`
+	Ryohi       []Ryohi    `json:"ryohi"`
+	Office      *string    `json:"office"`
+	PayDay      *string    `json:"payDay"`
+	Approvals   []Approval `json:"approvals"`
 }
 
 // PrintRequest represents the print request data structure
 type PrintRequest struct {
-	Items       []Item  `json:"items"`
-	Print       bool    `json:"print,omitempty"`       // 印刷するかどうか
-	PrinterName *string `json:"printerName,omitempty"` // 指定プリンター名（省略時はデフォルト）
+	Items       []Item     `json:"items" openapi:"required"`
+	Print       bool       `json:"print,omitempty"`       // 印刷するかどうか
+	PrinterName *string    `json:"printerName,omitempty"` // 指定プリンター名（省略時はデフォルト）
+	Watermark   *Watermark `json:"watermark,omitempty"`   // 全ページに重ねる透かし（下書き・再発行・控え）
+}
+
+// PDFResponse represents the success response of /generate-pdf and /print-pdf
+type PDFResponse struct {
+	Status  string `json:"status"`  // 常に "success"
+	Message string `json:"message"` // 処理結果メッセージ
+	Items   int    `json:"items"`   // 処理したアイテム数
+	Printed bool   `json:"printed"` // 印刷したかどうか
+}
+
+// EnvelopePrintResponse represents the success response of /print
+type EnvelopePrintResponse struct {
+	Status   string `json:"status"`   // 常に "success"
+	Message  string `json:"message"`  // 処理結果メッセージ
+	Filename string `json:"filename"` // 受信したファイル名
+	Printer  string `json:"printer"`  // 印刷先プリンター（空はデフォルトプリンター）
+	Printed  bool   `json:"printed"`  // 印刷したかどうか
+	FileSize int64  `json:"fileSize"` // 受信したファイルサイズ（バイト）
+}
+
+// HealthResponse represents the response of /health
+type HealthResponse struct {
+	Status    string `json:"status"`    // 常に "ok"
+	Service   string `json:"service"`   // サービス名
+	Version   string `json:"version"`   // ビルドバージョン
+	Paused    bool   `json:"paused"`    // サービス一時停止中（印刷の受付を止めている）
+	Timestamp string `json:"timestamp"` // RFC 3339 形式の現在時刻
+}
+
+// UpdateStatusResponse represents the response of GET /admin/update
+type UpdateStatusResponse struct {
+	CurrentVersion    string  `json:"currentVersion"`          // 実行中のバージョン
+	Channel           string  `json:"channel"`                 // stable, beta, pinned
+	PinnedVersion     string  `json:"pinnedVersion,omitempty"` // channel が pinned の場合
+	Source            string  `json:"source"`                  // アップデート元の説明
+	LatestVersion     *string `json:"latestVersion"`           // 最後のチェックで見つかった適用可能なバージョン
+	UpdateAvailable   bool    `json:"updateAvailable"`
+	LastChecked       *string `json:"lastChecked"`         // RFC 3339 形式（未チェックの場合は null）
+	LastError         string  `json:"lastError,omitempty"` // 最後のチェック・適用のエラー
+	AutoUpdate        bool    `json:"autoUpdate"`          // 自動アップデートが有効か
+	InProgress        bool    `json:"inProgress"`          // 適用・ロールバック処理中か
+	RollbackAvailable bool    `json:"rollbackAvailable"`   // 前のバージョンが残っているか
+}
+
+// UpdateApplyRequest represents the body of POST /admin/update/apply
+type UpdateApplyRequest struct {
+	Version string `json:"version,omitempty"` // 省略時はチャンネルに従った最新版
+}
+
+// UpdateActionResponse represents the response of POST /admin/update/apply and /admin/update/rollback
+type UpdateActionResponse struct {
+	Status  string `json:"status"`            // 常に "accepted"
+	Action  string `json:"action"`            // apply, rollback
+	Version string `json:"version,omitempty"` // 適用するバージョン
+	Message string `json:"message"`
 }
 
 // Helper functions
@@ -60,64 +115,34 @@ func Float64Ptr(f float64) *float64 {
 	return &f
 }
 
-// FormatPrice formats price with comma separator
+// FormatPrice formats price with comma separator (0 is printed as blank)
 func FormatPrice(price int) string {
 	if price == 0 {
 		return ""
 	}
-
-	str := ""
-	num := price
-	if num < 0 {
-		str = "-"
-		num = -num
-	}
-
-	digits := []rune{}
-	for num > 0 {
-		digits = append([]rune{'0' + rune(num%10)}, digits...)
-		num /= 10
-	}
-
-	if len(digits) == 0 {
-		return "0"
-	}
-
-	result := ""
-	for i, digit := range digits {
-		if i > 0 && (len(digits)-i)%3 == 0 {
-			result += ","
-		}
-		result += string(digit)
-	}
-
-	return str + result
+	return Money(price).String()
 }
 
-// ParseDate parses date string and returns formatted date
+// ParseDate parses date string and returns it in the 出発・帰着日 style (DateStyleSlots)
 func ParseDate(dateStr string) (string, error) {
-	if dateStr == "" {
-		return "", nil
-	}
-
-	t, err := time.Parse("2006-01-02", dateStr)
-	if err != nil {
-		return "", err
-	}
-
-	return t.Format("01　02"), nil
+	return parseAndFormatDate(dateStr, DateStyleSlots)
 }
 
-// ParsePayDay parses pay day and returns formatted date
+// ParsePayDay parses pay day and returns it in the 精算日 style (DateStyleYMD)
 func ParsePayDay(dateStr string) (string, error) {
+	return parseAndFormatDate(dateStr, DateStyleYMD)
+}
+
+// parseAndFormatDate - 日付を解析して書式で整形（空の場合は空）
+func parseAndFormatDate(dateStr, style string) (string, error) {
 	if dateStr == "" {
 		return "", nil
 	}
 
-	t, err := time.Parse("2006-01-02", dateStr)
+	t, err := parseItemDate(dateStr)
 	if err != nil {
 		return "", err
 	}
 
-	return t.Format("2006  01　02"), nil
+	return formatDate(t, style), nil
 }
@@ -52,6 +52,30 @@ func TestFormatPrice(t *testing.T) {
 	}
 }
 
+func TestParseDateAndPayDay(t *testing.T) {
+	tests := []struct {
+		name     string
+		parse    func(string) (string, error)
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{name: "出発日", parse: ParseDate, input: "2025-01-06", expected: "01　 06"},
+		{name: "精算日", parse: ParsePayDay, input: "2025-01-06", expected: "2025年 01月 06日"},
+		{name: "空", parse: ParseDate, input: "", expected: ""},
+		{name: "不正な日付", parse: ParsePayDay, input: "2025-13-01", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.parse(tt.input)
+			if (err != nil) != tt.wantErr || result != tt.expected {
+				t.Errorf("parse(%q) = %q, %v; expected %q", tt.input, result, err, tt.expected)
+			}
+		})
+	}
+}
+
 func TestStringPtr(t *testing.T) {
 	s := "test"
 	ptr := StringPtr(s)
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 金額・数量・税率
+// 浮動小数点の誤差が出ないよう、金額は円単位、数量と税率は固定小数点の整数で計算する
+
+// Money - 金額（円）
+type Money int64
+
+// String - 3桁区切り（0は "0"）
+func (m Money) String() string {
+	sign := ""
+	n := int64(m)
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+	digits := strconv.FormatInt(n, 10)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	return sign + b.String()
+}
+
+// Quantity - 数量（0.1単位。旅費の計欄）
+type Quantity int64
+
+// quantityFromFloat - JSONの数値を0.1単位に丸める（小数第2位を四捨五入）
+func quantityFromFloat(f float64) Quantity {
+	n, _ := parseDecimal(strconv.FormatFloat(f, 'f', -1, 64), 1)
+	return Quantity(n)
+}
+
+// String - 小数第1位まで（8.5、-0.5）
+func (q Quantity) String() string {
+	sign := ""
+	n := int64(q)
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+	return fmt.Sprintf("%s%d.%d", sign, n/10, n%10)
+}
+
+// TaxRate - 消費税率（0.01%単位。10% は 1000）
+type TaxRate int64
+
+// taxRateFromPercent - パーセントの税率（10、8）を変換（小数第3位を四捨五入）
+func taxRateFromPercent(f float64) (TaxRate, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) || f < 0 || f > 100 {
+		return 0, fmt.Errorf("must be a percentage between 0 and 100 (e.g. 10 or 8)")
+	}
+	n, err := parseDecimal(strconv.FormatFloat(f, 'f', -1, 64), 2)
+	return TaxRate(n), err
+}
+
+// String - 10%、8%、10.5%
+func (r TaxRate) String() string {
+	s := strconv.FormatInt(int64(r)/100, 10)
+	if frac := int64(r) % 100; frac != 0 {
+		s += strings.TrimRight(fmt.Sprintf(".%02d", frac), "0")
+	}
+	return s + "%"
+}
+
+// parseDecimal - 10進数の文字列を小数第 places 位までの整数にする（それより下は四捨五入）
+func parseDecimal(s string, places int) (int64, error) {
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" {
+		whole = "0"
+	}
+	roundUp := len(frac) > places && frac[places] >= '5'
+	frac = (frac + strings.Repeat("0", places))[:places]
+
+	n, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid decimal %q: %v", s, err)
+	}
+	if roundUp {
+		n++
+	}
+	if neg {
+		n = -n
+	}
+	return n, nil
+}
+
+// 消費税の端数処理（pdf.tax.rounding）
+const (
+	TaxRoundFloor = "floor" // 切り捨て
+	TaxRoundHalf  = "round" // 四捨五入
+	TaxRoundCeil  = "ceil"  // 切り上げ
+)
+
+// validateTaxRounding - 端数処理の指定を確認
+func validateTaxRounding(mode string) error {
+	switch mode {
+	case TaxRoundFloor, TaxRoundHalf, TaxRoundCeil:
+		return nil
+	}
+	return fmt.Errorf("消費税の端数処理は floor・round・ceil のいずれかを指定してください: %q", mode)
+}
+
+// divRound - n/d を端数処理する（d > 0。負の金額は絶対値で処理して符号を戻す）
+func divRound(n, d int64, mode string) int64 {
+	sign := int64(1)
+	if n < 0 {
+		sign, n = -1, -n
+	}
+	q, r := n/d, n%d
+	switch {
+	case r == 0:
+	case mode == TaxRoundCeil:
+		q++
+	case mode == TaxRoundHalf && r*2 >= d:
+		q++
+	}
+	return sign * q
+}
+
+// moneyFromFloat - JSONの金額を円単位に丸める（1円未満は四捨五入）
+func moneyFromFloat(f float64) Money {
+	n, _ := parseDecimal(strconv.FormatFloat(f, 'f', -1, 64), 0)
+	return Money(n)
+}
+
+// TaxBreakdown - 税込・税抜・消費税の内訳
+type TaxBreakdown struct {
+	Rate      TaxRate
+	HasRate   bool  // 税率が分かっているか（消費税額だけを指定した場合は false）
+	Exclusive Money // 税抜
+	Tax       Money // 消費税
+	Inclusive Money // 税込
+}
+
+// computeTax - 金額と税率から内訳を計算（included: 金額が税込か）
+func computeTax(amount Money, rate TaxRate, included bool, rounding string) TaxBreakdown {
+	b := TaxBreakdown{Rate: rate, HasRate: true}
+	if included {
+		// 税込金額に含まれる消費税 = 税込 × 税率 / (100% + 税率)
+		b.Inclusive = amount
+		b.Tax = Money(divRound(int64(amount)*int64(rate), 10000+int64(rate), rounding))
+		b.Exclusive = amount - b.Tax
+	} else {
+		b.Exclusive = amount
+		b.Tax = Money(divRound(int64(amount)*int64(rate), 10000, rounding))
+		b.Inclusive = amount + b.Tax
+	}
+	return b
+}
+
+// splitTax - 金額と消費税額から内訳を作る（included: 金額が税込か）
+func splitTax(amount, tax Money, included bool) TaxBreakdown {
+	b := TaxBreakdown{Tax: tax}
+	if included {
+		b.Inclusive = amount
+		b.Exclusive = amount - tax
+	} else {
+		b.Exclusive = amount
+		b.Inclusive = amount + tax
+	}
+	return b
+}
+
+// itemTax - アイテムの消費税の内訳（Tax・TaxRate のどちらも指定されていない・税率が不正な場合は false）
+// 消費税額（Tax）がある場合はその額で内訳を作り、税率（TaxRate）は印字にだけ使う
+func itemTax(item Item, cfg TaxConfig) (TaxBreakdown, bool) {
+	var rate TaxRate
+	hasRate := false
+	if item.TaxRate != nil {
+		r, err := taxRateFromPercent(*item.TaxRate)
+		if err != nil {
+			return TaxBreakdown{}, false
+		}
+		rate, hasRate = r, true
+	}
+	if item.Tax != nil {
+		b := splitTax(Money(item.Price), moneyFromFloat(*item.Tax), cfg.PriceIncludesTax)
+		b.Rate, b.HasRate = rate, hasRate
+		return b, true
+	}
+	if !hasRate {
+		return TaxBreakdown{}, false
+	}
+	return computeTax(Money(item.Price), rate, cfg.PriceIncludesTax, cfg.Rounding), true
+}
+
+// validateItemTax - Item.TaxRate が税率（パーセント）として正しいか検証
+func validateItemTax(items []Item) []FieldError {
+	var errs []FieldError
+	for i, item := range items {
+		if item.TaxRate == nil {
+			continue
+		}
+		if _, err := taxRateFromPercent(*item.TaxRate); err != nil {
+			errs = append(errs, FieldError{Field: fmt.Sprintf("items[%d].taxRate", i), Message: err.Error()})
+		}
+	}
+	return errs
+}
+
+// TaxConfig - 消費税の計算方法
+type TaxConfig struct {
+	Rounding         string `json:"rounding"`         // 端数処理: floor（切り捨て）, round（四捨五入）, ceil（切り上げ）
+	PriceIncludesTax bool   `json:"priceIncludesTax"` // Item.Price が税込金額か（false の場合は税抜金額として消費税を加算）
+}
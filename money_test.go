@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		input Money
+		want  string
+	}{
+		{0, "0"},
+		{5, "5"},
+		{999, "999"},
+		{1000, "1,000"},
+		{1234567, "1,234,567"},
+		{-5000, "-5,000"},
+	}
+	for _, tt := range tests {
+		if got := tt.input.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q, want %q", int64(tt.input), got, tt.want)
+		}
+	}
+}
+
+func TestQuantityFromFloat(t *testing.T) {
+	tests := []struct {
+		input float64
+		want  string
+	}{
+		{1.5, "1.5"},
+		{2, "2.0"},
+		{0.1 + 0.2, "0.3"},
+		{8.25, "8.3"}, // %.1f では 8.2
+		{2.675, "2.7"},
+		{-0.05, "-0.1"},
+	}
+	for _, tt := range tests {
+		if got := quantityFromFloat(tt.input).String(); got != tt.want {
+			t.Errorf("quantityFromFloat(%v) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestTaxRateFromPercent(t *testing.T) {
+	tests := []struct {
+		input   float64
+		want    TaxRate
+		str     string
+		wantErr bool
+	}{
+		{input: 10, want: 1000, str: "10%"},
+		{input: 8, want: 800, str: "8%"},
+		{input: 10.5, want: 1050, str: "10.5%"},
+		{input: 0.125, want: 13, str: "0.13%"},
+		{input: 0, want: 0, str: "0%"},
+		{input: -1, wantErr: true},
+		{input: 100.01, wantErr: true},
+		{input: math.NaN(), wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := taxRateFromPercent(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("taxRateFromPercent(%v) = %v, want error", tt.input, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want || got.String() != tt.str {
+			t.Errorf("taxRateFromPercent(%v) = %d (%s), %v, want %d (%s)", tt.input, got, got, err, tt.want, tt.str)
+		}
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input   string
+		places  int
+		want    int64
+		wantErr bool
+	}{
+		{input: "12.345", places: 2, want: 1235},
+		{input: "12.344", places: 2, want: 1234},
+		{input: "-0.5", places: 1, want: -5},
+		{input: ".5", places: 1, want: 5},
+		{input: "7", places: 2, want: 700},
+		{input: "abc", places: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseDecimal(tt.input, tt.places)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseDecimal(%q, %d) = %d, %v, want %d (error %v)", tt.input, tt.places, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestComputeTax(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   Money
+		rate     TaxRate
+		included bool
+		rounding string
+		want     TaxBreakdown
+	}{
+		{"税込10%", 11000, 1000, true, TaxRoundFloor, TaxBreakdown{1000, true, 10000, 1000, 11000}},
+		{"税込8%", 10800, 800, true, TaxRoundFloor, TaxBreakdown{800, true, 10000, 800, 10800}},
+		{"税込の切り捨て", 1000, 1000, true, TaxRoundFloor, TaxBreakdown{1000, true, 910, 90, 1000}},
+		{"税込の四捨五入", 1000, 1000, true, TaxRoundHalf, TaxBreakdown{1000, true, 909, 91, 1000}},
+		{"税込の切り上げ", 1000, 1000, true, TaxRoundCeil, TaxBreakdown{1000, true, 909, 91, 1000}},
+		{"税抜の切り捨て", 1005, 1000, false, TaxRoundFloor, TaxBreakdown{1000, true, 1005, 100, 1105}},
+		{"税抜の四捨五入（0.5）", 1005, 1000, false, TaxRoundHalf, TaxBreakdown{1000, true, 1005, 101, 1106}},
+		{"税抜の四捨五入（0.4）", 1004, 1000, false, TaxRoundHalf, TaxBreakdown{1000, true, 1004, 100, 1104}},
+		{"税抜の切り上げ", 1001, 1000, false, TaxRoundCeil, TaxBreakdown{1000, true, 1001, 101, 1102}},
+		{"マイナスは絶対値で端数処理", -1005, 1000, false, TaxRoundFloor, TaxBreakdown{1000, true, -1005, -100, -1105}},
+		{"税率0%", 5000, 0, true, TaxRoundCeil, TaxBreakdown{0, true, 5000, 0, 5000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := computeTax(tt.amount, tt.rate, tt.included, tt.rounding); got != tt.want {
+				t.Errorf("computeTax() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestItemTax(t *testing.T) {
+	cfg := defaultConfig().PDF.Tax
+	if _, ok := itemTax(Item{Price: 1000}, cfg); ok {
+		t.Error("itemTax() without tax should be false")
+	}
+	if _, ok := itemTax(Item{Price: 1000, TaxRate: Float64Ptr(500)}, cfg); ok {
+		t.Error("itemTax() with an invalid rate should be false")
+	}
+	excluded := TaxConfig{Rounding: TaxRoundFloor}
+	tests := []struct {
+		name string
+		item Item
+		cfg  TaxConfig
+		want TaxBreakdown
+	}{
+		{"税率から計算", Item{Price: 3300, TaxRate: Float64Ptr(10)}, cfg, TaxBreakdown{1000, true, 3000, 300, 3300}},
+		{"消費税額（税込）", Item{Price: 5000, Tax: Float64Ptr(500.0)}, cfg, TaxBreakdown{0, false, 4500, 500, 5000}},
+		{"消費税額（税抜）", Item{Price: 5000, Tax: Float64Ptr(500.0)}, excluded, TaxBreakdown{0, false, 5000, 500, 5500}},
+		{"消費税額の1円未満は四捨五入", Item{Price: 3000, Tax: Float64Ptr(272.5)}, cfg, TaxBreakdown{0, false, 2727, 273, 3000}},
+		{"消費税額と税率（額を優先）", Item{Price: 10800, Tax: Float64Ptr(800), TaxRate: Float64Ptr(8)}, cfg, TaxBreakdown{800, true, 10000, 800, 10800}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := itemTax(tt.item, tt.cfg)
+			if !ok || got != tt.want {
+				t.Errorf("itemTax() = %+v, %v, want %+v", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigTax(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		want    TaxConfig
+		wantErr string
+	}{
+		{name: "既定値", config: `{}`, want: TaxConfig{Rounding: TaxRoundFloor, PriceIncludesTax: true}},
+		{name: "税抜・四捨五入", config: `{"pdf":{"tax":{"rounding":"round","priceIncludesTax":false}}}`, want: TaxConfig{Rounding: TaxRoundHalf}},
+		{name: "不明な端数処理", config: `{"pdf":{"tax":{"rounding":"bankers"}}}`, wantErr: "消費税の端数処理"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), configFileName)
+			if err := os.WriteFile(path, []byte(tt.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := loadConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.PDF.Tax != tt.want {
+				t.Errorf("tax = %+v, want %+v", cfg.PDF.Tax, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderPDFWithTax(t *testing.T) {
+	items := []Item{
+		{Name: "税込", Price: 11000, TaxRate: Float64Ptr(10)},
+		{Name: "軽減税率", Price: 10800, TaxRate: Float64Ptr(8)},
+		{Name: "消費税額", Price: 5000, Tax: Float64Ptr(500.0)},
+	}
+	var buf bytes.Buffer
+	if _, err := RenderPDF(&buf, "", items); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Error("output is not a PDF")
+	}
+}
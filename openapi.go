@@ -0,0 +1,382 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// OpenAPI 3 ドキュメント（スキーマはGoの型から生成するため、モデル変更が自動的に反映される）
+
+// openAPIObject - OpenAPIドキュメントの汎用ノード
+type openAPIObject = map[string]interface{}
+
+// schemaRef - components/schemas への参照
+func schemaRef(name string) openAPIObject {
+	return openAPIObject{"$ref": "#/components/schemas/" + name}
+}
+
+// schemaFromType - Goの型からJSON Schemaを生成
+// 構造体は components に登録して参照を返す。required は明示したフィールドのみ
+func schemaFromType(t reflect.Type, components openAPIObject) openAPIObject {
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := schemaFromType(t.Elem(), components)
+		if _, isRef := schema["$ref"]; isRef {
+			return openAPIObject{"allOf": []interface{}{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	case reflect.String:
+		return openAPIObject{"type": "string"}
+	case reflect.Bool:
+		return openAPIObject{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return openAPIObject{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return openAPIObject{"type": "number"}
+	case reflect.Slice:
+		return openAPIObject{"type": "array", "items": schemaFromType(t.Elem(), components)}
+	case reflect.Interface:
+		return openAPIObject{}
+	case reflect.Struct:
+		name := t.Name()
+		if _, done := components[name]; !done {
+			components[name] = openAPIObject{} // 再帰参照対策
+			components[name] = structSchema(t, components)
+		}
+		return schemaRef(name)
+	}
+	return openAPIObject{}
+}
+
+// structSchema - 構造体のスキーマ
+func structSchema(t reflect.Type, components openAPIObject) openAPIObject {
+	properties := openAPIObject{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, omitempty, ok := jsonFieldName(field)
+		if !ok {
+			continue
+		}
+		properties[name] = schemaFromType(field.Type, components)
+		if field.Tag.Get("openapi") == "required" || (!omitempty && responseSchemas[t.Name()]) {
+			required = append(required, name)
+		}
+	}
+	schema := openAPIObject{"type": "object", "properties": properties}
+	if !responseSchemas[t.Name()] {
+		schema["additionalProperties"] = false // /v1 は未知のフィールドを拒否
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// responseSchemas - 省略されないフィールドを必須とするレスポンス型
+var responseSchemas = map[string]bool{
+	"PDFResponse":           true,
+	"EnvelopePrintResponse": true,
+	"HealthResponse":        true,
+	"APIErrorResponse":      true,
+	"FieldError":            true,
+	"UpdateStatusResponse":  true,
+	"UpdateActionResponse":  true,
+}
+
+// jsonFieldName - JSONタグからフィールド名を取得
+func jsonFieldName(field reflect.StructField) (name string, omitempty bool, ok bool) {
+	if !field.IsExported() {
+		return "", false, false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, true
+}
+
+// jsonContent - application/json のコンテンツ定義
+func jsonContent(schema openAPIObject, example interface{}) openAPIObject {
+	media := openAPIObject{"schema": schema}
+	if example != nil {
+		media["example"] = example
+	}
+	return openAPIObject{"application/json": media}
+}
+
+// errorResponse - エラーレスポンス定義
+func errorResponse(description string) openAPIObject {
+	return openAPIObject{
+		"description": description,
+		"content":     jsonContent(schemaRef("APIErrorResponse"), nil),
+	}
+}
+
+// openAPIDocument - OpenAPI 3 ドキュメントを生成
+func openAPIDocument() openAPIObject {
+	components := openAPIObject{}
+	for _, v := range []interface{}{
+		PrintRequest{}, Item{}, Ryohi{},
+		PDFResponse{}, EnvelopePrintResponse{}, HealthResponse{}, APIErrorResponse{}, FieldError{},
+		UpdateStatusResponse{}, UpdateApplyRequest{}, UpdateActionResponse{},
+	} {
+		schemaFromType(reflect.TypeOf(v), components)
+	}
+
+	exampleItems := []interface{}{openAPIObject{"car": "長崎100か4105", "name": "松本　俊之", "ryohi": []interface{}{}}}
+	pdfRequestBody := func(legacy bool) openAPIObject {
+		schema := schemaRef("PrintRequest")
+		if legacy {
+			schema = openAPIObject{"oneOf": []interface{}{
+				schemaRef("PrintRequest"),
+				openAPIObject{"type": "array", "items": schemaRef("Item"), "description": "Legacy format: bare array of items (never printed)"},
+			}}
+		}
+		return openAPIObject{
+			"required": true,
+			"content":  jsonContent(schema, openAPIObject{"items": exampleItems, "printerName": "Canon Printer"}),
+		}
+	}
+	pdfResponses := func() openAPIObject {
+		return openAPIObject{
+			"200": openAPIObject{"description": "PDF generated (and printed when requested)", "content": jsonContent(schemaRef("PDFResponse"), nil)},
+			"400": errorResponse("INVALID_BODY or INVALID_JSON (including unknown fields on /v1)"),
+			"405": errorResponse("METHOD_NOT_ALLOWED"),
+			"422": errorResponse("VALIDATION_FAILED"),
+			"500": errorResponse("PDF_GENERATION_FAILED"),
+			"502": errorResponse("PRINT_FAILED"),
+			"503": errorResponse("PRINT_QUEUE_FULL, SHUTTING_DOWN or SERVICE_PAUSED"),
+		}
+	}
+	generateOperation := func(operationID string, legacy bool) openAPIObject {
+		description := "Accepts a PrintRequest object only; unknown fields are rejected. Prints only when `print` is true."
+		if legacy {
+			description = "Accepts a PrintRequest or a bare array of items. Prints only when `print` is true. Unknown fields are ignored."
+		}
+		return openAPIObject{
+			"operationId": operationID,
+			"summary":     "Generate PDF from JSON data",
+			"description": description,
+			"requestBody": pdfRequestBody(legacy),
+			"responses":   pdfResponses(),
+		}
+	}
+	printOperation := func(operationID string) openAPIObject {
+		return openAPIObject{
+			"operationId": operationID,
+			"summary":     "Generate and print PDF",
+			"requestBody": pdfRequestBody(false),
+			"responses":   pdfResponses(),
+		}
+	}
+	envelopeOperation := func(operationID string) openAPIObject {
+		return openAPIObject{
+			"operationId": operationID,
+			"summary":     "Print an uploaded PDF file (envelope printing)",
+			"requestBody": openAPIObject{
+				"required": true,
+				"content": openAPIObject{
+					"multipart/form-data": openAPIObject{
+						"schema": openAPIObject{
+							"type":     "object",
+							"required": []string{"document"},
+							"properties": openAPIObject{
+								"document": openAPIObject{"type": "string", "format": "binary", "description": "PDF file to print"},
+								"printer":  openAPIObject{"type": "string", "description": "Printer name (default printer when omitted)"},
+							},
+						},
+					},
+				},
+			},
+			"responses": openAPIObject{
+				"200": openAPIObject{"description": "Printed", "content": jsonContent(schemaRef("EnvelopePrintResponse"), nil)},
+				"400": errorResponse("INVALID_MULTIPART"),
+				"405": errorResponse("METHOD_NOT_ALLOWED"),
+				"422": errorResponse("VALIDATION_FAILED (missing or non-PDF document)"),
+				"500": errorResponse("INTERNAL_ERROR"),
+				"502": errorResponse("PRINT_FAILED"),
+				"503": errorResponse("PRINT_QUEUE_FULL, SHUTTING_DOWN or SERVICE_PAUSED"),
+			},
+		}
+	}
+	healthOperation := func(operationID string) openAPIObject {
+		return openAPIObject{
+			"operationId": operationID,
+			"summary":     "Health check",
+			"responses": openAPIObject{
+				"200": openAPIObject{"description": "Service is running (`paused` is true while the Windows service is paused)", "content": jsonContent(schemaRef("HealthResponse"), nil)},
+			},
+		}
+	}
+	// adminOperation - 管理API（Bearer認証）の共通定義
+	adminOperation := func(operationID, summary, description string, responses openAPIObject) openAPIObject {
+		responses["401"] = errorResponse("UNAUTHORIZED (missing or invalid bearer token)")
+		responses["403"] = errorResponse("ADMIN_DISABLED (admin.token is not configured)")
+		responses["405"] = errorResponse("METHOD_NOT_ALLOWED")
+		return openAPIObject{
+			"operationId": operationID,
+			"summary":     summary,
+			"description": description,
+			"security":    []interface{}{openAPIObject{"bearerAuth": []interface{}{}}},
+			"responses":   responses,
+		}
+	}
+	updateStatus := func(description string) openAPIObject {
+		return openAPIObject{"description": description, "content": jsonContent(schemaRef("UpdateStatusResponse"), nil)}
+	}
+	updateAccepted := openAPIObject{"description": "Staged; the service restarts after pending jobs finish", "content": jsonContent(schemaRef("UpdateActionResponse"), nil)}
+
+	paths := openAPIObject{
+		"/v1/generate-pdf": openAPIObject{"post": generateOperation("generatePdf", false)},
+		"/v1/print-pdf":    openAPIObject{"post": printOperation("printPdf")},
+		"/v1/print":        openAPIObject{"post": envelopeOperation("printEnvelope")},
+		"/v1/health":       openAPIObject{"get": healthOperation("health")},
+		"/generate-pdf":    openAPIObject{"post": generateOperation("legacyGeneratePdf", true)},
+		"/print-pdf":       openAPIObject{"post": printOperation("legacyPrintPdf")},
+		"/print":           openAPIObject{"post": envelopeOperation("legacyPrintEnvelope")},
+		"/health":          openAPIObject{"get": healthOperation("legacyHealth")},
+		"/admin/update": openAPIObject{
+			"get": adminOperation("updateStatus", "Update status", "Current version, channel and the result of the last check.", openAPIObject{
+				"200": updateStatus("Update status"),
+			}),
+		},
+		"/admin/update/check": openAPIObject{
+			"post": adminOperation("updateCheck", "Check for a newer release now", "Queries the configured update source and returns the refreshed status.", openAPIObject{
+				"200": updateStatus("Check completed"),
+				"409": errorResponse("NO_UPDATE_AVAILABLE (development build)"),
+				"502": errorResponse("UPDATE_FAILED (update source unreachable)"),
+			}),
+		},
+		"/admin/update/apply": openAPIObject{
+			"post": func() openAPIObject {
+				op := adminOperation("updateApply", "Apply a release and restart",
+					"Downloads and verifies the release (the newest one for the configured channel unless `version` is given), stages it and restarts once pending print jobs finish. An explicit version may be older than the running one.",
+					openAPIObject{
+						"202": updateAccepted,
+						"400": errorResponse("INVALID_BODY or INVALID_JSON"),
+						"404": errorResponse("RELEASE_NOT_FOUND"),
+						"409": errorResponse("UPDATE_IN_PROGRESS or NO_UPDATE_AVAILABLE"),
+						"422": errorResponse("VALIDATION_FAILED (version is not a semantic version)"),
+						"502": errorResponse("UPDATE_FAILED (download, checksum or signature failure)"),
+					})
+				op["requestBody"] = openAPIObject{
+					"required": false,
+					"content":  jsonContent(schemaRef("UpdateApplyRequest"), openAPIObject{"version": "v1.2.3"}),
+				}
+				return op
+			}(),
+		},
+		"/admin/update/rollback": openAPIObject{
+			"post": adminOperation("updateRollback", "Roll back to the previous version",
+				"Restores the executable saved by the last update (print_pdf.exe.old) and restarts once pending print jobs finish.",
+				openAPIObject{
+					"202": updateAccepted,
+					"409": errorResponse("UPDATE_IN_PROGRESS or NO_ROLLBACK_AVAILABLE"),
+					"502": errorResponse("UPDATE_FAILED"),
+				}),
+		},
+		"/metrics": openAPIObject{
+			"get": openAPIObject{
+				"operationId": "metrics",
+				"summary":     "Prometheus metrics",
+				"responses": openAPIObject{
+					"200": openAPIObject{"description": "Metrics in Prometheus text format", "content": openAPIObject{"text/plain": openAPIObject{"schema": openAPIObject{"type": "string"}}}},
+					"405": errorResponse("METHOD_NOT_ALLOWED"),
+				},
+			},
+		},
+		"/openapi.json": openAPIObject{
+			"get": openAPIObject{
+				"operationId": "openapi",
+				"summary":     "OpenAPI 3 specification of this API",
+				"responses": openAPIObject{
+					"200": openAPIObject{"description": "This document", "content": jsonContent(openAPIObject{"type": "object"}, nil)},
+					"405": errorResponse("METHOD_NOT_ALLOWED"),
+				},
+			},
+		},
+		"/": openAPIObject{
+			"get": openAPIObject{
+				"operationId": "overview",
+				"summary":     "API overview",
+				"responses": openAPIObject{
+					"200": openAPIObject{"description": "Human readable overview", "content": openAPIObject{"text/plain": openAPIObject{"schema": openAPIObject{"type": "string"}}}},
+					"404": errorResponse("NOT_FOUND"),
+				},
+			},
+		},
+	}
+
+	// 非推奨ルートに deprecated と Deprecation ヘッダーを記載
+	for _, route := range apiRoutes() {
+		if route.Successor == "" {
+			continue
+		}
+		item, ok := paths[route.Path].(openAPIObject)
+		if !ok {
+			continue
+		}
+		for _, op := range item {
+			operation := op.(openAPIObject)
+			operation["deprecated"] = true
+			operation["description"] = strings.TrimSpace(fmt.Sprintf("Deprecated: use %s. %s", route.Successor, stringValue(operation["description"])))
+			for _, response := range operation["responses"].(openAPIObject) {
+				response.(openAPIObject)["headers"] = openAPIObject{
+					"Deprecation": openAPIObject{"description": "Always \"true\" on deprecated routes", "schema": openAPIObject{"type": "string"}},
+					"Link":        openAPIObject{"description": "Successor route (rel=\"successor-version\")", "schema": openAPIObject{"type": "string"}},
+				}
+			}
+		}
+	}
+
+	return openAPIObject{
+		"openapi": "3.0.3",
+		"info": openAPIObject{
+			"title":       "PDF Generator API",
+			"version":     Version,
+			"description": "Generates travel expense sheets (出張旅費精算書) as PDF and prints them.",
+		},
+		"servers": []interface{}{openAPIObject{"url": "http://localhost:8081"}},
+		"paths":   paths,
+		"components": openAPIObject{
+			"schemas": components,
+			"securitySchemes": openAPIObject{
+				"bearerAuth": openAPIObject{"type": "http", "scheme": "bearer", "description": "admin.token from print_pdf_config.json"},
+			},
+		},
+	}
+}
+
+// stringValue - 文字列でなければ空文字列
+func stringValue(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+// openAPIHandler - GET /openapi.json
+func openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeMethodNotAllowed(w, r, "GET")
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(openAPIDocument())
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// openAPISpec - 実際に配信されているドキュメントを取得
+func openAPISpec(t *testing.T) map[string]interface{} {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	newServeMux().ServeHTTP(rec, httptest.NewRequest("GET", "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json status = %d", rec.Code)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+	return spec
+}
+
+func TestOpenAPIPathsMatchRoutes(t *testing.T) {
+	spec := openAPISpec(t)
+	paths := spec["paths"].(map[string]interface{})
+
+	routed := map[string]bool{}
+	for _, route := range apiRoutes() {
+		for _, method := range route.Methods {
+			key := strings.ToLower(method) + " " + route.Path
+			routed[key] = true
+			op, ok := paths[route.Path].(map[string]interface{})
+			if !ok || op[strings.ToLower(method)] == nil {
+				t.Errorf("route %s is not documented in openapi.json", key)
+			}
+		}
+	}
+
+	for path, item := range paths {
+		for method := range item.(map[string]interface{}) {
+			if !routed[method+" "+path] {
+				t.Errorf("openapi.json documents %s %s but no handler is registered", method, path)
+			}
+		}
+	}
+}
+
+func TestOpenAPIMarksLegacyRoutesDeprecated(t *testing.T) {
+	paths := openAPISpec(t)["paths"].(map[string]interface{})
+	for _, route := range apiRoutes() {
+		op := paths[route.Path].(map[string]interface{})[strings.ToLower(route.Methods[0])].(map[string]interface{})
+		deprecated, _ := op["deprecated"].(bool)
+		if deprecated != (route.Successor != "") {
+			t.Errorf("%s: deprecated = %v, successor = %q", route.Path, deprecated, route.Successor)
+		}
+	}
+}
+
+func TestOpenAPIComponentsCoverModels(t *testing.T) {
+	schemas := openAPISpec(t)["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	expected := map[string][]string{
+		"PrintRequest": {"items", "print", "printerName", "watermark"},
+		"Item":         {"car", "name", "purpose", "startDate", "endDate", "price", "tax", "taxRate", "description", "ryohi", "office", "payDay"},
+		"Ryohi":        {"date", "dest", "detail", "kukan", "price", "vol"},
+	}
+	for name, fields := range expected {
+		schema, ok := schemas[name].(map[string]interface{})
+		if !ok {
+			t.Errorf("schema %s missing", name)
+			continue
+		}
+		props := schema["properties"].(map[string]interface{})
+		for _, field := range fields {
+			if _, ok := props[field]; !ok {
+				t.Errorf("schema %s missing property %s", name, field)
+			}
+		}
+	}
+}
+
+// contractCase - ハンドラーの実際の応答が仕様に記載されているか確認するケース
+type contractCase struct {
+	name        string
+	method      string
+	path        string
+	contentType string
+	body        []byte
+	printErr    error
+}
+
+func multipartBody(t *testing.T, withDocument bool) ([]byte, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.WriteField("printer", "LBP221-futo")
+	if withDocument {
+		fw, _ := mw.CreateFormFile("document", "futo.pdf")
+		fw.Write([]byte("%PDF-1.4\n%%EOF\n"))
+	}
+	mw.Close()
+	return buf.Bytes(), mw.FormDataContentType()
+}
+
+func TestOpenAPIContract(t *testing.T) {
+	spec := openAPISpec(t)
+	paths := spec["paths"].(map[string]interface{})
+
+	// 仕様に記載された例をそのままリクエストに使う
+	example := func(path string) []byte {
+		op := paths[path].(map[string]interface{})["post"].(map[string]interface{})
+		content := op["requestBody"].(map[string]interface{})["content"].(map[string]interface{})
+		data, _ := json.Marshal(content["application/json"].(map[string]interface{})["example"])
+		return data
+	}
+	withDoc, withDocType := multipartBody(t, true)
+	withoutDoc, withoutDocType := multipartBody(t, false)
+
+	cases := []contractCase{
+		{name: "generate example", method: "POST", path: "/generate-pdf", body: example("/generate-pdf")},
+		{name: "generate legacy array", method: "POST", path: "/generate-pdf", body: []byte(`[{"car":"test","name":"テスト","ryohi":[]}]`)},
+		{name: "generate invalid json", method: "POST", path: "/generate-pdf", body: []byte(`{`)},
+		{name: "generate empty", method: "POST", path: "/generate-pdf", body: []byte(`[]`)},
+		{name: "generate wrong method", method: "GET", path: "/generate-pdf"},
+		{name: "print-pdf example", method: "POST", path: "/print-pdf", body: example("/print-pdf")},
+		{name: "print-pdf printer failure", method: "POST", path: "/print-pdf", body: example("/print-pdf"), printErr: errors.New("offline")},
+		{name: "print envelope", method: "POST", path: "/print", body: withDoc, contentType: withDocType},
+		{name: "print envelope missing document", method: "POST", path: "/print", body: withoutDoc, contentType: withoutDocType},
+		{name: "v1 generate example", method: "POST", path: "/v1/generate-pdf", body: example("/v1/generate-pdf")},
+		{name: "v1 generate bare array", method: "POST", path: "/v1/generate-pdf", body: []byte(`[{"car":"test","name":"テスト","ryohi":[]}]`)},
+		{name: "v1 generate unknown field", method: "POST", path: "/v1/generate-pdf", body: []byte(`{"items":[{"name":"a"}],"copies":2}`)},
+		{name: "v1 print-pdf example", method: "POST", path: "/v1/print-pdf", body: example("/v1/print-pdf")},
+		{name: "v1 print envelope", method: "POST", path: "/v1/print", body: withDoc, contentType: withDocType},
+		{name: "v1 health", method: "GET", path: "/v1/health"},
+		{name: "health", method: "GET", path: "/health"},
+		{name: "admin status disabled", method: "GET", path: "/admin/update"},
+		{name: "admin apply disabled", method: "POST", path: "/admin/update/apply"},
+		{name: "admin check wrong method", method: "GET", path: "/admin/update/check"},
+		{name: "metrics", method: "GET", path: "/metrics"},
+		{name: "overview", method: "GET", path: "/"},
+		{name: "unknown path", method: "GET", path: "/does-not-exist"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stubPrinter(t, func(pdfPath, printerName string) error { return tc.printErr })
+
+			req := httptest.NewRequest(tc.method, tc.path, bytes.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			} else if tc.body != nil {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			rec := httptest.NewRecorder()
+			requestIDMiddleware(newServeMux()).ServeHTTP(rec, req)
+
+			specPath := tc.path
+			if _, ok := paths[specPath]; !ok {
+				specPath = "/" // 未定義のパスはルートハンドラーが処理
+			}
+			op, ok := paths[specPath].(map[string]interface{})[strings.ToLower(tc.method)].(map[string]interface{})
+			if !ok {
+				// 仕様にないメソッドは 405 と Allow ヘッダーで応答すること
+				if rec.Code != http.StatusMethodNotAllowed {
+					t.Fatalf("%s %s is not documented but returned %d", tc.method, specPath, rec.Code)
+				}
+				for method := range paths[specPath].(map[string]interface{}) {
+					if !strings.Contains(rec.Header().Get("Allow"), strings.ToUpper(method)) {
+						t.Errorf("Allow header %q does not list documented method %s", rec.Header().Get("Allow"), method)
+					}
+				}
+				return
+			}
+			responses := op["responses"].(map[string]interface{})
+			status := fmt.Sprint(rec.Code)
+			response, ok := responses[status].(map[string]interface{})
+			if !ok {
+				documented := make([]string, 0, len(responses))
+				for code := range responses {
+					documented = append(documented, code)
+				}
+				sort.Strings(documented)
+				t.Fatalf("status %s is not documented (documented: %v): %s", status, documented, rec.Body.String())
+			}
+
+			content := response["content"].(map[string]interface{})
+			media, ok := content["application/json"].(map[string]interface{})
+			if !ok {
+				return // text/plain
+			}
+			var body interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not JSON: %s", rec.Body.String())
+			}
+			if err := validateSchema(spec, media["schema"].(map[string]interface{}), body, "$"); err != nil {
+				t.Errorf("response does not match schema: %v\n%s", err, rec.Body.String())
+			}
+		})
+	}
+}
+
+// validateSchema - 仕様で使っているJSON Schemaのサブセットを検証
+func validateSchema(spec map[string]interface{}, schema map[string]interface{}, value interface{}, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		resolved := spec["components"].(map[string]interface{})["schemas"].(map[string]interface{})[name]
+		if resolved == nil {
+			return fmt.Errorf("%s: unresolved $ref %s", path, ref)
+		}
+		return validateSchema(spec, resolved.(map[string]interface{}), value, path)
+	}
+	if value == nil {
+		if schema["nullable"] == true || len(schema) == 0 {
+			return nil
+		}
+		return fmt.Errorf("%s: null is not allowed", path)
+	}
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, s := range allOf {
+			if err := validateSchema(spec, s.(map[string]interface{}), value, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		for _, s := range oneOf {
+			if validateSchema(spec, s.(map[string]interface{}), value, path) == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: value matches none of oneOf", path)
+	}
+
+	switch schema["type"] {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected object", path)
+		}
+		props, _ := schema["properties"].(map[string]interface{})
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, r := range required {
+				if _, ok := obj[r.(string)]; !ok {
+					return fmt.Errorf("%s: missing required property %s", path, r)
+				}
+			}
+		}
+		for key, v := range obj {
+			propSchema, ok := props[key].(map[string]interface{})
+			if !ok {
+				if props != nil {
+					return fmt.Errorf("%s: undocumented property %s", path, key)
+				}
+				continue
+			}
+			if err := validateSchema(spec, propSchema, v, path+"."+key); err != nil {
+				return err
+			}
+		}
+	case "array":
+		arr, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected array", path)
+		}
+		for i, v := range arr {
+			if err := validateSchema(spec, schema["items"].(map[string]interface{}), v, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: expected string", path)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean", path)
+		}
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("%s: expected number", path)
+		}
+		if schema["type"] == "integer" && n != float64(int64(n)) {
+			return fmt.Errorf("%s: expected integer", path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// サービス一時停止中の印刷リクエストの扱い（print.pauseMode）
+const (
+	PauseModeReject = "reject" // 503 SERVICE_PAUSED で拒否
+	PauseModeQueue  = "queue"  // 受け付けて再開まで待たせる（キューの上限を超えると503）
+)
+
+// pauseService - Windowsサービスの一時停止: 新しい印刷ジョブを開始しない
+// 実行中のジョブはそのまま完了させる
+func pauseService() {
+	mode := currentConfig().Print.PauseMode
+	jobQueue.Pause(mode == PauseModeQueue)
+	servicePaused.Set(1)
+	writeEventLog("INFO", fmt.Sprintf("サービスを一時停止しました (印刷リクエスト: %s, 待機中・実行中: %d件)", mode, jobQueue.Depth()))
+}
+
+// continueService - 一時停止を解除し、待たせていた印刷ジョブを再開
+func continueService() {
+	jobQueue.Resume()
+	servicePaused.Set(0)
+	writeEventLog("INFO", fmt.Sprintf("サービスを再開しました (待機中・実行中: %d件)", jobQueue.Depth()))
+}
+
+// rejectWhilePaused - 一時停止中に受け付けないリクエストなら503を返してtrue
+// 印刷はキューの設定に従い、印刷なしの生成は print.generateWhilePaused に従う
+func rejectWhilePaused(w http.ResponseWriter, r *http.Request, printing bool) bool {
+	if !jobQueue.Paused() {
+		return false
+	}
+	if printing && !jobQueue.Rejecting() {
+		return false
+	}
+	if !printing && currentConfig().Print.GenerateWhilePaused {
+		return false
+	}
+	target := "PDF生成"
+	if printing {
+		target = "印刷"
+	}
+	return writePrintUnavailable(w, r, errServicePaused, target, nil)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// withPauseConfig - テスト中だけ一時停止の設定を差し替える
+func withPauseConfig(t *testing.T, mode string, generateWhilePaused bool) {
+	t.Helper()
+	withTestQueue(t, 5)
+	cfg := currentConfig()
+	cfg.Print.PauseMode = mode
+	cfg.Print.GenerateWhilePaused = generateWhilePaused
+	setConfig(cfg)
+	t.Cleanup(func() { servicePaused.Set(0) })
+}
+
+func TestRequestsWhilePaused(t *testing.T) {
+	tests := []struct {
+		name                string
+		mode                string
+		generateWhilePaused bool
+		path                string
+		body                string
+		wantStatus          int
+	}{
+		{name: "print rejected", mode: PauseModeReject, path: "/v1/print-pdf", body: `{"items":[{"name":"a"}]}`, wantStatus: http.StatusServiceUnavailable},
+		{name: "generate with print rejected", mode: PauseModeReject, generateWhilePaused: true, path: "/v1/generate-pdf", body: `{"items":[{"name":"a"}],"print":true}`, wantStatus: http.StatusServiceUnavailable},
+		{name: "generate allowed", mode: PauseModeReject, generateWhilePaused: true, path: "/v1/generate-pdf", body: `{"items":[{"name":"a"}]}`, wantStatus: http.StatusOK},
+		{name: "generate disabled", mode: PauseModeQueue, generateWhilePaused: false, path: "/v1/generate-pdf", body: `{"items":[{"name":"a"}]}`, wantStatus: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withPauseConfig(t, tt.mode, tt.generateWhilePaused)
+			stubPrinter(t, func(pdfPath, printerName string) error {
+				t.Error("printer must not be called while paused")
+				return nil
+			})
+			pauseService()
+
+			req := httptest.NewRequest("POST", tt.path, bytes.NewBufferString(tt.body))
+			rec := httptest.NewRecorder()
+			requestIDMiddleware(newServeMux()).ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusServiceUnavailable {
+				return
+			}
+			if resp := decodeAPIError(t, rec); resp.Code != ErrCodeServicePaused {
+				t.Errorf("code = %s, want %s", resp.Code, ErrCodeServicePaused)
+			}
+			if rec.Header().Get("Retry-After") == "" {
+				t.Error("Retry-After header missing")
+			}
+		})
+	}
+}
+
+func TestPrintQueuedWhilePausedResumesOnContinue(t *testing.T) {
+	withPauseConfig(t, PauseModeQueue, true)
+	printed := make(chan struct{}, 1)
+	stubPrinter(t, func(pdfPath, printerName string) error {
+		printed <- struct{}{}
+		return nil
+	})
+	pauseService()
+
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		body := `{"items":[{"name":"a"}]}`
+		done <- serveWithRequestID(v1PrintPDFHandler, httptest.NewRequest("POST", "/v1/print-pdf", bytes.NewBufferString(body)))
+	}()
+
+	waitForDepth(t, 1, done)
+	select {
+	case <-printed:
+		t.Fatal("printed while paused")
+	default:
+	}
+
+	continueService()
+	rec := receiveWithin(t, done, "response")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	receiveWithin(t, printed, "print job")
+}
+
+// testWaitTimeout - 待機するテストの上限時間（失敗したリクエストで止まり続けないようにする）
+const testWaitTimeout = 10 * time.Second
+
+// waitForDepth - 印刷キューが指定件数になるまで待つ（先にリクエストが終わった場合・時間切れはテストを失敗にする）
+func waitForDepth(t *testing.T, n int, done <-chan *httptest.ResponseRecorder) {
+	t.Helper()
+	deadline := time.NewTimer(testWaitTimeout)
+	defer deadline.Stop()
+	tick := time.NewTicker(time.Millisecond)
+	defer tick.Stop()
+	for jobQueue.Depth() < n {
+		select {
+		case rec := <-done:
+			t.Fatalf("request finished before the queue depth reached %d: %d %s", n, rec.Code, rec.Body.String())
+		case <-deadline.C:
+			t.Fatalf("queue depth = %d, want %d after %v", jobQueue.Depth(), n, testWaitTimeout)
+		case <-tick.C:
+		}
+	}
+}
+
+// receiveWithin - チャネルから受信する（時間切れはテストを失敗にする）
+func receiveWithin[T any](t *testing.T, ch <-chan T, what string) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(testWaitTimeout):
+		t.Fatalf("no %s within %v", what, testWaitTimeout)
+		var zero T
+		return zero
+	}
+}
+
+func TestHealthReportsPaused(t *testing.T) {
+	withPauseConfig(t, PauseModeReject, true)
+
+	for _, paused := range []bool{true, false} {
+		if paused {
+			pauseService()
+		} else {
+			continueService()
+		}
+		rec := httptest.NewRecorder()
+		healthHandler(rec, httptest.NewRequest("GET", "/v1/health", nil))
+		var resp HealthResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Paused != paused || resp.Status != "ok" {
+			t.Errorf("paused=%v: health = %+v", paused, resp)
+		}
+		if servicePaused.Value() != map[bool]float64{true: 1, false: 0}[paused] {
+			t.Errorf("paused=%v: gauge = %v", paused, servicePaused.Value())
+		}
+	}
+}
+
+func TestQueuedPrintKeepsItsOwnPDF(t *testing.T) {
+	withPauseConfig(t, PauseModeQueue, true)
+	type printed struct {
+		path string
+		data []byte
+	}
+	jobs := make(chan printed, 2)
+	stubPrinter(t, func(pdfPath, printerName string) error {
+		data, err := os.ReadFile(pdfPath)
+		if err != nil {
+			return err
+		}
+		jobs <- printed{pdfPath, data}
+		return nil
+	})
+	pauseService()
+
+	// 一時停止中に印刷を2件待たせ、その間に印刷なしの生成も行う
+	done := make(chan *httptest.ResponseRecorder, 2)
+	for i, name := range []string{"山田", "佐藤　花子"} {
+		body := `{"items":[{"name":"` + name + `"}]}`
+		go func() {
+			done <- serveWithRequestID(v1PrintPDFHandler, httptest.NewRequest("POST", "/v1/print-pdf", bytes.NewBufferString(body)))
+		}()
+		waitForDepth(t, i+1, done)
+	}
+	rec := serveWithRequestID(v1GeneratePDFHandler, httptest.NewRequest("POST", "/v1/generate-pdf", bytes.NewBufferString(`{"items":[{"name":"鈴木"}]}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("generate status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	continueService()
+	var got []printed
+	for i := 0; i < 2; i++ {
+		if rec := receiveWithin(t, done, "print response"); rec.Code != http.StatusOK {
+			t.Fatalf("print status = %d: %s", rec.Code, rec.Body.String())
+		}
+		got = append(got, receiveWithin(t, jobs, "print job"))
+	}
+	if got[0].path == got[1].path || filepath.Base(got[0].path) == "travel_expense_reportlab_style.pdf" {
+		t.Errorf("print jobs share a PDF file: %q, %q", got[0].path, got[1].path)
+	}
+	if bytes.Equal(got[0].data, got[1].data) {
+		t.Error("print jobs printed the same PDF")
+	}
+	for _, p := range got {
+		if _, err := os.Stat(p.path); !os.IsNotExist(err) {
+			t.Errorf("temporary PDF %s was not removed: %v", p.path, err)
+		}
+	}
+}
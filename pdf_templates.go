@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// PDFテンプレート（帳票のレイアウト）の登録
+// HTTP API は既定のテンプレートを使い、print_pdf render では -template で選べる
+
+// defaultTemplateID - 既定のテンプレート
+const defaultTemplateID = "travel-expense"
+
+// pdfTemplate - 帳票のレイアウト
+type pdfTemplate struct {
+	ID          string
+	Description string
+	Dates       DateStyles                                          // 日付の書式の既定値（pdf.templates.<id>.dates で上書き）
+	build       func(items []Item, opts RenderOptions) *gofpdf.Fpdf // 描画済みのドキュメントを作成
+}
+
+// pdfTemplates - 登録済みのテンプレート（ID → テンプレート）
+var pdfTemplates = map[string]pdfTemplate{}
+
+// registerPDFTemplate - テンプレートを登録
+func registerPDFTemplate(t pdfTemplate) {
+	if _, exists := pdfTemplates[t.ID]; exists {
+		panic("PDFテンプレートが重複しています: " + t.ID)
+	}
+	pdfTemplates[t.ID] = t
+}
+
+func init() {
+	registerPDFTemplate(pdfTemplate{
+		ID:          defaultTemplateID,
+		Description: "出張旅費日当駐車料込精算書（A5横、1アイテム1ページ）",
+		Dates:       DateStyles{PayDay: DateStyleYMD, Trip: DateStyleSlots, Row: DateStyleSlash, Seal: DateStyleSeal},
+		build: func(items []Item, opts RenderOptions) *gofpdf.Fpdf {
+			client := newReportLabStylePdfClient()
+			client.render(items, opts)
+			return client.pdf
+		},
+	})
+}
+
+// pdfTemplateIDs - 登録済みのテンプレートID（昇順）
+func pdfTemplateIDs() []string {
+	ids := make([]string, 0, len(pdfTemplates))
+	for id := range pdfTemplates {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// findPDFTemplate - IDでテンプレートを探す（空の場合は既定のテンプレート）
+func findPDFTemplate(id string) (pdfTemplate, error) {
+	if id == "" {
+		id = defaultTemplateID
+	}
+	t, ok := pdfTemplates[id]
+	if !ok {
+		return pdfTemplate{}, fmt.Errorf("unknown template %q (available: %s)", id, strings.Join(pdfTemplateIDs(), ", "))
+	}
+	return t, nil
+}
+
+// RenderPDF - テンプレートでPDFを生成して w に書き込み、ページ数を返す
+func RenderPDF(w io.Writer, templateID string, items []Item) (int, error) {
+	return RenderPDFWithOptions(w, templateID, items, RenderOptions{})
+}
+
+// RenderPDFWithOptions - 描画オプション（透かしなど）を指定してPDFを生成し、ページ数を返す
+func RenderPDFWithOptions(w io.Writer, templateID string, items []Item, opts RenderOptions) (int, error) {
+	t, err := findPDFTemplate(templateID)
+	if err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	pdf := t.build(items, opts)
+	pages := pdf.PageNo()
+	if _, err := writePDF(w, pdf, start); err != nil {
+		return 0, err
+	}
+	return pages, nil
+}
+
+// renderPDFTempFile - 既定のテンプレートでPDFを一時ファイル（print_pdf_*.pdf）に生成してパスを返す
+// 待機中の印刷ジョブが後のリクエストの出力で上書きされないよう、リクエストごとに別のファイルにする。削除は呼び出し側で行う
+func renderPDFTempFile(items []Item, opts RenderOptions) (string, error) {
+	file, err := os.CreateTemp("", "print_pdf_*.pdf")
+	if err != nil {
+		return "", err
+	}
+	_, err = RenderPDFWithOptions(file, "", items, opts)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// writePDF - 描画済みのPDFを w に書き込み、生成時間・ページ数・サイズをメトリクスに記録
+func writePDF(w io.Writer, pdf *gofpdf.Fpdf, start time.Time) (int64, error) {
+	pages := pdf.PageNo()
+	cw := &countingWriter{w: w}
+	if err := pdf.Output(cw); err != nil {
+		observeRender(time.Since(start), 0, 0, err)
+		return cw.n, err
+	}
+	observeRender(time.Since(start), pages, cw.n, nil)
+	return cw.n, nil
+}
+
+// countingWriter - 書き込んだバイト数を数える
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
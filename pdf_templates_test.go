@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFindPDFTemplate(t *testing.T) {
+	tests := []struct {
+		id      string
+		want    string
+		wantErr string
+	}{
+		{id: "", want: defaultTemplateID},
+		{id: "travel-expense", want: "travel-expense"},
+		{id: "invoice", wantErr: `unknown template "invoice"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			got, err := findPDFTemplate(tt.id)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got.ID != tt.want {
+				t.Errorf("findPDFTemplate(%q) = %q, %v; want %q", tt.id, got.ID, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderPDFToWriter(t *testing.T) {
+	items := []Item{
+		{Name: "山田　太郎", Car: "品川500あ1234", Price: 1000, Purpose: StringPtr("営業")},
+		{Name: "鈴木　花子", Price: 2000},
+	}
+
+	var buf bytes.Buffer
+	pages, err := RenderPDF(&buf, "", items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pages != len(items) {
+		t.Errorf("pages = %d, want %d", pages, len(items))
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) || !bytes.Contains(buf.Bytes(), []byte("%%EOF")) {
+		t.Errorf("output is not a complete PDF (%d bytes)", buf.Len())
+	}
+}
+
+// failingWriter - 書き込みに失敗する出力先
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestRenderPDFWriteError(t *testing.T) {
+	if _, err := RenderPDF(failingWriter{}, "", []Item{{Name: "test"}}); err == nil {
+		t.Error("RenderPDF should report write errors")
+	}
+}
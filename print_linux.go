@@ -0,0 +1,37 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+)
+
+// printPDF - 実際の印刷処理（テストで差し替え可能）
+var printPDF = PrintPDFWithLP
+
+// lpCommand - CUPSの lp コマンド（テストで差し替え可能）
+var lpCommand = "lp"
+
+// PrintPDFWithLP - CUPSの lp コマンドでPDFを印刷
+func PrintPDFWithLP(pdfPath string, printerName string) error {
+	absPath, err := filepath.Abs(pdfPath)
+	if err != nil {
+		return fmt.Errorf("PDFファイルの絶対パス取得エラー: %v", err)
+	}
+
+	output, err := exec.Command(lpCommand, lpArgs(absPath, printerName)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("印刷エラー: %v, 出力: %s", err, string(output))
+	}
+	return nil
+}
+
+// lpArgs - lp の引数（プリンター名が空の場合は既定のプリンター）
+func lpArgs(pdfPath, printerName string) []string {
+	if printerName == "" {
+		return []string{"--", pdfPath}
+	}
+	return []string{"-d", printerName, "--", pdfPath}
+}
@@ -0,0 +1,52 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLPArgs(t *testing.T) {
+	tests := []struct {
+		printer string
+		want    []string
+	}{
+		{"", []string{"--", "/tmp/a.pdf"}},
+		{"Canon_LBP221", []string{"-d", "Canon_LBP221", "--", "/tmp/a.pdf"}},
+	}
+	for _, tt := range tests {
+		if got := lpArgs("/tmp/a.pdf", tt.printer); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("lpArgs(%q) = %v, want %v", tt.printer, got, tt.want)
+		}
+	}
+}
+
+func TestPrintPDFWithLP(t *testing.T) {
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	fakeLP := filepath.Join(dir, "lp")
+	script := "#!/bin/sh\necho \"$@\" > " + argsFile + "\n[ \"$2\" != offline ]\n"
+	if err := os.WriteFile(fakeLP, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	original := lpCommand
+	lpCommand = fakeLP
+	t.Cleanup(func() { lpCommand = original })
+
+	if err := PrintPDFWithLP("doc.pdf", "office"); err != nil {
+		t.Fatalf("PrintPDFWithLP: %v", err)
+	}
+	args, _ := os.ReadFile(argsFile)
+	abs, _ := filepath.Abs("doc.pdf")
+	if strings.TrimSpace(string(args)) != "-d office -- "+abs {
+		t.Errorf("lp args = %q", args)
+	}
+
+	if err := PrintPDFWithLP("doc.pdf", "offline"); err == nil {
+		t.Error("PrintPDFWithLP should fail when lp exits with an error")
+	}
+}
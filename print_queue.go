@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// errPrintQueueFull - 印刷キューが満杯
+	errPrintQueueFull = errors.New("print queue is full")
+	// errPrintQueueDraining - 停止・アップデートのため新しいジョブを受け付けていない
+	errPrintQueueDraining = errors.New("print queue is draining")
+	// errServicePaused - サービス一時停止中のため新しいジョブを受け付けていない
+	errServicePaused = errors.New("service is paused")
+)
+
+// printQueue - 印刷ジョブの同時実行数と待機数を制限するキュー
+type printQueue struct {
+	pending chan struct{} // 待機中＋実行中のジョブ（容量 = キューサイズ）
+	workers chan struct{} // 実行中のジョブ（容量 = 同時実行数）
+
+	mu               sync.Mutex // draining・paused と pending への投入を排他
+	draining         bool
+	paused           bool
+	queueWhilePaused bool          // 一時停止中も受け付けて再開まで待たせるか
+	resumed          chan struct{} // 一時停止中のみ有効。再開・停止処理で close
+}
+
+// newPrintQueue - 印刷キューを作成
+func newPrintQueue(maxConcurrent, queueSize int) *printQueue {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	if queueSize < maxConcurrent {
+		queueSize = maxConcurrent
+	}
+	return &printQueue{
+		pending: make(chan struct{}, queueSize),
+		workers: make(chan struct{}, maxConcurrent),
+	}
+}
+
+// Depth - 待機中＋実行中のジョブ数
+func (q *printQueue) Depth() int {
+	return len(q.pending)
+}
+
+// Submit - ジョブを投入し完了まで待機（満杯なら errPrintQueueFull、停止処理中なら errPrintQueueDraining）
+func (q *printQueue) Submit(ctx context.Context, job func() error) error {
+	q.mu.Lock()
+	if q.draining {
+		q.mu.Unlock()
+		return errPrintQueueDraining
+	}
+	if q.paused && !q.queueWhilePaused {
+		q.mu.Unlock()
+		return errServicePaused
+	}
+	select {
+	case q.pending <- struct{}{}:
+	default:
+		q.mu.Unlock()
+		return errPrintQueueFull
+	}
+	q.mu.Unlock()
+	printQueueDepth.Set(float64(q.Depth()))
+	defer func() {
+		<-q.pending
+		printQueueDepth.Set(float64(q.Depth()))
+	}()
+
+	for {
+		if err := q.waitResumed(ctx); err != nil {
+			return err
+		}
+		select {
+		case q.workers <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if !q.Paused() {
+			break
+		}
+		<-q.workers // 実行枠を待つ間に一時停止された
+	}
+	defer func() { <-q.workers }()
+
+	return job()
+}
+
+// waitResumed - 一時停止中なら再開まで待つ（再開されずに停止処理に入った場合は errPrintQueueDraining）
+func (q *printQueue) waitResumed(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.paused {
+		q.mu.Unlock()
+		return nil
+	}
+	resumed := q.resumed
+	q.mu.Unlock()
+
+	select {
+	case <-resumed:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if q.Draining() {
+		return errPrintQueueDraining
+	}
+	return nil
+}
+
+// Pause - 新しいジョブの開始を止める
+// queueJobs が true なら新しいジョブも受け付けて再開まで待たせ、false なら errServicePaused で拒否する
+func (q *printQueue) Pause(queueJobs bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.paused {
+		q.paused = true
+		q.resumed = make(chan struct{})
+	}
+	q.queueWhilePaused = queueJobs
+}
+
+// Resume - 一時停止を解除し、待機中のジョブを再開
+func (q *printQueue) Resume() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.paused {
+		q.paused = false
+		close(q.resumed)
+	}
+}
+
+// Paused - 一時停止中かどうか
+func (q *printQueue) Paused() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.paused
+}
+
+// Rejecting - 一時停止中で新しいジョブを拒否しているかどうか
+func (q *printQueue) Rejecting() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.paused && !q.queueWhilePaused
+}
+
+// Draining - 停止処理中かどうか
+func (q *printQueue) Draining() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.draining
+}
+
+// StopAccepting - 新しいジョブの受付を止める
+// 一時停止中に待たせているジョブは開始せずに errPrintQueueDraining で終了させる
+func (q *printQueue) StopAccepting() {
+	q.mu.Lock()
+	q.draining = true
+	if q.paused {
+		q.paused = false
+		close(q.resumed)
+	}
+	q.mu.Unlock()
+}
+
+// Drain - 新しいジョブの受付を止め、待機中・実行中のジョブがなくなるまで待つ
+func (q *printQueue) Drain(ctx context.Context) error {
+	q.StopAccepting()
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for q.Depth() > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %d print jobs still pending", ctx.Err(), q.Depth())
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// 印刷キュー（startHTTPServerで設定値に従って再作成）
+var jobQueue = newPrintQueue(1, 10)
+
+// printJob - 印刷キュー経由でPDFを印刷
+func printJob(ctx context.Context, pdfPath string, printerName string) error {
+	return jobQueue.Submit(ctx, func() error {
+		return printWithMetrics(pdfPath, printerName)
+	})
+}
+
+// printWithMetrics - 計測付きでPDFを印刷
+func printWithMetrics(pdfPath string, printerName string) error {
+	label := printerMetricLabel(printerName)
+	start := time.Now()
+	err := printPDF(pdfPath, printerName)
+	printDuration.Observe(time.Since(start).Seconds(), label)
+	if err != nil {
+		printJobsTotal.Inc(label, "failure")
+		printFailuresTotal.Inc(label)
+		return err
+	}
+	printJobsTotal.Inc(label, "success")
+	return nil
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPrintQueueRejectsWhenFull(t *testing.T) {
+	q := newPrintQueue(1, 2)
+
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.Submit(context.Background(), func() error {
+				<-release
+				return nil
+			})
+		}()
+	}
+
+	// 2件がキューに入るまで待機
+	deadline := time.Now().Add(time.Second)
+	for q.Depth() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	err := q.Submit(context.Background(), func() error { return nil })
+	if !errors.Is(err, errPrintQueueFull) {
+		t.Errorf("Submit() error = %v, expected errPrintQueueFull", err)
+	}
+
+	close(release)
+	wg.Wait()
+	if q.Depth() != 0 {
+		t.Errorf("Depth() = %d after completion, expected 0", q.Depth())
+	}
+}
+
+func TestPrintQueueLimitsConcurrency(t *testing.T) {
+	q := newPrintQueue(2, 10)
+
+	var running, maxRunning int32
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.Submit(context.Background(), func() error {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					m := atomic.LoadInt32(&maxRunning)
+					if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	if maxRunning > 2 {
+		t.Errorf("max concurrent jobs = %d, expected <= 2", maxRunning)
+	}
+}
+
+func TestPrintQueueCancelledWhileWaiting(t *testing.T) {
+	q := newPrintQueue(1, 5)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go q.Submit(context.Background(), func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ran := false
+	err := q.Submit(ctx, func() error {
+		ran = true
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || ran {
+		t.Errorf("Submit() error = %v, ran = %v; expected cancellation before running", err, ran)
+	}
+}
+
+func TestPrintWithMetricsRecordsFailures(t *testing.T) {
+	stubPrinter(t, func(pdfPath, printerName string) error {
+		return errors.New("paper jam")
+	})
+
+	before := printFailuresTotal.Value("Branch-Printer")
+	if err := printWithMetrics("dummy.pdf", "Branch-Printer"); err == nil {
+		t.Fatal("expected error")
+	}
+	if got := printFailuresTotal.Value("Branch-Printer"); got != before+1 {
+		t.Errorf("failures = %v, expected %v", got, before+1)
+	}
+}
+
+func TestPrintQueueDrain(t *testing.T) {
+	q := newPrintQueue(1, 5)
+
+	release := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- q.Submit(context.Background(), func() error {
+			<-release
+			return nil
+		})
+	}()
+	for q.Depth() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	drained := make(chan error, 1)
+	go func() { drained <- q.Drain(context.Background()) }()
+	for !q.Draining() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// 停止処理中は新しいジョブを受け付けない
+	if err := q.Submit(context.Background(), func() error { return nil }); !errors.Is(err, errPrintQueueDraining) {
+		t.Errorf("Submit() while draining error = %v, expected errPrintQueueDraining", err)
+	}
+
+	select {
+	case <-drained:
+		t.Fatal("Drain returned while a job was still running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-drained; err != nil {
+		t.Errorf("Drain() error = %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("running job error = %v", err)
+	}
+}
+
+func TestPrintQueueDrainTimeout(t *testing.T) {
+	q := newPrintQueue(1, 5)
+
+	release := make(chan struct{})
+	defer close(release)
+	go q.Submit(context.Background(), func() error {
+		<-release
+		return nil
+	})
+	for q.Depth() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := q.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Drain() error = %v, expected deadline exceeded", err)
+	}
+}
+
+func TestPrintQueuePauseRejects(t *testing.T) {
+	q := newPrintQueue(1, 5)
+	q.Pause(false)
+	if !q.Paused() || !q.Rejecting() {
+		t.Fatal("queue should be paused and rejecting")
+	}
+	if err := q.Submit(context.Background(), func() error { return nil }); !errors.Is(err, errServicePaused) {
+		t.Errorf("Submit() error = %v, expected errServicePaused", err)
+	}
+
+	q.Resume()
+	if err := q.Submit(context.Background(), func() error { return nil }); err != nil {
+		t.Errorf("Submit() after Resume error = %v", err)
+	}
+}
+
+func TestPrintQueuePauseQueuesUntilResume(t *testing.T) {
+	q := newPrintQueue(1, 5)
+	q.Pause(true)
+
+	var ran int32
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			done <- q.Submit(context.Background(), func() error {
+				atomic.AddInt32(&ran, 1)
+				return nil
+			})
+		}()
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for q.Depth() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := atomic.LoadInt32(&ran); n != 0 {
+		t.Fatalf("%d jobs ran while paused", n)
+	}
+
+	q.Resume()
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Errorf("Submit() error = %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&ran); n != 2 {
+		t.Errorf("%d jobs ran after Resume, expected 2", n)
+	}
+}
+
+func TestPrintQueueDrainWhilePaused(t *testing.T) {
+	q := newPrintQueue(1, 5)
+	q.Pause(true)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- q.Submit(context.Background(), func() error {
+			t.Error("queued job must not start after the queue was drained")
+			return nil
+		})
+	}()
+	deadline := time.Now().Add(time.Second)
+	for q.Depth() < 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := q.Drain(ctx); err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+	if err := <-done; !errors.Is(err, errPrintQueueDraining) {
+		t.Errorf("Submit() error = %v, expected errPrintQueueDraining", err)
+	}
+}
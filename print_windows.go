@@ -0,0 +1,90 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// printPDF - 実際の印刷処理（テストで差し替え可能）
+var printPDF = PrintPDFWithSumatra
+
+// PrintPDFWithSumatra - SumatraPDFを使用してPDFを印刷
+func PrintPDFWithSumatra(pdfPath string, printerName string) error {
+	// SumatraPDFの実行ファイルパスを取得
+	sumatraPath, err := getSumatraPDFPath()
+	if err != nil {
+		return fmt.Errorf("SumatraPDF実行ファイルが見つかりません: %v", err)
+	}
+
+	// PDFファイルの絶対パスを取得
+	absPath, err := filepath.Abs(pdfPath)
+	if err != nil {
+		return fmt.Errorf("PDFファイルの絶対パス取得エラー: %v", err)
+	}
+
+	// SumatraPDFコマンドを構築
+	var cmd *exec.Cmd
+	if printerName != "" {
+		// 特定のプリンターに印刷
+		cmd = exec.Command(sumatraPath, "-print-to", printerName, absPath)
+	} else {
+		// デフォルトプリンターに印刷
+		cmd = exec.Command(sumatraPath, "-print-to-default", absPath)
+	}
+
+	fmt.Printf("SumatraPDFで印刷中: %s\n", absPath)
+	if printerName != "" {
+		fmt.Printf("プリンター: %s\n", printerName)
+	} else {
+		fmt.Println("デフォルトプリンターに印刷")
+	}
+
+	// コマンド実行
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("印刷エラー: %v, 出力: %s", err, string(output))
+	}
+
+	fmt.Println("印刷が正常に実行されました")
+	return nil
+}
+
+// getSumatraPDFPath - SumatraPDFの実行ファイルパスを取得
+func getSumatraPDFPath() (string, error) {
+	// 複数の場所でSumatraPDFを探す
+	searchPaths := []string{
+		".",    // 現在のディレクトリ
+		"C:\\", // Cドライブルート（サービス実行時用）
+	}
+
+	candidates := []string{
+		"SumatraPDF-3.5.2-64.exe",
+		"SumatraPDF.exe",
+	}
+
+	for _, searchPath := range searchPaths {
+		for _, candidate := range candidates {
+			// パスを結合
+			fullPath := filepath.Join(searchPath, candidate)
+			// 絶対パスに変換
+			absPath, err := filepath.Abs(fullPath)
+			if err != nil {
+				continue
+			}
+			if _, err := os.Stat(absPath); err == nil {
+				return absPath, nil
+			}
+		}
+	}
+
+	// システムPATHでSumatraPDFを探す
+	if path, err := exec.LookPath("SumatraPDF.exe"); err == nil {
+		return path, nil
+	}
+
+	return "", fmt.Errorf("SumatraPDF実行ファイルが見つかりません")
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 備考欄
+// Item.Description と、帳票から自動で作る備考（旅費明細の印字漏れの警告・出張日数）を備考欄に印字する
+
+// RemarksConfig - 自動で備考に追加する内容
+type RemarksConfig struct {
+	Truncation bool `json:"truncation"` // 旅費明細が欄に収まらず印字できなかった件数を追記
+	TripDays   bool `json:"tripDays"`   // 出発日・帰着日から出張日数を追記
+}
+
+// ryohiPrintSummary - 旅費明細の印字結果
+type ryohiPrintSummary struct {
+	Total   int // 旅費明細の件数
+	Omitted int // 欄に収まらず、一部または全部を印字できなかった件数
+}
+
+// remarksBox - 備考欄の文字を収める枠（「備考」の見出しの右、枠線の内側）
+var remarksBox = fitBox{X: 20, Y: 120, W: 134, H: 17.5}
+
+// remarksStyle - 備考の文字サイズ（長い場合は6ptまで縮小し、収まらない分は省略）
+var remarksStyle = fitStyle{Align: alignLeft, MinSize: 6, MaxSize: 9}
+
+// buildRemarks - 備考欄に印字する文章（Description の後に自動の備考を1行ずつ追加）
+func buildRemarks(item Item, summary ryohiPrintSummary, cfg RemarksConfig) string {
+	var lines []string
+	if item.Description != nil {
+		if desc := strings.TrimSpace(*item.Description); desc != "" {
+			lines = append(lines, desc)
+		}
+	}
+	if cfg.Truncation && summary.Omitted > 0 {
+		lines = append(lines, fmt.Sprintf("※旅費明細%d件のうち%d件は欄に収まらないため印字していません", summary.Total, summary.Omitted))
+	}
+	if cfg.TripDays {
+		if s := tripDaysRemark(item); s != "" {
+			lines = append(lines, s)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// tripDaysRemark - 出発日から帰着日までの日数（どちらかが解析できない、または帰着日が前の場合は空）
+func tripDaysRemark(item Item) string {
+	if item.StartDate == nil || item.EndDate == nil {
+		return ""
+	}
+	start, err := parseItemDate(*item.StartDate)
+	if err != nil {
+		return ""
+	}
+	end, err := parseItemDate(*item.EndDate)
+	if err != nil {
+		return ""
+	}
+	// 時刻や夏時間の影響を受けないよう、暦の日付どうしで数える
+	days := int(calendarDate(end).Sub(calendarDate(start)).Hours()/24) + 1
+	if days < 1 {
+		return ""
+	}
+	if days == 1 {
+		return fmt.Sprintf("※出張日数 1日（%s 日帰り）", formatDate(start, DateStyleMD))
+	}
+	return fmt.Sprintf("※出張日数 %d日（%s〜%s）", days, formatDate(start, DateStyleMD), formatDate(end, DateStyleMD))
+}
+
+// calendarDate - 日付だけを残した日時（UTC の0時）
+func calendarDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBuildRemarks(t *testing.T) {
+	trip := Item{StartDate: StringPtr("2025-01-06"), EndDate: StringPtr("2025-01-08"), Description: StringPtr("  会議資料は別添  ")}
+	tests := []struct {
+		name    string
+		item    Item
+		summary ryohiPrintSummary
+		cfg     RemarksConfig
+		want    string
+	}{
+		{name: "なし", item: Item{}, cfg: RemarksConfig{Truncation: true, TripDays: true}, want: ""},
+		{name: "説明のみ", item: trip, cfg: RemarksConfig{}, want: "会議資料は別添"},
+		{
+			name:    "印字漏れの警告",
+			item:    Item{},
+			summary: ryohiPrintSummary{Total: 16, Omitted: 2},
+			cfg:     RemarksConfig{Truncation: true},
+			want:    "※旅費明細16件のうち2件は欄に収まらないため印字していません",
+		},
+		{name: "警告を無効化", item: Item{}, summary: ryohiPrintSummary{Total: 16, Omitted: 2}, cfg: RemarksConfig{}, want: ""},
+		{
+			name:    "説明・警告・出張日数",
+			item:    trip,
+			summary: ryohiPrintSummary{Total: 15, Omitted: 1},
+			cfg:     RemarksConfig{Truncation: true, TripDays: true},
+			want:    "会議資料は別添\n※旅費明細15件のうち1件は欄に収まらないため印字していません\n※出張日数 3日（1月6日〜1月8日）",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildRemarks(tt.item, tt.summary, tt.cfg); got != tt.want {
+				t.Errorf("buildRemarks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTripDaysRemark(t *testing.T) {
+	tests := []struct {
+		start, end string
+		want       string
+	}{
+		{"2025-01-06", "2025-01-06", "※出張日数 1日（1月6日 日帰り）"},
+		{"2024-12-30", "2025-01-02", "※出張日数 4日（12月30日〜1月2日）"},
+		{"2024-02-28", "2024-03-01", "※出張日数 3日（2月28日〜3月1日）"},
+		{"2025-01-06T23:00:00+09:00", "2025-01-07T01:00:00+09:00", "※出張日数 2日（1月6日〜1月7日）"},
+		{"2025-01-08", "2025-01-06", ""}, // 帰着日が出発日より前
+		{"2025-01-06", "someday", ""},
+	}
+	for _, tt := range tests {
+		item := Item{StartDate: StringPtr(tt.start), EndDate: StringPtr(tt.end)}
+		if got := tripDaysRemark(item); got != tt.want {
+			t.Errorf("tripDaysRemark(%s, %s) = %q, want %q", tt.start, tt.end, got, tt.want)
+		}
+	}
+	if got := tripDaysRemark(Item{StartDate: StringPtr("2025-01-06")}); got != "" {
+		t.Errorf("tripDaysRemark() without end date = %q, want empty", got)
+	}
+}
+
+func TestPrintRyohiItemsSummary(t *testing.T) {
+	oneRow := func(n int) []Ryohi {
+		list := make([]Ryohi, n)
+		for i := range list {
+			list[i] = Ryohi{Date: StringPtr("2025-01-06"), Dest: StringPtr("福岡")}
+		}
+		return list
+	}
+	longDetail := Ryohi{Date: StringPtr("2025-01-07"), Detail: []string{strings.Repeat("会議資料の作成", 8)}} // 摘要が4行以上に折り返される
+
+	tests := []struct {
+		name  string
+		ryohi []Ryohi
+		want  ryohiPrintSummary
+	}{
+		{name: "収まる", ryohi: oneRow(14), want: ryohiPrintSummary{Total: 14}},
+		{name: "14行を超えた明細", ryohi: oneRow(16), want: ryohiPrintSummary{Total: 16, Omitted: 2}},
+		{name: "途中で切れた明細", ryohi: append(oneRow(12), longDetail), want: ryohiPrintSummary{Total: 13, Omitted: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newReportLabStylePdfClient()
+			c.pdf.AddPage()
+			if got := c.printRyohiItems(tt.ryohi); got != tt.want {
+				t.Errorf("printRyohiItems() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderPDFWithRemarks(t *testing.T) {
+	items := []Item{{
+		Name:        "山田　太郎",
+		Description: StringPtr(strings.Repeat("備考の長い説明文です。", 30)),
+		Ryohi:       make([]Ryohi, 20),
+	}}
+	for i := range items[0].Ryohi {
+		items[0].Ryohi[i] = Ryohi{Dest: StringPtr("福岡")}
+	}
+	var buf bytes.Buffer
+	if _, err := RenderPDF(&buf, "", items); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Error("output is not a PDF")
+	}
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"time"
 
@@ -22,12 +21,12 @@ type ReportLabStylePdfClient struct {
 	col1, col2, col3, col4, col5 float64
 	rowH1                        float64
 	tblTY                        float64
+	// 日付の書式
+	dates DateStyles
 }
 
-// NewReportLabStylePdfClient - ReportLabスタイルのPDFクライアントを作成
-func NewReportLabStylePdfClient(data []Item) *ReportLabStylePdfClient {
-	fmt.Println("Creating ReportLab Style PDF client...")
-
+// newReportLabStylePdfClient - レイアウトとフォントを設定したクライアントを作成（描画はしない）
+func newReportLabStylePdfClient() *ReportLabStylePdfClient {
 	// A5横向きでPDFを初期化 (210mm x 148mm)
 	pdf := gofpdf.New("L", "mm", "A5", "")
 	
@@ -38,6 +37,7 @@ func NewReportLabStylePdfClient(data []Item) *ReportLabStylePdfClient {
 		pdf:       pdf,
 		pageSizeX: 210, // A5横向きの幅
 		pageSizeY: 148, // A5横向きの高さ
+		dates:     templateDateStyles(defaultTemplateID),
 	}
 
 	// マージンを設定 (ReportLabの座標系に合わせて)
@@ -55,79 +55,81 @@ func NewReportLabStylePdfClient(data []Item) *ReportLabStylePdfClient {
 	client.rowH1 = 15.0
 	client.tblTY = 95.0 // 基本情報テーブルの位置
 
-	// Windowsフォントを設定
-	if err := client.setupWindowsFont(); err != nil {
-		fmt.Printf("Windowsフォント設定エラー: %v\n", err)
-		fmt.Println("標準フォントで継続...")
+	// 日本語フォントを設定
+	if err := client.setupJapaneseFont(); err != nil {
+		writeEventLog("WARN", fmt.Sprintf("日本語フォント設定エラー: %v（標準フォントで継続）", err))
 	}
+	return client
+}
 
-	filePath := "travel_expense_reportlab_style.pdf"
-	fmt.Println("ReportLab Style output file:", filePath)
-
+// render - アイテムごとに1ページ描画（透かしの指定があれば各ページの最後に重ねる）
+func (c *ReportLabStylePdfClient) render(data []Item, opts RenderOptions) {
 	// アイテム数に基づいてページを明示的に制御
 	expectedPages := len(data)
 	
 	// 各アイテムを処理
 	for index, item := range data {
-		pdf.AddPage()
-		fmt.Printf("Processing item %d/%d (Page: %d)\n", index+1, len(data), pdf.PageNo())
+		c.pdf.AddPage()
+		writeEventLog("DEBUG", fmt.Sprintf("Processing item %d/%d (Page: %d)", index+1, len(data), c.pdf.PageNo()))
 
-		client.drawLine()
-		client.printItem(item)
+		c.drawLine()
+		c.printItem(item)
+		if opts.Watermark != nil {
+			c.drawWatermark(*opts.Watermark)
+		}
 	}
 	
-	// 期待ページ数と実際のページ数を比較
-	actualPages := pdf.PageNo()
-	fmt.Printf("Expected pages: %d, Actual pages: %d\n", expectedPages, actualPages)
-	
 	// 余分なページがある場合の警告（デバッグ用）
-	if actualPages != expectedPages {
-		fmt.Printf("警告: 期待ページ数(%d)と実際のページ数(%d)が一致しません\n", expectedPages, actualPages)
+	if actualPages := c.pdf.PageNo(); actualPages != expectedPages {
+		writeEventLog("WARN", fmt.Sprintf("期待ページ数(%d)と実際のページ数(%d)が一致しません", expectedPages, actualPages))
 	}
+}
 
-	// PDFを保存
-	err := pdf.OutputFileAndClose(filePath)
+// NewReportLabStylePdfClient - ReportLabスタイルのPDFを生成して travel_expense_reportlab_style.pdf に保存
+// 保存に失敗した場合は nil
+func NewReportLabStylePdfClient(data []Item) *ReportLabStylePdfClient {
+	return NewReportLabStylePdfClientWithOptions(data, RenderOptions{})
+}
+
+// NewReportLabStylePdfClientWithOptions - 描画オプション（透かしなど）を指定してPDFを生成・保存
+func NewReportLabStylePdfClientWithOptions(data []Item, opts RenderOptions) *ReportLabStylePdfClient {
+	renderStart := time.Now()
+	client := newReportLabStylePdfClient()
+	client.render(data, opts)
+
+	filePath := "travel_expense_reportlab_style.pdf"
+	file, err := os.Create(filePath)
 	if err != nil {
-		fmt.Printf("Error saving ReportLab Style PDF: %v\n", err)
+		writeEventLog("ERROR", fmt.Sprintf("PDFファイル作成エラー: %v", err))
+		observeRender(time.Since(renderStart), 0, 0, err)
+		return nil
+	}
+	size, err := writePDF(file, client.pdf, renderStart)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		writeEventLog("ERROR", fmt.Sprintf("PDF保存エラー: %v", err))
 		return nil
-	} else {
-		fmt.Println("ReportLab Style PDF saved successfully!")
-
-		if fileInfo, err := os.Stat(filePath); err == nil {
-			fmt.Printf("File size: %d bytes\n", fileInfo.Size())
-			if fileInfo.Size() > 1000 {
-				fmt.Println("PDF appears to have proper content!")
-			}
-		}
 	}
+	writeEventLog("DEBUG", fmt.Sprintf("PDFを保存しました: %s (%d bytes)", filePath, size))
 
 	return client
 }
 
-// setupWindowsFont - Windowsフォントを設定
-func (c *ReportLabStylePdfClient) setupWindowsFont() error {
-	fmt.Println("Setting up Windows standard fonts for ReportLab style...")
-
-	windowsFonts := []struct {
-		name string
-		path string
-	}{
-		{"yumin", "C:/Windows/Fonts/yumin.ttf"},
-		{"yugothm", "C:/Windows/Fonts/yugothm.ttf"},
-		{"meiryo", "C:/Windows/Fonts/meiryo.ttf"},
-	}
-
-	for _, font := range windowsFonts {
+// setupJapaneseFont - OSの日本語フォントを設定（候補は fonts_<os>.go）
+func (c *ReportLabStylePdfClient) setupJapaneseFont() error {
+	for _, font := range japaneseFonts {
 		if _, err := os.Stat(font.path); err == nil {
-			fmt.Printf("Found Windows font: %s at %s\n", font.name, font.path)
-			c.pdf.AddUTF8Font(font.name, "", font.path)
-			fmt.Printf("Successfully added Windows font: %s\n", font.name)
+			// gofpdf はフォントの場所を基準にパスを結合するため、ディレクトリとファイル名に分けて登録
+			c.pdf.SetFontLocation(filepath.Dir(font.path))
+			c.pdf.AddUTF8Font(font.name, "", filepath.Base(font.path))
+			writeEventLog("DEBUG", fmt.Sprintf("日本語フォントを登録: %s (%s)", font.name, font.path))
 			return nil
 		}
 	}
 
-	fmt.Println("Windows標準フォントが見つからない、標準フォントを使用...")
-	return fmt.Errorf("no Windows fonts found")
+	return fmt.Errorf("no Japanese fonts found")
 }
 
 // truncateText - テキストを指定された文字数で切り詰める
@@ -176,35 +178,6 @@ func (c *ReportLabStylePdfClient) drawLine() {
 	c.drawSummaryTable()
 }
 
-// drawApprovalTable - 承認テーブルを描画
-// drawApprovalTable - 承認テーブルを描画
-func (c *ReportLabStylePdfClient) drawApprovalTable() {
-	startX := 155.0 // 3列×15mm
-	startY := 25.0  // 上から40mm下
-	colWidth := 15.0
-	rowHeight1 := 5.0
-	rowHeight2 := 15.0
-
-	// テーブル用の細い線幅を設定
-	c.pdf.SetLineWidth(0.2)
-
-	// ヘッダー行
-	c.pdf.SetFont("yumin", "", 9)
-	headers := []string{"社　長", "会　計", "所　属"}
-	for i, header := range headers {
-		x := startX + float64(i)*colWidth
-		c.pdf.Rect(x, startY, colWidth, rowHeight1, "D")
-		textWidth := c.pdf.GetStringWidth(header)
-		c.pdf.Text(x+(colWidth-textWidth)/2, startY+4, header)
-	}
-
-	// データ行（空）
-	for i := 0; i < 3; i++ {
-		x := startX + float64(i)*colWidth
-		c.pdf.Rect(x, startY+rowHeight1, colWidth, rowHeight2, "D")
-	}
-}
-
 func (c *ReportLabStylePdfClient) drawBasedata(item Item) {
 	startX := 10.0
 	startY := 15.0
@@ -220,17 +193,16 @@ func (c *ReportLabStylePdfClient) drawBasedata(item Item) {
 	// 精算日
 	if item.PayDay != nil {
 		c.pdf.SetFont("yumin", "", 9)
-		if t, err := time.Parse("2006-01-02", *item.PayDay); err == nil {
-			payDay := t.Format("清算日　2006年 01月 02日")
-			c.pdf.Text(startX+100, startY+5, payDay)
+		if t, err := parseItemDate(*item.PayDay); err == nil {
+			payDay := "清算日　" + formatDate(t, c.dates.PayDay)
+			c.fitText(fitBox{startX + 100, startY + 0.9, 44, 6}, payDay, fitStyle{Align: alignLeft, MinSize: 6, MaxSize: 9}, "精算日")
 		}
 	}
 
 	// 所属（右上）
 	if item.Office != nil {
 		c.pdf.SetFont("yumin", "", 10)
-		textWidth := c.pdf.GetStringWidth(*item.Office)
-		c.pdf.Text(startX+190-textWidth-2, startY+5, *item.Office)
+		c.fitText(fitBox{startX + 145, startY + 0.8, 43, 6}, *item.Office, fitStyle{Align: alignRight, MinSize: 6, MaxSize: 10}, "所属")
 	}
 
 }
@@ -343,53 +315,94 @@ func (c *ReportLabStylePdfClient) printItem(item Item) {
 	startX := 14.0
 	startY := 36.8
 
+	// 基本情報テーブルの各欄（ヘッダーの下、枠線の内側1mm）
+	dateStyle := fitStyle{Align: alignLeft, MinSize: 8, MaxSize: 10}
+	fieldStyle := fitStyle{Align: alignLeft, MinSize: 6, MaxSize: 10}
+	c.pdf.SetFont("yumin", "", 10)
+
 	if item.StartDate != nil {
-		c.pdf.SetFont("yumin", "", 10)
-		if t, err := time.Parse("2006-01-02", *item.StartDate); err == nil {
-			startDate := t.Format("01　 02")
-			c.pdf.Text(startX, startY, startDate)
+		if t, err := parseItemDate(*item.StartDate); err == nil {
+			startDate := formatDate(t, c.dates.Trip)
+			c.fitText(fitBox{startX, startY - 3.2, 27, 4}, startDate, dateStyle, "出発日")
 		}
 	}
 
 	// 帰着日
 	if item.EndDate != nil {
-		c.pdf.SetFont("yumin", "", 10)
-		if t, err := time.Parse("2006-01-02", *item.EndDate); err == nil {
-			endDate := t.Format("01　 02")
-			c.pdf.Text(startX, startY+7, endDate)
+		if t, err := parseItemDate(*item.EndDate); err == nil {
+			endDate := formatDate(t, c.dates.Trip)
+			c.fitText(fitBox{startX, startY + 3.8, 27, 4}, endDate, dateStyle, "帰着日")
 		}
 	}
 
 	// 出張目的
 	if item.Purpose != nil {
-		c.pdf.SetFont("yumin", "", 10)
-		c.pdf.Text(startX+32, startY+7, *item.Purpose)
+		c.fitText(fitBox{startX + 28, startY + 1.2, 23, 7}, *item.Purpose, fieldStyle, "出張目的")
 	}
 
 	// 車両
 	if item.Car != "" {
-		c.pdf.SetFont("yumin", "", 10)
-		c.pdf.Text(startX+52, startY+7, item.Car)
+		c.fitText(fitBox{startX + 53, startY + 1.2, 26.75, 7}, item.Car, fieldStyle, "車両No.")
 	}
 
 	// 氏名
 	if item.Name != "" {
-		c.pdf.SetFont("yumin", "", 10)
-		c.pdf.Text(startX+85, startY+7, item.Name)
+		c.fitText(fitBox{startX + 81.75, startY + 1.2, 28, 7}, item.Name, fieldStyle, "氏名")
 	}
 
 	// 合計金額（上部の計欄）
 	c.pdf.SetFont("yumin", "", 12)
 	priceStr := FormatPrice(item.Price)
-	textWidth := c.pdf.GetStringWidth(priceStr)
-	c.pdf.Text(c.rX-textWidth-5, c.tY-12, priceStr)
+	tax, hasTax := itemTax(item, currentConfig().PDF.Tax)
+	if hasTax {
+		priceStr = tax.Inclusive.String()
+	}
+	c.fitText(fitBox{c.rX - 43, c.tY - 17.5, 38, 8}, priceStr, fitStyle{Align: alignRight, MinSize: 8, MaxSize: 12}, "合計金額")
+	if hasTax {
+		c.printTaxBreakdown(tax)
+	}
 
 	// 旅費データを処理
-	c.printRyohiItems(item.Ryohi)
+	summary := c.printRyohiItems(item.Ryohi)
+
+	// 備考
+	c.printRemarks(item, summary)
+
+	// 承認欄
+	c.printApprovals(item.Approvals)
+
+	// 元の記録へのQRコード・バーコード
+	c.printBarcode(item)
+}
+
+// printRemarks - 備考欄に Description と自動の備考を印字
+func (c *ReportLabStylePdfClient) printRemarks(item Item, summary ryohiPrintSummary) {
+	cfg := currentConfig().PDF
+	remarks := buildRemarks(item, summary, cfg.Remarks)
+	if remarks == "" {
+		return
+	}
+	box := remarksBox
+	if cfg.Barcode.inRemarks() {
+		box.W = barcodeRemarksBox.X - box.X - 1 // QRコードと重ならないよう狭くする
+	}
+	c.pdf.SetFont("yumin", "", remarksStyle.MaxSize)
+	c.fitText(box, remarks, remarksStyle, "備考")
 }
 
-// printRyohiItems - 旅費データを印刷
-func (c *ReportLabStylePdfClient) printRyohiItems(ryohiList []Ryohi) {
+// printTaxBreakdown - 計欄の合計金額の下に税抜金額と消費税を印字
+func (c *ReportLabStylePdfClient) printTaxBreakdown(tax TaxBreakdown) {
+	style := fitStyle{Align: alignRight, MinSize: 6, MaxSize: 8}
+	c.fitText(fitBox{c.rX - 43, c.tY - 9.5, 38, 4.3}, "税抜 "+tax.Exclusive.String(), style, "税抜金額")
+	label := "消費税 " + tax.Tax.String()
+	if tax.HasRate {
+		label = fmt.Sprintf("消費税(%s) %s", tax.Rate, tax.Tax)
+	}
+	c.fitText(fitBox{c.rX - 43, c.tY - 5.2, 38, 4.3}, label, style, "消費税")
+}
+
+// printRyohiItems - 旅費データを印刷（欄に収まらなかった件数を返す）
+func (c *ReportLabStylePdfClient) printRyohiItems(ryohiList []Ryohi) ryohiPrintSummary {
 	startX := 10.0
 	startY := 47.0 // メインテーブルのヘッダー下から開始
 	colWidths := []float64{10, 17, 40, 30, 15, 15, 15, 25, 23}
@@ -397,14 +410,28 @@ func (c *ReportLabStylePdfClient) printRyohiItems(ryohiList []Ryohi) {
 
 	c.pdf.SetFont("yumin", "", 10)
 
+	// 摘要・区間は列の幅（mm）に収まるよう、それぞれの文字サイズで測って折り返す
+	layout, defaults := currentConfig().PDF, defaultConfig().PDF
+	detailCol := layout.Detail.withDefaults(defaults.Detail)
+	kukanCol := layout.Kukan.withDefaults(defaults.Kukan)
+
+	// 日付・行先・金額は枠に収まるよう縮小・折り返し
+	centerStyle := fitStyle{Align: alignCenter, MinSize: 6, MaxSize: 10}
+	rightStyle := fitStyle{Align: alignRight, MinSize: 6, MaxSize: 10}
+
+	summary := ryohiPrintSummary{Total: len(ryohiList)}
 	currentRow := 0
 	for i, ryohi := range ryohiList {
 		if currentRow >= 14 { // 最大14行まで表示
+			summary.Omitted += len(ryohiList) - i
 			break
 		}
 
-		// 旅費データを印刷用に準備（摘要10文字、区間22文字制限）
-		printData := prepareRyohiForPrint(ryohi, 10, 22)
+		// 旅費データを印刷用に準備（日付はテンプレートの書式で整形）
+		printData := prepareRyohiForPrintWidth(ryohi,
+			fontSizeMeasurer{c.pdf, detailCol.FontSize}, detailCol,
+			fontSizeMeasurer{c.pdf, kukanCol.FontSize}, kukanCol, c.dates.Row)
+		logCellDiagnostics(i, currentRow, printData.Diagnostics)
 
 		// 残り行数をチェック（14行まで対応）
 		remainingRows := 14 - currentRow
@@ -432,34 +459,34 @@ func (c *ReportLabStylePdfClient) printRyohiItems(ryohiList []Ryohi) {
 			currentY := startY + float64(physicalRow)*rowHeight + yOffset
 			currentX := startX
 
+			// 表の半行（5mm）の枠
+			cellY := currentY + 2
+			cell := func(x, w float64) fitBox { return fitBox{x, cellY, w, 5} }
+
 			// 日付
 			if row < len(printData.DateLines) && printData.DateLines[row] != "" {
-				date := printData.DateLines[row]
 				c.pdf.SetFont("yumin", "", 10)
-				textWidth := c.pdf.GetStringWidth(date)
-				c.pdf.Text(currentX+(colWidths[0]-textWidth)/2, currentY+6, date)
+				c.fitText(cell(currentX+0.5, colWidths[0]-1), printData.DateLines[row], centerStyle, "日付")
 			}
 			currentX += colWidths[0]
 
 			// 行先
 			if row < len(printData.DestLines) && printData.DestLines[row] != "" {
-				dest := printData.DestLines[row]
-				textWidth := c.pdf.GetStringWidth(dest)
-				c.pdf.Text(currentX+(colWidths[1]-textWidth)/2, currentY+6, dest)
+				c.fitText(cell(currentX+0.5, colWidths[1]-1), printData.DestLines[row], centerStyle, "行先")
 			}
 			currentX += colWidths[1]
 
-			// 摘要
+			// 摘要（折り返し済みの行を、折り返しで決めた文字サイズで描画）
 			if row < len(printData.DetailLines) && printData.DetailLines[row] != "" {
-				detail := printData.DetailLines[row]
-				c.pdf.Text(currentX+1, currentY+6, detail)
+				size := lineFontSize(printData.DetailSizes, row, detailCol.FontSize)
+				c.fitText(cell(currentX+1, detailCol.WidthMM), printData.DetailLines[row], fitStyle{Align: alignLeft, MinSize: detailCol.MinFontSize, MaxSize: size}, "摘要")
 			}
 			currentX += colWidths[2]
 
 			// 区間
 			if row < len(printData.KukanLines) && printData.KukanLines[row] != "" {
-				kukan := printData.KukanLines[row]
-				c.pdf.Text(currentX+1, currentY+6, kukan)
+				size := lineFontSize(printData.KukanSizes, row, kukanCol.FontSize)
+				c.fitText(cell(currentX+1, kukanCol.WidthMM), printData.KukanLines[row], fitStyle{Align: alignLeft, MinSize: kukanCol.MinFontSize, MaxSize: size}, "区間")
 			}
 			currentX += colWidths[3]
 
@@ -474,103 +501,38 @@ func (c *ReportLabStylePdfClient) printRyohiItems(ryohiList []Ryohi) {
 
 			// 旅費日当
 			if row < len(printData.PriceLines) && printData.PriceLines[row] != "" {
-				priceStr := printData.PriceLines[row]
-				textWidth := c.pdf.GetStringWidth(priceStr)
-				c.pdf.Text(currentX+colWidths[7]-textWidth-1, currentY+6, priceStr)
+				c.fitText(cell(currentX+1, colWidths[7]-2), printData.PriceLines[row], rightStyle, "旅費日当")
 			}
 			currentX += colWidths[7]
 
 			// 計
 			if row < len(printData.VolLines) && printData.VolLines[row] != "" {
-				volStr := printData.VolLines[row]
-				textWidth := c.pdf.GetStringWidth(volStr)
-				c.pdf.Text(currentX+colWidths[8]-textWidth-1, currentY+6, volStr)
+				c.fitText(cell(currentX+1, colWidths[8]-2), printData.VolLines[row], rightStyle, "計")
 			}
 
 			drawnRows++ // 実際に描画した行数をインクリメント
 		}
 
 		currentRow += drawnRows // 実際に描画した行数分だけ進める
+		if drawnRows < printData.contentRows() {
+			summary.Omitted++
+		}
 
 		// デバッグ情報
-		fmt.Printf("旅費項目 %d: 最大行数=%d, 実際印刷行数=%d, 現在行=%d\n",
-			i+1, printData.MaxRows, drawnRows, currentRow)
-	}
-}
-
-// PrintPDFWithSumatra - SumatraPDFを使用してPDFを印刷
-func PrintPDFWithSumatra(pdfPath string, printerName string) error {
-	// SumatraPDFの実行ファイルパスを取得
-	sumatraPath, err := getSumatraPDFPath()
-	if err != nil {
-		return fmt.Errorf("SumatraPDF実行ファイルが見つかりません: %v", err)
-	}
-
-	// PDFファイルの絶対パスを取得
-	absPath, err := filepath.Abs(pdfPath)
-	if err != nil {
-		return fmt.Errorf("PDFファイルの絶対パス取得エラー: %v", err)
-	}
-
-	// SumatraPDFコマンドを構築
-	var cmd *exec.Cmd
-	if printerName != "" {
-		// 特定のプリンターに印刷
-		cmd = exec.Command(sumatraPath, "-print-to", printerName, absPath)
-	} else {
-		// デフォルトプリンターに印刷
-		cmd = exec.Command(sumatraPath, "-print-to-default", absPath)
+		writeEventLog("DEBUG", fmt.Sprintf("旅費項目 %d: 最大行数=%d, 実際印刷行数=%d, 現在行=%d",
+			i+1, printData.MaxRows, drawnRows, currentRow))
 	}
 
-	fmt.Printf("SumatraPDFで印刷中: %s\n", absPath)
-	if printerName != "" {
-		fmt.Printf("プリンター: %s\n", printerName)
-	} else {
-		fmt.Println("デフォルトプリンターに印刷")
+	if summary.Omitted > 0 {
+		writeEventLog("WARN", fmt.Sprintf("旅費明細が14行を超えたため、%d件中%d件を印字できませんでした", summary.Total, summary.Omitted))
 	}
-
-	// コマンド実行
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("印刷エラー: %v, 出力: %s", err, string(output))
-	}
-
-	fmt.Println("印刷が正常に実行されました")
-	return nil
+	return summary
 }
 
-// getSumatraPDFPath - SumatraPDFの実行ファイルパスを取得
-func getSumatraPDFPath() (string, error) {
-	// 複数の場所でSumatraPDFを探す
-	searchPaths := []string{
-		".", // 現在のディレクトリ
-		"C:\\", // Cドライブルート（サービス実行時用）
+// logCellDiagnostics - 列幅に収まらず折り返し・縮小したセルを記録（index: 旅費項目、startRow: 項目の先頭の行）
+func logCellDiagnostics(index, startRow int, diags []CellDiagnostic) {
+	for _, d := range diags {
+		d.Row += startRow
+		writeEventLog("WARN", fmt.Sprintf("旅費項目 %d: %s", index+1, d))
 	}
-	
-	candidates := []string{
-		"SumatraPDF-3.5.2-64.exe",
-		"SumatraPDF.exe",
-	}
-
-	for _, searchPath := range searchPaths {
-		for _, candidate := range candidates {
-			// パスを結合
-			fullPath := filepath.Join(searchPath, candidate)
-			// 絶対パスに変換
-			absPath, err := filepath.Abs(fullPath)
-			if err != nil {
-				continue
-			}
-			if _, err := os.Stat(absPath); err == nil {
-				return absPath, nil
-			}
-		}
-	}
-
-	// システムPATHでSumatraPDFを探す
-	if path, err := exec.LookPath("SumatraPDF.exe"); err == nil {
-		return path, nil
-	}
-
-	return "", fmt.Errorf("SumatraPDF実行ファイルが見つかりません")
 }
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+)
+
+// apiRoute - HTTPルート定義（OpenAPIドキュメントと契約テストで共有）
+type apiRoute struct {
+	Path      string
+	Methods   []string // OPTIONS（CORSプリフライト）は含めない
+	Summary   string
+	Handler   http.HandlerFunc
+	Successor string // 非推奨ルートの移行先（空なら現行ルート）
+}
+
+// apiRoutes - 公開しているHTTPルート一覧
+func apiRoutes() []apiRoute {
+	return []apiRoute{
+		{Path: "/v1/generate-pdf", Methods: []string{"POST"}, Summary: "Generate PDF from a PrintRequest (prints when print=true)", Handler: v1GeneratePDFHandler},
+		{Path: "/v1/print-pdf", Methods: []string{"POST"}, Summary: "Generate and print PDF", Handler: v1PrintPDFHandler},
+		{Path: "/v1/print", Methods: []string{"POST"}, Summary: "Print an uploaded PDF file (envelope printing)", Handler: envelopePrintHandler},
+		{Path: "/v1/health", Methods: []string{"GET"}, Summary: "Health check", Handler: healthHandler},
+		{Path: "/generate-pdf", Methods: []string{"POST"}, Summary: "Generate PDF (also accepts a bare item array)", Handler: generatePDFHandler, Successor: "/v1/generate-pdf"},
+		{Path: "/print-pdf", Methods: []string{"POST"}, Summary: "Generate and print PDF", Handler: printPDFHandler, Successor: "/v1/print-pdf"},
+		{Path: "/print", Methods: []string{"POST"}, Summary: "Print an uploaded PDF file", Handler: envelopePrintHandler, Successor: "/v1/print"},
+		{Path: "/health", Methods: []string{"GET"}, Summary: "Health check", Handler: healthHandler},
+		{Path: "/admin/update", Methods: []string{"GET"}, Summary: "Update status (bearer token required)", Handler: adminUpdateStatusHandler},
+		{Path: "/admin/update/check", Methods: []string{"POST"}, Summary: "Check for a newer release now", Handler: adminUpdateCheckHandler},
+		{Path: "/admin/update/apply", Methods: []string{"POST"}, Summary: "Apply the latest or a given release and restart", Handler: adminUpdateApplyHandler},
+		{Path: "/admin/update/rollback", Methods: []string{"POST"}, Summary: "Restore the previous executable and restart", Handler: adminUpdateRollbackHandler},
+		{Path: "/metrics", Methods: []string{"GET"}, Summary: "Prometheus metrics", Handler: metricsHandler},
+		{Path: "/openapi.json", Methods: []string{"GET"}, Summary: "OpenAPI 3 specification of this API", Handler: openAPIHandler},
+		{Path: "/", Methods: []string{"GET"}, Summary: "API overview", Handler: rootHandler},
+	}
+}
+
+// newServeMux - ルート一覧からHTTPマルチプレクサを作成
+func newServeMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	for _, route := range apiRoutes() {
+		handler := route.Handler
+		if route.Successor != "" {
+			handler = deprecatedRoute(route.Successor, handler)
+		}
+		mux.HandleFunc(route.Path, instrumentHandler(route.Path, handler))
+	}
+	return mux
+}
+
+// rootHandler - APIの概要を表示
+func rootHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		writeAPIError(w, r, http.StatusNotFound, ErrCodeNotFound, "Endpoint not found",
+			map[string]string{"path": r.URL.Path})
+		return
+	}
+	writeRequestLog(r, "INFO", fmt.Sprintf("ルートアクセス from %s", r.RemoteAddr))
+
+	var endpoints strings.Builder
+	for _, route := range apiRoutes() {
+		if route.Path == "/" {
+			continue
+		}
+		summary := route.Summary
+		if route.Successor != "" {
+			summary += fmt.Sprintf(" [deprecated, use %s]", route.Successor)
+		}
+		fmt.Fprintf(&endpoints, "- %-4s %-22s: %s\n", strings.Join(route.Methods, ","), route.Path, summary)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, `
+PDF Generator API Server %s
+
+Available endpoints:
+%s
+The full API specification is available at GET /openapi.json
+Unversioned routes (/generate-pdf, /print-pdf, /print) are deprecated; use /v1.
+
+Example request (generate only):
+curl -X POST http://localhost:8081/v1/generate-pdf \
+  -H "Content-Type: application/json" \
+  -d '{"items":[{"car":"test","name":"テスト","ryohi":[]}]}'
+
+Example request (generate and print to the default printer):
+curl -X POST http://localhost:8081/v1/print-pdf \
+  -H "Content-Type: application/json" \
+  -d '{"items":[{"car":"test","name":"テスト","ryohi":[]}]}'
+
+Example request (generate and print to specific printer):
+curl -X POST http://localhost:8081/v1/print-pdf \
+  -H "Content-Type: application/json" \
+  -d '{"items":[{"car":"test","name":"テスト","ryohi":[]}],"printerName":"Canon Printer"}'
+
+Example request (print an envelope PDF):
+curl -X POST http://localhost:8081/v1/print \
+  -F "document=@envelope.pdf" \
+  -F "printer=LBP221-futo"
+
+Version: %s
+Platform: %s %s
+`, Version, endpoints.String(), Version, runtime.GOOS, runtime.GOARCH)
+}
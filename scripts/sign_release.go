@@ -0,0 +1,102 @@
+//go:build ignore
+
+// sign_release - リリースzipのチェックサムファイルとed25519署名を作成
+//
+// 鍵ペアの生成（初回のみ。秘密鍵はGitHub Secretsに、公開鍵はVariablesに登録）:
+//
+//	go run scripts/sign_release.go -genkey
+//
+// 署名（環境変数 UPDATE_SIGNING_KEY に base64 の秘密鍵を設定）:
+//
+//	go run scripts/sign_release.go print_pdf_v1.2.3.zip
+//
+// print_pdf_v1.2.3.zip.sha256 と print_pdf_v1.2.3.zip.sha256.sig を作成する。
+// 実行ファイルは UpdatePublicKey に埋め込まれた公開鍵でこれらを検証してから更新する。
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	genKey := flag.Bool("genkey", false, "generate a new ed25519 key pair")
+	flag.Parse()
+
+	if *genKey {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			log.Fatalf("鍵生成エラー: %v", err)
+		}
+		fmt.Printf("UPDATE_SIGNING_KEY (secret): %s\n", base64.StdEncoding.EncodeToString(priv.Seed()))
+		fmt.Printf("UPDATE_PUBLIC_KEY  (public): %s\n", base64.StdEncoding.EncodeToString(pub))
+		return
+	}
+
+	if flag.NArg() != 1 {
+		log.Fatal("使い方: go run scripts/sign_release.go [-genkey] <archive.zip>")
+	}
+	archive := flag.Arg(0)
+
+	key, err := signingKey(os.Getenv("UPDATE_SIGNING_KEY"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sum, err := fileSHA256(archive)
+	if err != nil {
+		log.Fatalf("チェックサム計算エラー: %v", err)
+	}
+	checksums := []byte(fmt.Sprintf("%x  %s\n", sum, filepath.Base(archive)))
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(key, checksums)) + "\n"
+
+	if err := os.WriteFile(archive+".sha256", checksums, 0644); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(archive+".sha256.sig", []byte(signature), 0644); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s", checksums)
+	fmt.Printf("signed: %s.sha256.sig\n", archive)
+}
+
+// signingKey - base64の秘密鍵（シード32バイトまたは秘密鍵64バイト）を読み込む
+func signingKey(encoded string) (ed25519.PrivateKey, error) {
+	if encoded == "" {
+		return nil, fmt.Errorf("環境変数 UPDATE_SIGNING_KEY が設定されていません")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("UPDATE_SIGNING_KEY の形式が不正です: %v", err)
+	}
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw), nil
+	}
+	return nil, fmt.Errorf("UPDATE_SIGNING_KEY の長さが不正です: %d バイト", len(raw))
+}
+
+func fileSHA256(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semVersion - セマンティックバージョン（https://semver.org/ 準拠、先頭の "v" は省略可）
+type semVersion struct {
+	Major, Minor, Patch int
+	Prerelease          []string // "-" 以降をドットで分割したもの（例: beta.2 → ["beta", "2"]）
+}
+
+// parseSemVer - "v1.2.3" / "1.2.3-beta.1+build" 形式を解析（ビルドメタデータは無視）
+func parseSemVer(s string) (semVersion, error) {
+	var v semVersion
+	rest := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexByte(rest, '+'); i >= 0 {
+		rest = rest[:i]
+	}
+	if i := strings.IndexByte(rest, '-'); i >= 0 {
+		pre := rest[i+1:]
+		rest = rest[:i]
+		if pre == "" {
+			return v, fmt.Errorf("invalid version %q: empty prerelease", s)
+		}
+		for _, id := range strings.Split(pre, ".") {
+			if id == "" {
+				return v, fmt.Errorf("invalid version %q: empty prerelease identifier", s)
+			}
+			v.Prerelease = append(v.Prerelease, id)
+		}
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return semVersion{}, fmt.Errorf("invalid version %q: expected MAJOR.MINOR.PATCH", s)
+	}
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || part == "" || (len(part) > 1 && part[0] == '0') {
+			return semVersion{}, fmt.Errorf("invalid version %q: bad number %q", s, part)
+		}
+		*nums[i] = n
+	}
+	return v, nil
+}
+
+// IsPrerelease - プレリリース（beta等）かどうか
+func (v semVersion) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// Compare - v < o なら -1、等しければ 0、v > o なら 1
+func (v semVersion) Compare(o semVersion) int {
+	for _, d := range [][2]int{{v.Major, o.Major}, {v.Minor, o.Minor}, {v.Patch, o.Patch}} {
+		if d[0] != d[1] {
+			return compareInt(d[0], d[1])
+		}
+	}
+
+	// プレリリースなしの方が新しい（1.0.0-beta < 1.0.0）
+	switch {
+	case !v.IsPrerelease() && !o.IsPrerelease():
+		return 0
+	case !v.IsPrerelease():
+		return 1
+	case !o.IsPrerelease():
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(o.Prerelease); i++ {
+		if c := comparePrereleaseID(v.Prerelease[i], o.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInt(len(v.Prerelease), len(o.Prerelease))
+}
+
+// String - "v" 付きの表記
+func (v semVersion) String() string {
+	s := fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.IsPrerelease() {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	return s
+}
+
+// comparePrereleaseID - 数値は数値として、それ以外は文字列として比較（数値の方が小さい）
+func comparePrereleaseID(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return compareInt(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,74 @@
+package main
+
+import "testing"
+
+func TestParseSemVer(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "v1.2.3", want: "v1.2.3"},
+		{input: "1.2.3", want: "v1.2.3"},
+		{input: "v1.2.3-beta.1", want: "v1.2.3-beta.1"},
+		{input: "v1.2.3+build.5", want: "v1.2.3"},
+		{input: "v1.2.3-rc.1+build", want: "v1.2.3-rc.1"},
+		{input: " v10.20.30 ", want: "v10.20.30"},
+		{input: "dev", wantErr: true},
+		{input: "v1.2", wantErr: true},
+		{input: "v1.2.3.4", wantErr: true},
+		{input: "v1.02.3", wantErr: true},
+		{input: "v1.x.3", wantErr: true},
+		{input: "v1.2.3-", wantErr: true},
+		{input: "v1.2.3-beta..1", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseSemVer(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.String() != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSemVersionCompare(t *testing.T) {
+	// semver.org の優先順位の例を含む
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.0.0", "v1.0.0", 0},
+		{"v1.0.1", "v1.0.0", 1},
+		{"v1.0.9", "v1.0.10", -1},
+		{"v1.10.0", "v1.9.0", 1},
+		{"v2.0.0", "v1.99.99", 1},
+		{"v1.0.0-alpha", "v1.0.0", -1},
+		{"v1.0.0-alpha", "v1.0.0-alpha.1", -1},
+		{"v1.0.0-alpha.1", "v1.0.0-alpha.beta", -1},
+		{"v1.0.0-alpha.beta", "v1.0.0-beta", -1},
+		{"v1.0.0-beta", "v1.0.0-beta.2", -1},
+		{"v1.0.0-beta.2", "v1.0.0-beta.11", -1},
+		{"v1.0.0-beta.11", "v1.0.0-rc.1", -1},
+		{"v1.0.0-rc.1", "v1.0.0", -1},
+		{"v1.0.0+build1", "v1.0.0+build2", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			a, _ := parseSemVer(tt.a)
+			b, _ := parseSemVer(tt.b)
+			if got := a.Compare(b); got != tt.want {
+				t.Errorf("Compare(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+			if got := b.Compare(a); got != -tt.want {
+				t.Errorf("Compare(%s, %s) = %d, want %d", tt.b, tt.a, got, -tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// systemd のユニット名（journald の SyslogIdentifier にも使う）
+const systemdUnitName = "print_pdf"
+
+// systemdUnitDir - ユニットファイルを置くディレクトリ（テストで差し替え可能）
+var systemdUnitDir = "/etc/systemd/system"
+
+// runSystemctl - systemctl を実行して標準出力を返す（テストで差し替え可能）
+var runSystemctl = func(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("systemctl", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return stdout.String(), fmt.Errorf("systemctl %s: %s", strings.Join(args, " "), msg)
+		}
+		return stdout.String(), fmt.Errorf("systemctl %s: %w", strings.Join(args, " "), err)
+	}
+	return stdout.String(), nil
+}
+
+// systemdUnitParams - ユニットファイルのテンプレートに渡す値
+type systemdUnitParams struct {
+	Description    string
+	ExecStart      string
+	WorkingDir     string
+	User           string
+	Identifier     string
+	RestartSec     int
+	StartLimit     int // StartLimitIntervalSec 内に再起動を試みる回数
+	StartLimitSec  int
+	TimeoutStopSec int
+}
+
+// systemdUnitTemplate - Type=notify のユニットファイル（sd_notify・ウォッチドッグ・SIGHUP での再読み込みに対応）
+var systemdUnitTemplate = template.Must(template.New("unit").Parse(`[Unit]
+Description={{.Description}}
+After=network-online.target cups.service
+Wants=network-online.target
+StartLimitIntervalSec={{.StartLimitSec}}
+StartLimitBurst={{.StartLimit}}
+
+[Service]
+Type=notify
+ExecStart={{.ExecStart}}
+ExecReload=/bin/kill -HUP $MAINPID
+WorkingDirectory={{.WorkingDir}}
+{{- if .User}}
+User={{.User}}
+{{- end}}
+SyslogIdentifier={{.Identifier}}
+WatchdogSec=30
+Restart=on-failure
+RestartSec={{.RestartSec}}
+TimeoutStopSec={{.TimeoutStopSec}}
+
+[Install]
+WantedBy=multi-user.target
+`))
+
+// systemdServiceControl - systemctl でサービスを管理
+type systemdServiceControl struct{}
+
+func newPlatformServiceControl() serviceControl { return systemdServiceControl{} }
+
+// systemdUnitPath - ユニットファイルのパス
+func systemdUnitPath() string {
+	return filepath.Join(systemdUnitDir, systemdUnitName+".service")
+}
+
+// buildSystemdUnit - ユニットファイルの内容を生成
+func buildSystemdUnit(opts serviceInstallOptions) (string, error) {
+	args := []string{opts.Executable, "serve"}
+	if opts.ConfigFile != "" {
+		args = append(args, "-config", opts.ConfigFile)
+	}
+	for i, arg := range args {
+		args[i] = systemdQuote(arg)
+	}
+
+	params := systemdUnitParams{
+		Description:    serviceName,
+		ExecStart:      strings.Join(args, " "),
+		WorkingDir:     systemdQuote(filepath.Dir(opts.Executable)),
+		User:           opts.User,
+		Identifier:     systemdUnitName,
+		RestartSec:     int(serviceRestartDelays[0].Seconds()),
+		StartLimit:     len(serviceRestartDelays) + 1,
+		StartLimitSec:  int(serviceResetPeriod.Seconds()),
+		TimeoutStopSec: int((drainTimeout() + 30*time.Second).Seconds()),
+	}
+	var buf bytes.Buffer
+	if err := systemdUnitTemplate.Execute(&buf, params); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// systemdQuote - 空白や引用符を含む引数を systemd の書式でクォート
+func systemdQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\") {
+		return arg
+	}
+	return strconv.Quote(arg)
+}
+
+// Install - ユニットファイルを書き込み、自動起動を有効化
+func (systemdServiceControl) Install(opts serviceInstallOptions) error {
+	if opts.Password != "" {
+		return errors.New("a password (-password, -password-stdin or " + servicePasswordEnv + ") is not supported on Linux; systemd runs the service as -user without a password")
+	}
+	path := systemdUnitPath()
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("service is already installed (%s); run \"print_pdf service uninstall\" first", path)
+	}
+	unit, err := buildSystemdUnit(opts)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(unit), 0644); err != nil {
+		return fmt.Errorf("cannot write unit file (run as root): %w", err)
+	}
+	if _, err := runSystemctl("daemon-reload"); err != nil {
+		os.Remove(path)
+		return err
+	}
+	if _, err := runSystemctl("enable", systemdUnitName); err != nil {
+		os.Remove(path)
+		runSystemctl("daemon-reload")
+		return err
+	}
+	return nil
+}
+
+// Uninstall - サービスを停止・無効化してユニットファイルを削除
+func (systemdServiceControl) Uninstall() error {
+	path := systemdUnitPath()
+	if _, err := os.Stat(path); err != nil {
+		return errServiceNotInstalled
+	}
+	if _, err := runSystemctl("disable", "--now", systemdUnitName); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("cannot remove unit file: %w", err)
+	}
+	_, err := runSystemctl("daemon-reload")
+	return err
+}
+
+// Start - サービスを開始（Type=notify のため起動完了の通知まで待つ）
+func (c systemdServiceControl) Start() error {
+	if err := c.requireInstalled(); err != nil {
+		return err
+	}
+	_, err := runSystemctl("start", systemdUnitName)
+	return err
+}
+
+// Stop - サービスを停止（印刷中のジョブの完了を待つ）
+func (c systemdServiceControl) Stop() error {
+	if err := c.requireInstalled(); err != nil {
+		return err
+	}
+	_, err := runSystemctl("stop", systemdUnitName)
+	return err
+}
+
+func (systemdServiceControl) requireInstalled() error {
+	if _, err := os.Stat(systemdUnitPath()); err != nil {
+		return errServiceNotInstalled
+	}
+	return nil
+}
+
+// systemdShowProperties - Status で取得するプロパティ
+var systemdShowProperties = []string{
+	"LoadState", "ActiveState", "SubState", "MainPID", "UnitFileState",
+	"ExecStart", "User", "FragmentPath", "Restart", "RestartUSec", "StartLimitBurst", "StartLimitIntervalUSec",
+}
+
+// Status - systemctl show の内容からサービスの状態を取得
+func (systemdServiceControl) Status() (serviceState, error) {
+	out, err := runSystemctl("show", systemdUnitName, "--property="+strings.Join(systemdShowProperties, ","))
+	if err != nil {
+		return serviceState{}, err
+	}
+	return parseSystemdShow(out), nil
+}
+
+// parseSystemdShow - systemctl show の出力（Key=Value の行）を解析
+func parseSystemdShow(out string) serviceState {
+	props := map[string]string{}
+	for _, line := range strings.Split(out, "\n") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(line), "="); ok {
+			props[key] = value
+		}
+	}
+	if props["LoadState"] != "loaded" {
+		return serviceState{}
+	}
+
+	state := serviceState{
+		Installed: true,
+		State:     systemdStateName(props["ActiveState"], props["SubState"]),
+		StartType: props["UnitFileState"],
+		Command:   systemdExecCommand(props["ExecStart"]),
+		Account:   props["User"],
+		UnitFile:  props["FragmentPath"],
+		LogSource: fmt.Sprintf("journald (journalctl -u %s)", systemdUnitName),
+		LogReady:  true,
+	}
+	if state.Account == "" {
+		state.Account = "root"
+	}
+	if pid, err := strconv.Atoi(props["MainPID"]); err == nil {
+		state.PID = pid
+	}
+	if restart := props["Restart"]; restart != "" {
+		state.Recovery = fmt.Sprintf("restart %s after %s", restart, props["RestartUSec"])
+		if burst := props["StartLimitBurst"]; burst != "" {
+			state.Recovery += fmt.Sprintf("; at most %s starts per %s", burst, props["StartLimitIntervalUSec"])
+		}
+	}
+	return state
+}
+
+// systemdStateName - ActiveState/SubState を Windows と共通の表記に変換
+func systemdStateName(active, sub string) string {
+	switch active {
+	case "active":
+		if sub == "running" {
+			return "running"
+		}
+		return "active (" + sub + ")"
+	case "activating", "reloading":
+		return "start-pending"
+	case "deactivating":
+		return "stop-pending"
+	case "inactive":
+		return "stopped"
+	case "failed":
+		return "failed"
+	}
+	return active
+}
+
+// systemdExecCommand - ExecStart プロパティ（{ path=... ; argv[]=... ; ... }）からコマンドラインを取り出す
+func systemdExecCommand(value string) string {
+	_, rest, ok := strings.Cut(value, "argv[]=")
+	if !ok {
+		return value
+	}
+	argv, _, _ := strings.Cut(rest, " ;")
+	return strings.TrimSpace(argv)
+}
@@ -0,0 +1,179 @@
+//go:build linux
+
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withFakeSystemctl - systemctl の呼び出しを記録し、ユニットファイルを一時ディレクトリに置く
+func withFakeSystemctl(t *testing.T, show string, fail string) *[]string {
+	t.Helper()
+	var calls []string
+	originalRun, originalDir := runSystemctl, systemdUnitDir
+	systemdUnitDir = t.TempDir()
+	runSystemctl = func(args ...string) (string, error) {
+		calls = append(calls, strings.Join(args, " "))
+		if fail != "" && args[0] == fail {
+			return "", errors.New("systemctl " + fail + " failed")
+		}
+		if args[0] == "show" {
+			return show, nil
+		}
+		return "", nil
+	}
+	t.Cleanup(func() { runSystemctl, systemdUnitDir = originalRun, originalDir })
+	return &calls
+}
+
+func TestBuildSystemdUnit(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    serviceInstallOptions
+		want    []string
+		wantNot []string
+	}{
+		{
+			name: "defaults",
+			opts: serviceInstallOptions{Executable: "/opt/print_pdf/print_pdf"},
+			want: []string{
+				"Type=notify",
+				"ExecStart=/opt/print_pdf/print_pdf serve\n",
+				"WorkingDirectory=/opt/print_pdf\n",
+				"SyslogIdentifier=print_pdf",
+				"Restart=on-failure",
+				"RestartSec=5\n",
+				"StartLimitBurst=4",
+				"StartLimitIntervalSec=86400",
+				"TimeoutStopSec=150",
+				"WantedBy=multi-user.target",
+			},
+			wantNot: []string{"User="},
+		},
+		{
+			name: "config and user",
+			opts: serviceInstallOptions{Executable: "/opt/print pdf/print_pdf", ConfigFile: "/etc/print_pdf/config.json", User: "printer"},
+			want: []string{
+				`ExecStart="/opt/print pdf/print_pdf" serve -config /etc/print_pdf/config.json`,
+				`WorkingDirectory="/opt/print pdf"`,
+				"User=printer\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit, err := buildSystemdUnit(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(unit, want) {
+					t.Errorf("unit missing %q:\n%s", want, unit)
+				}
+			}
+			for _, not := range tt.wantNot {
+				if strings.Contains(unit, not) {
+					t.Errorf("unit should not contain %q:\n%s", not, unit)
+				}
+			}
+		})
+	}
+}
+
+func TestSystemdInstallUninstall(t *testing.T) {
+	calls := withFakeSystemctl(t, "", "")
+	ctl := systemdServiceControl{}
+
+	if err := ctl.Install(serviceInstallOptions{Executable: "/opt/print_pdf/print_pdf"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(systemdUnitDir, "print_pdf.service")); err != nil {
+		t.Fatalf("unit file not written: %v", err)
+	}
+	if err := ctl.Install(serviceInstallOptions{Executable: "/opt/print_pdf/print_pdf"}); err == nil {
+		t.Error("second install should fail")
+	}
+	if err := ctl.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctl.Uninstall(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(systemdUnitPath()); !os.IsNotExist(err) {
+		t.Errorf("unit file should be removed: %v", err)
+	}
+	if err := ctl.Stop(); !errors.Is(err, errServiceNotInstalled) {
+		t.Errorf("Stop after uninstall = %v, want errServiceNotInstalled", err)
+	}
+
+	want := "daemon-reload|enable print_pdf|start print_pdf|disable --now print_pdf|daemon-reload"
+	if got := strings.Join(*calls, "|"); got != want {
+		t.Errorf("systemctl calls = %q, want %q", got, want)
+	}
+}
+
+func TestSystemdInstallRollsBackOnFailure(t *testing.T) {
+	withFakeSystemctl(t, "", "enable")
+	if err := (systemdServiceControl{}).Install(serviceInstallOptions{Executable: "/opt/print_pdf/print_pdf"}); err == nil {
+		t.Fatal("Install should fail when enable fails")
+	}
+	if _, err := os.Stat(systemdUnitPath()); !os.IsNotExist(err) {
+		t.Errorf("unit file should be removed after failure: %v", err)
+	}
+}
+
+func TestSystemdInstallRejectsPassword(t *testing.T) {
+	calls := withFakeSystemctl(t, "", "")
+	err := (systemdServiceControl{}).Install(serviceInstallOptions{Executable: "/opt/print_pdf/print_pdf", User: "printer", Password: "pw"})
+	if err == nil || len(*calls) != 0 {
+		t.Errorf("Install with password = %v (calls %v), want error without calling systemctl", err, *calls)
+	}
+}
+
+func TestParseSystemdShow(t *testing.T) {
+	tests := []struct {
+		name string
+		show string
+		want serviceState
+	}{
+		{
+			name: "running",
+			show: "LoadState=loaded\nActiveState=active\nSubState=running\nMainPID=1234\nUnitFileState=enabled\n" +
+				"ExecStart={ path=/opt/print_pdf/print_pdf ; argv[]=/opt/print_pdf/print_pdf serve ; ignore_errors=no ; start_time=[n/a] ; pid=0 ; code=(null) ; status=0/0 }\n" +
+				"User=printer\nFragmentPath=/etc/systemd/system/print_pdf.service\nRestart=on-failure\nRestartUSec=5s\nStartLimitBurst=4\nStartLimitIntervalUSec=1d\n",
+			want: serviceState{
+				Installed: true, State: "running", PID: 1234, StartType: "enabled",
+				Command: "/opt/print_pdf/print_pdf serve", Account: "printer",
+				UnitFile:  "/etc/systemd/system/print_pdf.service",
+				Recovery:  "restart on-failure after 5s; at most 4 starts per 1d",
+				LogSource: "journald (journalctl -u print_pdf)", LogReady: true,
+			},
+		},
+		{
+			name: "failed as root",
+			show: "LoadState=loaded\nActiveState=failed\nSubState=failed\nMainPID=0\nUnitFileState=enabled\nUser=\n",
+			want: serviceState{
+				Installed: true, State: "failed", StartType: "enabled", Account: "root",
+				LogSource: "journald (journalctl -u print_pdf)", LogReady: true,
+			},
+		},
+		{
+			name: "not installed",
+			show: "LoadState=not-found\nActiveState=inactive\nSubState=dead\n",
+			want: serviceState{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseSystemdShow(tt.show); got != tt.want {
+				t.Errorf("parseSystemdShow =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// サービスの開始を待つ時間（停止は印刷ジョブの完了待ちを含めて drainTimeout を加える）
+const serviceStartTimeout = 30 * time.Second
+
+// windowsServiceControl - サービスコントロールマネージャー（SCM）でサービスを管理
+type windowsServiceControl struct{}
+
+func newPlatformServiceControl() serviceControl { return windowsServiceControl{} }
+
+// connect - SCMに接続してサービスを開く（登録されていない場合は errServiceNotInstalled）
+func (windowsServiceControl) connect() (*mgr.Mgr, *mgr.Service, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot connect to the service manager (run as administrator): %w", err)
+	}
+	s, err := m.OpenService(serviceName)
+	if err != nil {
+		m.Disconnect()
+		if errors.Is(err, windows.ERROR_SERVICE_DOES_NOT_EXIST) {
+			return nil, nil, errServiceNotInstalled
+		}
+		return nil, nil, err
+	}
+	return m, s, nil
+}
+
+// Install - サービスを自動起動で登録し、障害時の回復設定とイベントログのソースを登録
+func (c windowsServiceControl) Install(opts serviceInstallOptions) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("cannot connect to the service manager (run as administrator): %w", err)
+	}
+	defer m.Disconnect()
+
+	if s, err := m.OpenService(serviceName); err == nil {
+		s.Close()
+		return fmt.Errorf("service %q is already installed; run \"print_pdf service uninstall\" first", serviceName)
+	}
+
+	args := []string{"serve"}
+	if opts.ConfigFile != "" {
+		args = append(args, "-config", opts.ConfigFile)
+	}
+	s, err := m.CreateService(serviceName, opts.Executable, mgr.Config{
+		DisplayName:      serviceName,
+		Description:      serviceDescription,
+		StartType:        mgr.StartAutomatic,
+		ServiceStartName: opts.User,
+		Password:         opts.Password,
+	}, args...)
+	if err != nil {
+		return fmt.Errorf("cannot create service: %w", err)
+	}
+	defer s.Close()
+
+	// 異常終了した場合は段階的に間隔を空けて再起動
+	actions := make([]mgr.RecoveryAction, len(serviceRestartDelays))
+	for i, d := range serviceRestartDelays {
+		actions[i] = mgr.RecoveryAction{Type: mgr.ServiceRestart, Delay: d}
+	}
+	if err := s.SetRecoveryActions(actions, uint32(serviceResetPeriod.Seconds())); err != nil {
+		s.Delete()
+		return fmt.Errorf("cannot set recovery actions: %w", err)
+	}
+	// エラーで停止した場合（SERVICE_STOPPED と終了コードを報告した場合）も回復処理の対象にする
+	if err := s.SetRecoveryActionsOnNonCrashFailures(true); err != nil {
+		s.Delete()
+		return fmt.Errorf("cannot set recovery actions: %w", err)
+	}
+
+	// 既に登録されている場合（再インストール時など）はそのまま使う
+	if err := eventlog.InstallAsEventCreate(serviceName, eventlog.Error|eventlog.Warning|eventlog.Info); err != nil && !eventSourceRegistered() {
+		s.Delete()
+		return fmt.Errorf("cannot register event log source: %w", err)
+	}
+	return nil
+}
+
+// Uninstall - サービスを停止して削除し、イベントログのソースを削除
+func (c windowsServiceControl) Uninstall() error {
+	m, s, err := c.connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	defer s.Close()
+
+	if status, err := s.Query(); err == nil && status.State != svc.Stopped {
+		if err := c.stop(s); err != nil {
+			return err
+		}
+	}
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("cannot delete service: %w", err)
+	}
+	if err := eventlog.Remove(serviceName); err != nil && eventSourceRegistered() {
+		return fmt.Errorf("service removed but the event log source could not be removed: %w", err)
+	}
+	return nil
+}
+
+// Start - サービスを開始して実行中になるまで待つ
+func (c windowsServiceControl) Start() error {
+	m, s, err := c.connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	defer s.Close()
+
+	if err := s.Start(); err != nil {
+		if errors.Is(err, windows.ERROR_SERVICE_ALREADY_RUNNING) {
+			return nil
+		}
+		return fmt.Errorf("cannot start service: %w", err)
+	}
+	return waitServiceState(s, svc.Running, serviceStartTimeout)
+}
+
+// Stop - サービスを停止して停止するまで待つ
+func (c windowsServiceControl) Stop() error {
+	m, s, err := c.connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	defer s.Close()
+	return c.stop(s)
+}
+
+func (windowsServiceControl) stop(s *mgr.Service) error {
+	if _, err := s.Control(svc.Stop); err != nil {
+		if errors.Is(err, windows.ERROR_SERVICE_NOT_ACTIVE) {
+			return nil
+		}
+		return fmt.Errorf("cannot stop service: %w", err)
+	}
+	// 印刷中のジョブの完了を待ってから停止する
+	return waitServiceState(s, svc.Stopped, drainTimeout()+serviceStartTimeout)
+}
+
+// Status - サービスの状態と登録内容
+func (c windowsServiceControl) Status() (serviceState, error) {
+	m, s, err := c.connect()
+	if errors.Is(err, errServiceNotInstalled) {
+		return serviceState{}, nil
+	}
+	if err != nil {
+		return serviceState{}, err
+	}
+	defer m.Disconnect()
+	defer s.Close()
+
+	status, err := s.Query()
+	if err != nil {
+		return serviceState{}, fmt.Errorf("cannot query service: %w", err)
+	}
+	state := serviceState{
+		Installed: true,
+		State:     windowsStateName(status.State),
+		PID:       int(status.ProcessId),
+		LogSource: "Windows Event Log (Application) source " + serviceName,
+		LogReady:  eventSourceRegistered(),
+	}
+	if cfg, err := s.Config(); err == nil {
+		state.StartType = windowsStartTypeName(cfg.StartType, cfg.DelayedAutoStart)
+		state.Command = cfg.BinaryPathName
+		state.Account = cfg.ServiceStartName
+		state.Description = cfg.Description
+	}
+	if actions, err := s.RecoveryActions(); err == nil {
+		var delays []time.Duration
+		for _, a := range actions {
+			if a.Type == mgr.ServiceRestart {
+				delays = append(delays, a.Delay)
+			}
+		}
+		reset, _ := s.ResetPeriod()
+		state.Recovery = formatRecovery(delays, time.Duration(reset)*time.Second)
+	}
+	return state, nil
+}
+
+// waitServiceState - サービスが指定した状態になるまで待つ
+func waitServiceState(s *mgr.Service, want svc.State, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		status, err := s.Query()
+		if err != nil {
+			return fmt.Errorf("cannot query service: %w", err)
+		}
+		if status.State == want {
+			return nil
+		}
+		if want == svc.Running && status.State == svc.Stopped {
+			return fmt.Errorf("service stopped during start (exit code %d); see the Application event log", status.Win32ExitCode)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for service to become %s (current: %s)", windowsStateName(want), windowsStateName(status.State))
+		}
+		time.Sleep(300 * time.Millisecond)
+	}
+}
+
+// eventSourceRegistered - イベントログのソースが登録済みか
+func eventSourceRegistered() bool {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE,
+		`SYSTEM\CurrentControlSet\Services\EventLog\Application\`+serviceName, registry.QUERY_VALUE)
+	if err != nil {
+		return false
+	}
+	k.Close()
+	return true
+}
+
+func windowsStateName(state svc.State) string {
+	switch state {
+	case svc.Stopped:
+		return "stopped"
+	case svc.StartPending:
+		return "start-pending"
+	case svc.StopPending:
+		return "stop-pending"
+	case svc.Running:
+		return "running"
+	case svc.ContinuePending:
+		return "continue-pending"
+	case svc.PausePending:
+		return "pause-pending"
+	case svc.Paused:
+		return "paused"
+	}
+	return fmt.Sprintf("unknown (%d)", state)
+}
+
+func windowsStartTypeName(startType uint32, delayed bool) string {
+	switch startType {
+	case mgr.StartAutomatic:
+		if delayed {
+			return "auto (delayed)"
+		}
+		return "auto"
+	case mgr.StartManual:
+		return "manual"
+	case mgr.StartDisabled:
+		return "disabled"
+	}
+	return fmt.Sprintf("type %d", startType)
+}
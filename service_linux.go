@@ -0,0 +1,205 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// journald への出力（systemd から起動され標準出力がジャーナルにつながっている場合のみ。nil の場合はコンソールに出力）
+var elog eventLogger
+
+// 設定を再読み込みするシグナル
+var reloadSignals = []os.Signal{syscall.SIGHUP}
+
+// 自己アップデートはWindowsのみ対応（Linuxではパッケージ等で更新する）
+var selfUpdateSupported = false
+
+// startUpdateScript - Linuxでは置換用バッチファイルを実行できない
+var startUpdateScript = func(batchFile string) error {
+	return errSelfUpdateUnsupported
+}
+
+// runService - systemd から起動された場合は sd_notify で状態を通知し、それ以外はコンソールで実行
+func runService() {
+	if !isServiceProcess() {
+		runConsoleApp()
+		return
+	}
+	if os.Getenv("JOURNAL_STREAM") != "" {
+		elog = &journalLogger{w: os.Stderr}
+	}
+	runSystemdService()
+}
+
+// stopServiceForUpdate - Linuxでは自己アップデートしないため、サービスマネージャー経由の停止は行わない
+func stopServiceForUpdate() bool {
+	return false
+}
+
+// isServiceProcess - systemd のサービスとして起動されているか
+func isServiceProcess() bool {
+	return os.Getenv("NOTIFY_SOCKET") != "" || os.Getenv("INVOCATION_ID") != ""
+}
+
+// runSystemdService - systemd（Type=notify）のサービスとして実行
+func runSystemdService() {
+	writeEventLog("INFO", "PDF Generator をsystemdサービスとして開始")
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+
+	go startHTTPServer()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// HTTPサーバーが応答するようになってから起動完了を通知
+	if waitUntilServing(ctx, 30*time.Second) {
+		sdNotifyLog(fmt.Sprintf("READY=1\nSTATUS=Listening on %s", httpPort))
+	} else {
+		writeEventLog("ERROR", "HTTPサーバーが応答しません。起動完了を通知できませんでした")
+	}
+	if interval := watchdogInterval(); interval > 0 {
+		writeEventLog("INFO", fmt.Sprintf("systemd ウォッチドッグを有効化 (間隔: %v)", interval))
+		go runWatchdog(ctx, interval)
+	}
+
+	for sig := range sigChan {
+		if sig == syscall.SIGHUP {
+			sdNotifyLog("RELOADING=1")
+			reloadConfigAndLog()
+			sdNotifyLog("READY=1")
+			continue
+		}
+
+		writeEventLog("INFO", "サービス停止要求を受信")
+		// 印刷中のジョブを待つ間に systemd に強制終了されないよう、停止のタイムアウトを延長
+		sdNotifyLog(fmt.Sprintf("STOPPING=1\nEXTEND_TIMEOUT_USEC=%d", (drainTimeout() + 5*time.Second).Microseconds()))
+		if err := drainAndShutdown(); err != nil {
+			writeEventLog("WARN", fmt.Sprintf("停止処理がタイムアウトしました: %v", err))
+		}
+		return
+	}
+}
+
+// sdNotify - systemd に状態を通知（NOTIFY_SOCKET がない場合は何もしない）
+func sdNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	// "@" で始まる場合は抽象名前空間のソケット（net パッケージが変換する）
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// sdNotifyLog - 通知に失敗した場合はログに記録
+func sdNotifyLog(state string) {
+	if err := sdNotify(state); err != nil {
+		writeEventLog("WARN", fmt.Sprintf("systemd への通知に失敗: %v", err))
+	}
+}
+
+// watchdogInterval - WATCHDOG=1 を送る間隔（WatchdogSec の半分。無効の場合は0）
+func watchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0 // 別のプロセス宛て
+	}
+	return time.Duration(usec) * time.Microsecond / 2
+}
+
+// runWatchdog - HTTPサーバーが応答している間だけ WATCHDOG=1 を送る
+// 応答しなくなった場合は通知を止め、systemd に再起動させる
+func runWatchdog(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := checkServing(ctx, interval/2); err != nil {
+			writeEventLog("WARN", fmt.Sprintf("ヘルスチェックに失敗したためウォッチドッグ通知を送りません: %v", err))
+			continue
+		}
+		sdNotifyLog("WATCHDOG=1")
+	}
+}
+
+// waitUntilServing - HTTPサーバーが応答するまで待つ
+func waitUntilServing(ctx context.Context, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if checkServing(ctx, time.Second) == nil {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	return false
+}
+
+// checkServing - 自身の /v1/health に問い合わせる
+func checkServing(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", selfHealthURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(resp.Status)
+	}
+	return nil
+}
+
+// journalLogger - 行頭に sd-daemon 形式の優先度（<3> 等）を付けて出力し、journald にレベルを伝える
+// 時刻は journald が付けるため出力しない
+type journalLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (j *journalLogger) write(priority int, msg string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	// 複数行のメッセージは1行にまとめる（行ごとに別のエントリになるため）
+	_, err := fmt.Fprintf(j.w, "<%d>%s\n", priority, strings.ReplaceAll(msg, "\n", " "))
+	return err
+}
+
+func (j *journalLogger) Info(eid uint32, msg string) error    { return j.write(6, msg) }
+func (j *journalLogger) Warning(eid uint32, msg string) error { return j.write(4, msg) }
+func (j *journalLogger) Error(eid uint32, msg string) error   { return j.write(3, msg) }
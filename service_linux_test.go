@@ -0,0 +1,132 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// listenNotifySocket - NOTIFY_SOCKET を模したソケットを作成
+func listenNotifySocket(t *testing.T) *net.UnixConn {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", path)
+	return conn
+}
+
+// readNotify - 通知を1件読み取る
+func readNotify(t *testing.T, conn *net.UnixConn, timeout time.Duration) (string, bool) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return "", false
+	}
+	return string(buf[:n]), true
+}
+
+func TestSdNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if err := sdNotify("READY=1"); err != nil {
+		t.Errorf("sdNotify without NOTIFY_SOCKET = %v", err)
+	}
+
+	conn := listenNotifySocket(t)
+	if err := sdNotify("READY=1\nSTATUS=Listening"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := readNotify(t, conn, time.Second); got != "READY=1\nSTATUS=Listening" {
+		t.Errorf("notification = %q", got)
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+	tests := []struct {
+		usec string
+		pid  string
+		want time.Duration
+	}{
+		{"", "", 0},
+		{"abc", "", 0},
+		{"0", "", 0},
+		{"30000000", "", 15 * time.Second},
+		{"30000000", pid, 15 * time.Second},
+		{"30000000", "1", 0},
+	}
+	for _, tt := range tests {
+		t.Setenv("WATCHDOG_USEC", tt.usec)
+		t.Setenv("WATCHDOG_PID", tt.pid)
+		if got := watchdogInterval(); got != tt.want {
+			t.Errorf("WATCHDOG_USEC=%q WATCHDOG_PID=%q: interval = %v, want %v", tt.usec, tt.pid, got, tt.want)
+		}
+	}
+}
+
+func TestRunWatchdogOnlyWhileHealthy(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	original := selfHealthURL
+	selfHealthURL = server.URL
+	t.Cleanup(func() { selfHealthURL = original })
+
+	conn := listenNotifySocket(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runWatchdog(ctx, 20*time.Millisecond)
+
+	if got, ok := readNotify(t, conn, time.Second); !ok || got != "WATCHDOG=1" {
+		t.Fatalf("notification = %q, %v", got, ok)
+	}
+
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	for {
+		if _, ok := readNotify(t, conn, 10*time.Millisecond); !ok {
+			break
+		}
+	}
+
+	healthy.Store(false)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go runWatchdog(ctx, 20*time.Millisecond)
+	if got, ok := readNotify(t, conn, 150*time.Millisecond); ok {
+		t.Errorf("watchdog notified while unhealthy: %q", got)
+	}
+}
+
+func TestJournalLogger(t *testing.T) {
+	var buf bytes.Buffer
+	j := &journalLogger{w: &buf}
+	j.Info(1, "started")
+	j.Warning(1, "slow")
+	j.Error(1, "failed\nwith detail")
+
+	want := "<6>started\n<4>slow\n<3>failed with detail\n"
+	if buf.String() != want {
+		t.Errorf("journal output = %q, want %q", buf.String(), want)
+	}
+}
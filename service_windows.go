@@ -0,0 +1,139 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/debug"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// Windowsイベントログ（サービス実行時のみ。nil の場合はコンソールに出力）
+var elog debug.Log
+
+// 設定を再読み込みするシグナル（Windowsにはない）
+var reloadSignals []os.Signal
+
+// 自己アップデート（置換用バッチファイル）に対応しているか（テストで差し替え可能）
+var selfUpdateSupported = true
+
+// startUpdateScript - 置換用バッチファイルを非同期で実行（テストで差し替え可能）
+var startUpdateScript = func(batchFile string) error {
+	return exec.Command("cmd", "/c", batchFile).Start()
+}
+
+// runService - Windowsサービスとして起動された場合はSCMに従い、それ以外はコンソールで実行
+func runService() {
+	// Windowsサービスとして実行されているかチェック
+	isWindowsService, err := svc.IsWindowsService()
+	if err != nil {
+		writeEventLog("FATAL", fmt.Sprintf("サービス状態確認エラー: %v", err))
+		log.Fatalf("サービス状態確認エラー: %v", err)
+	}
+
+	if isWindowsService {
+		// Windowsサービスとして実行
+		runWindowsService()
+	} else {
+		// コンソールアプリケーションとして実行
+		runConsoleApp()
+	}
+}
+
+// isServiceProcess - サービスマネージャーから起動されているか
+func isServiceProcess() bool {
+	isWindowsService, err := svc.IsWindowsService()
+	return err == nil && isWindowsService
+}
+
+// Windowsサービスとして実行
+func runWindowsService() {
+	var err error
+
+	// イベントログを開く（失敗しても続行）
+	elog, err = eventlog.Open(serviceName)
+	if err != nil {
+		// イベントログが開けない場合はファイルログのみ使用
+		elog = nil
+		log.Printf("イベントログを開けませんでした: %v", err)
+	}
+	defer func() {
+		if elog != nil {
+			elog.Close()
+		}
+	}()
+
+	writeEventLog("INFO", "PDF Generator API Service をWindowsサービスとして開始")
+
+	err = svc.Run(serviceName, &service{})
+	if err != nil {
+		writeEventLog("FATAL", fmt.Sprintf("サービス実行エラー: %v", err))
+		log.Fatalf("サービス実行エラー: %v", err)
+	}
+}
+
+// updateStop - 自己アップデートのための停止要求（Execute がサービスマネージャーに停止を報告して終了する）
+var updateStop = make(chan struct{}, 1)
+
+// stopServiceForUpdate - サービスハンドラーに停止を依頼
+// exitProcess で終了するとサービスマネージャーは異常終了とみなし、回復動作で旧バージョンを再起動して
+// 置換用バッチファイルの置き換え・sc start と競合するため、SERVICE_STOPPED を報告してから終了する
+func stopServiceForUpdate() bool {
+	select {
+	case updateStop <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// Windowsサービスハンドラー
+type service struct{}
+
+func (m *service) Execute(args []string, r <-chan svc.ChangeRequest, changes chan<- svc.Status) (ssec bool, errno uint32) {
+	const cmdsAccepted = svc.AcceptStop | svc.AcceptShutdown | svc.AcceptPauseAndContinue
+	changes <- svc.Status{State: svc.StartPending}
+
+	// HTTPサーバーを起動
+	go startHTTPServer()
+
+	changes <- svc.Status{State: svc.Running, Accepts: cmdsAccepted}
+
+	// サービス制御メッセージ・アップデートの停止要求を待機
+	for {
+		var c svc.ChangeRequest
+		select {
+		case <-updateStop:
+			// 処理中のジョブは restartForUpdate で完了済み。戻るとサービスマネージャーに停止を報告する
+			writeEventLog("INFO", "アップデートのためサービスを停止します")
+			changes <- svc.Status{State: svc.StopPending}
+			return
+		case c = <-r:
+		}
+		switch c.Cmd {
+		case svc.Interrogate:
+			changes <- c.CurrentStatus
+		case svc.Stop, svc.Shutdown:
+			writeEventLog("INFO", "サービス停止要求を受信")
+			// 印刷中のジョブを待つ間、サービスマネージャーに停止処理中であることを通知
+			changes <- svc.Status{State: svc.StopPending, WaitHint: uint32((drainTimeout() + 5*time.Second).Milliseconds())}
+			if err := drainAndShutdown(); err != nil {
+				writeEventLog("WARN", fmt.Sprintf("停止処理がタイムアウトしました: %v", err))
+			}
+			return
+		case svc.Pause:
+			pauseService()
+			changes <- svc.Status{State: svc.Paused, Accepts: cmdsAccepted}
+		case svc.Continue:
+			continueService()
+			changes <- svc.Status{State: svc.Running, Accepts: cmdsAccepted}
+		default:
+			elog.Error(1, fmt.Sprintf("unexpected control request #%d", c))
+		}
+	}
+}
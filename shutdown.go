@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// drainTimeout - 停止処理で実行中の処理を待つ最大時間
+func drainTimeout() time.Duration {
+	sec := currentConfig().Server.DrainTimeoutSec
+	if sec <= 0 {
+		sec = defaultConfig().Server.DrainTimeoutSec
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// drainAndShutdown - 新しい印刷ジョブの受付を止め、実行中のリクエストと印刷ジョブの完了を待ってからサーバーを停止
+// SumatraPDFの印刷中にプロセスを終了しないよう、停止・アップデートの前に必ず呼ぶ
+func drainAndShutdown() error {
+	timeout := drainTimeout()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	writeEventLog("INFO", fmt.Sprintf("新しい印刷ジョブの受付を停止し、処理中のジョブを待機します (待機中・実行中: %d件, 最大%v)",
+		jobQueue.Depth(), timeout))
+
+	// 先にキューを閉じる（接続済みのクライアントからの新しい印刷は503になる）
+	jobQueue.StopAccepting()
+	drained := make(chan error, 1)
+	go func() { drained <- jobQueue.Drain(ctx) }()
+
+	// 新しい接続の受付を止め、処理中のリクエストの完了を待つ
+	if httpServer != nil {
+		if err := httpServer.Shutdown(ctx); err != nil {
+			return fmt.Errorf("HTTPサーバー停止エラー: %v", err)
+		}
+	}
+
+	if err := <-drained; err != nil {
+		return err
+	}
+	writeEventLog("INFO", "処理中のジョブはすべて完了しました")
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withTestQueue - テスト中だけ印刷キューと停止待ち時間を差し替える
+func withTestQueue(t *testing.T, drainTimeoutSec int) {
+	t.Helper()
+	originalQueue, originalConfig := jobQueue, currentConfig()
+	jobQueue = newPrintQueue(1, 5)
+	cfg := originalConfig
+	cfg.Server.DrainTimeoutSec = drainTimeoutSec
+	setConfig(cfg)
+	t.Cleanup(func() {
+		jobQueue = originalQueue
+		setConfig(originalConfig)
+	})
+}
+
+func TestDrainAndShutdownWaitsForPrintJobs(t *testing.T) {
+	withTestQueue(t, 5)
+
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		printJobWithFunc(t, func() error {
+			<-release
+			return nil
+		})
+		close(finished)
+	}()
+	for jobQueue.Depth() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	result := make(chan error, 1)
+	go func() { result <- drainAndShutdown() }()
+
+	select {
+	case <-result:
+		t.Fatal("drainAndShutdown returned before the print job finished")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	<-finished
+	if err := <-result; err != nil {
+		t.Errorf("drainAndShutdown() error = %v", err)
+	}
+}
+
+func TestDrainAndShutdownTimeout(t *testing.T) {
+	withTestQueue(t, 1)
+
+	release := make(chan struct{})
+	defer close(release)
+	go printJobWithFunc(t, func() error {
+		<-release
+		return nil
+	})
+	for jobQueue.Depth() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	err := drainAndShutdown()
+	if err == nil || !strings.Contains(err.Error(), "still pending") {
+		t.Errorf("drainAndShutdown() error = %v, expected timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("drainAndShutdown took %v, expected about 1s", elapsed)
+	}
+}
+
+func TestPrintRejectedWhileDraining(t *testing.T) {
+	withTestQueue(t, 5)
+	stubPrinter(t, func(pdfPath, printerName string) error {
+		t.Error("printer must not be called while draining")
+		return nil
+	})
+	jobQueue.StopAccepting()
+
+	body := `{"items":[{"name":"a"}]}`
+	rec := serveWithRequestID(v1PrintPDFHandler, httptest.NewRequest("POST", "/v1/print-pdf", bytes.NewBufferString(body)))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+	if resp := decodeAPIError(t, rec); resp.Code != ErrCodeShuttingDown {
+		t.Errorf("code = %s, want %s", resp.Code, ErrCodeShuttingDown)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("Retry-After header missing")
+	}
+}
+
+// printJobWithFunc - 任意の処理を印刷ジョブとしてキューに投入
+func printJobWithFunc(t *testing.T, job func() error) {
+	t.Helper()
+	if err := jobQueue.Submit(context.Background(), job); err != nil {
+		t.Errorf("Submit() error = %v", err)
+	}
+}
@@ -1,15 +1,15 @@
 package main
 
 import (
-	"fmt"
-	"regexp"
 	"strings"
 )
 
 // TextWrapResult - テキスト折り返し結果
 type TextWrapResult struct {
-	Lines    []string
-	RowCount int
+	Lines       []string
+	RowCount    int
+	FontSizes   []float64        // 行ごとの文字サイズ（縮小した行のみ。0は列の文字サイズ）
+	Diagnostics []CellDiagnostic // 列幅に収まらず折り返し・縮小したセル
 }
 
 // wrapDetail - 摘要テキストを指定行数で折り返し
@@ -68,23 +68,21 @@ func wrapDetail(details []string, maxLen int) TextWrapResult {
 	}
 }
 
+// splitKukan - 区間テキストを正規化ルール（pdf.kukanRules）で正規化して区切り文字で分割
+func splitKukan(kukan string) []string {
+	return currentKukanNormalizer().split(kukan)
+}
+
 // wrapKukan - 区間テキストを指定行数で折り返し
 func wrapKukan(kukan string, maxLen int) TextWrapResult {
 	if kukan == "" {
 		return TextWrapResult{Lines: []string{""}, RowCount: 1}
 	}
 
-	// 特殊な文字列を置換
-	kukan = strings.ReplaceAll(kukan, "_九州外空車適用", "　九州外空車適用")
-	kukan = strings.ReplaceAll(kukan, "適用*   追加", "適用*　追加")
-	// 半角スペースを全角スペースに変換
-	kukan = strings.ReplaceAll(kukan, " ", "　")
-
-	// 区切り文字で分割 (全角スペース、｜、半角スペース+|、など)
-	re := regexp.MustCompile(`[　｜]| \||\|`)
-	parts := re.Split(kukan, -1)
+	parts := splitKukan(kukan)
 
 	var result []string
+	var diagnostics []CellDiagnostic
 	currentLine := ""
 	currentCount := 0
 
@@ -101,8 +99,16 @@ func wrapKukan(kukan string, maxLen int) TextWrapResult {
 			result = append(result, part)
 			currentCount = 0
 		} else if partLen > maxLen {
-			// 最大長を超える場合
-			result = append(result, "exceed*")
+			// 最大長を超える場合は文字単位で折り返す
+			if currentLine != "" {
+				result = append(result, currentLine)
+				currentLine = ""
+			}
+			diagnostics = append(diagnostics, CellDiagnostic{Column: "kukan", Row: len(result), Text: part, Action: cellWrapped})
+			runes := []rune(part)
+			for start := 0; start < len(runes); start += maxLen {
+				result = append(result, string(runes[start:min(start+maxLen, len(runes))]))
+			}
 			currentCount = 0
 		} else if currentCount+partLen+1 > maxLen {
 			// 現在行に追加すると最大長を超える場合
@@ -132,13 +138,121 @@ func wrapKukan(kukan string, maxLen int) TextWrapResult {
 	}
 
 	return TextWrapResult{
-		Lines:    result,
-		RowCount: len(result),
+		Lines:       result,
+		RowCount:    len(result),
+		Diagnostics: diagnostics,
+	}
+}
+
+// widthWrapper - 描画幅での折り返し結果を組み立てる
+type widthWrapper struct {
+	column string
+	col    ColumnConfig
+	m      textMeasurer
+	lines  []string
+	sizes  []float64
+	diags  []CellDiagnostic
+}
+
+// add - 列の文字サイズ（size が0）または縮小したサイズの行を追加
+func (w *widthWrapper) add(size float64, lines ...string) {
+	for _, line := range lines {
+		w.lines = append(w.lines, line)
+		w.sizes = append(w.sizes, size)
+	}
+}
+
+// overflow - 1区切りで幅を超えるテキストを列の設定に従って収めて追加
+// 続きをつなげられる場合（縮小せずに折り返した場合）は最後の行を確定せずに返す
+func (w *widthWrapper) overflow(text string) (rest string) {
+	lines, size, action := fitOverflow(text, w.col, w.m)
+	w.diags = append(w.diags, CellDiagnostic{Column: w.column, Row: len(w.lines), Text: text, Action: action, FontSize: size})
+	if size > 0 || len(lines) == 0 {
+		w.add(size, lines...)
+		return ""
+	}
+	w.add(0, lines[:len(lines)-1]...)
+	return lines[len(lines)-1]
+}
+
+func (w *widthWrapper) result() TextWrapResult {
+	res := TextWrapResult{Lines: w.lines, RowCount: len(w.lines), Diagnostics: w.diags}
+	for _, size := range w.sizes {
+		if size > 0 {
+			res.FontSizes = w.sizes
+			break
+		}
+	}
+	return res
+}
+
+// wrapDetailWidth - 摘要テキストを描画幅（mm）で折り返し
+// 項目は「、」でつなぎ、収まらない項目は次の行へ送る。1項目だけで幅を超える場合は col.Overflow に従う
+func wrapDetailWidth(details []string, col ColumnConfig, m textMeasurer) TextWrapResult {
+	w := widthWrapper{column: "detail", col: col, m: m}
+	currentLine := ""
+
+	for _, detail := range details {
+		if strings.TrimSpace(detail) == "" {
+			continue
+		}
+		if currentLine != "" && m.GetStringWidth(currentLine+"、"+detail) <= col.WidthMM {
+			currentLine += "、" + detail
+			continue
+		}
+		if currentLine != "" {
+			w.add(0, currentLine)
+			currentLine = ""
+		}
+		if m.GetStringWidth(detail) <= col.WidthMM {
+			currentLine = detail
+			continue
+		}
+		currentLine = w.overflow(detail)
+	}
+	if currentLine != "" {
+		w.add(0, currentLine)
 	}
+
+	return w.result()
 }
 
-// alignRows - 他のデータ項目を最大行数に合わせて配列を調整
-func alignRows(date, dest *string, price *int, vol *float64, maxRows int) ([]string, []string, []string, []string) {
+// wrapKukanWidth - 区間テキストを描画幅（mm）で折り返し
+// 区切り文字で分けた区間を全角スペースでつなぎ、収まらない区間は次の行へ送る。1区間だけで幅を超える場合は col.Overflow に従う
+func wrapKukanWidth(kukan string, col ColumnConfig, m textMeasurer) TextWrapResult {
+	if kukan == "" {
+		return TextWrapResult{Lines: []string{""}, RowCount: 1}
+	}
+
+	w := widthWrapper{column: "kukan", col: col, m: m}
+	currentLine := ""
+	for _, part := range splitKukan(kukan) {
+		switch {
+		case part == "":
+			continue
+		case m.GetStringWidth(part) > col.WidthMM:
+			if currentLine != "" {
+				w.add(0, currentLine)
+			}
+			currentLine = w.overflow(part)
+		case currentLine == "":
+			currentLine = part
+		case m.GetStringWidth(currentLine+"　"+part) <= col.WidthMM:
+			currentLine += "　" + part
+		default:
+			w.add(0, currentLine)
+			currentLine = part
+		}
+	}
+	if currentLine != "" || len(w.lines) == 0 {
+		w.add(0, currentLine)
+	}
+
+	return w.result()
+}
+
+// alignRows - 他のデータ項目を最大行数に合わせて配列を調整（日付は dateStyle の書式で整形）
+func alignRows(date, dest *string, price *int, vol *float64, maxRows int, dateStyle string) ([]string, []string, []string, []string) {
 	dateArr := make([]string, maxRows)
 	destArr := make([]string, maxRows)
 	priceArr := make([]string, maxRows)
@@ -146,17 +260,8 @@ func alignRows(date, dest *string, price *int, vol *float64, maxRows int) ([]str
 
 	// 最初の行に実際の値を設定
 	if date != nil {
-		// YYYY-MM-DD形式からMM/DD形式に変換
-		dateStr := *date
-		if len(dateStr) >= 10 && dateStr[4] == '-' && dateStr[7] == '-' {
-			// YYYY-MM-DD形式の場合
-			month := dateStr[5:7]
-			day := dateStr[8:10]
-			dateArr[0] = month + "/" + day
-		} else {
-			// そのまま使用
-			dateArr[0] = dateStr
-		}
+		// 解析できる日付は書式で整形（それ以外はそのまま使用）
+		dateArr[0] = formatDateText(*date, dateStyle)
 	}
 	if dest != nil {
 		destArr[0] = *dest
@@ -165,7 +270,7 @@ func alignRows(date, dest *string, price *int, vol *float64, maxRows int) ([]str
 		priceArr[0] = FormatPrice(*price)
 	}
 	if vol != nil {
-		volArr[0] = fmt.Sprintf("%.1f", *vol)
+		volArr[0] = quantityFromFloat(*vol).String()
 	}
 
 	// 残りの行は空文字列で埋める
@@ -220,6 +325,9 @@ type RyohiPrintData struct {
 	PriceLines  []string
 	VolLines    []string
 	MaxRows     int
+	DetailSizes []float64        // 摘要の行ごとの文字サイズ（0は列の文字サイズ）
+	KukanSizes  []float64        // 区間の行ごとの文字サイズ（0は列の文字サイズ）
+	Diagnostics []CellDiagnostic // 列幅に収まらず折り返し・縮小したセル
 }
 
 // hasContentInRow - 指定した行にコンテンツがあるかチェック
@@ -253,7 +361,18 @@ func (r *RyohiPrintData) hasContentInRow(row int) bool {
 	return false
 }
 
-// prepareRyohiForPrint - 旅費データを印刷用に準備
+// contentRows - コンテンツがある行数（空行は印字しないため数えない）
+func (r *RyohiPrintData) contentRows() int {
+	n := 0
+	for row := 0; row < r.MaxRows; row++ {
+		if r.hasContentInRow(row) {
+			n++
+		}
+	}
+	return n
+}
+
+// prepareRyohiForPrint - 旅費データを印刷用に準備（文字数で折り返し）
 func prepareRyohiForPrint(ryohi Ryohi, maxDetailLen, maxKukanLen int) RyohiPrintData {
 	// 摘要を折り返し
 	detailResult := TextWrapResult{Lines: []string{""}, RowCount: 1}
@@ -267,6 +386,29 @@ func prepareRyohiForPrint(ryohi Ryohi, maxDetailLen, maxKukanLen int) RyohiPrint
 		kukanResult = wrapKukan(*ryohi.Kukan, maxKukanLen)
 	}
 
+	return buildRyohiPrintData(ryohi, detailResult, kukanResult, DateStyleSlash)
+}
+
+// prepareRyohiForPrintWidth - 旅費データを印刷用に準備（描画幅で折り返し。日付は dateStyle の書式で整形）
+// detail・kukan はそれぞれの列の文字サイズ（detailCol.FontSize・kukanCol.FontSize）で測る
+func prepareRyohiForPrintWidth(ryohi Ryohi, detail textMeasurer, detailCol ColumnConfig, kukan textMeasurer, kukanCol ColumnConfig, dateStyle string) RyohiPrintData {
+	detailResult := TextWrapResult{Lines: []string{""}, RowCount: 1}
+	if len(ryohi.Detail) > 0 {
+		if wrapped := wrapDetailWidth(ryohi.Detail, detailCol, detail); wrapped.RowCount > 0 {
+			detailResult = wrapped
+		}
+	}
+
+	kukanResult := TextWrapResult{Lines: []string{""}, RowCount: 1}
+	if ryohi.Kukan != nil {
+		kukanResult = wrapKukanWidth(*ryohi.Kukan, kukanCol, kukan)
+	}
+
+	return buildRyohiPrintData(ryohi, detailResult, kukanResult, dateStyle)
+}
+
+// buildRyohiPrintData - 折り返した摘要・区間に他の項目の行数を合わせる
+func buildRyohiPrintData(ryohi Ryohi, detailResult, kukanResult TextWrapResult, dateStyle string) RyohiPrintData {
 	// 最大行数を決定
 	maxRows := detailResult.RowCount
 	if kukanResult.RowCount > maxRows {
@@ -275,7 +417,7 @@ func prepareRyohiForPrint(ryohi Ryohi, maxDetailLen, maxKukanLen int) RyohiPrint
 
 	// 他のデータを最大行数に合わせる
 	dateLines, destLines, priceLines, volLines := alignRows(
-		ryohi.Date, ryohi.Dest, ryohi.Price, ryohi.Vol, maxRows)
+		ryohi.Date, ryohi.Dest, ryohi.Price, ryohi.Vol, maxRows, dateStyle)
 
 	// すべての配列を最大行数に拡張
 	detailLines := extendToMaxRows(detailResult.Lines, maxRows)
@@ -289,5 +431,8 @@ func prepareRyohiForPrint(ryohi Ryohi, maxDetailLen, maxKukanLen int) RyohiPrint
 		PriceLines:  priceLines,
 		VolLines:    volLines,
 		MaxRows:     maxRows,
+		DetailSizes: detailResult.FontSizes,
+		KukanSizes:  kukanResult.FontSizes,
+		Diagnostics: append(detailResult.Diagnostics, kukanResult.Diagnostics...),
 	}
 }
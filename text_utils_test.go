@@ -110,8 +110,8 @@ func TestWrapKukan(t *testing.T) {
 			name:        "最大長超過",
 			kukan:       "非常に長い区間名称で最大長を超過するテスト",
 			maxLen:      5,
-			expectLines: []string{"exceed*"},
-			expectRows:  1,
+			expectLines: []string{"非常に長い", "区間名称で", "最大長を超", "過するテス", "ト"},
+			expectRows:  5,
 		},
 	}
 
@@ -181,7 +181,7 @@ func TestAlignRows(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			dateArr, destArr, priceArr, volArr := alignRows(tt.date, tt.dest, tt.price, tt.vol, tt.maxRows)
+			dateArr, destArr, priceArr, volArr := alignRows(tt.date, tt.dest, tt.price, tt.vol, tt.maxRows, DateStyleSlash)
 
 			if !reflect.DeepEqual(dateArr, tt.expectDate) {
 				t.Errorf("alignRows() dateArr = %v, expected %v", dateArr, tt.expectDate)
@@ -359,3 +359,138 @@ func TestIntegrationRealData(t *testing.T) {
 		t.Errorf("First price line should be '15,000', got '%s'", result.PriceLines[0])
 	}
 }
+
+// testColumn - 全角 3.5mm（monoMeasurer）を10ptとした列の設定
+func testColumn(width float64, overflow string) ColumnConfig {
+	return ColumnConfig{WidthMM: width, FontSize: 10, Overflow: overflow, MinFontSize: 6}
+}
+
+func TestWrapDetailWidth(t *testing.T) {
+	tests := []struct {
+		name        string
+		details     []string
+		col         ColumnConfig
+		want        []string
+		wantSizes   []float64
+		wantActions []string
+	}{
+		{name: "空", details: nil, col: testColumn(38, OverflowWrap), want: nil},
+		{name: "1行に収まる", details: []string{"会議", "資料作成"}, col: testColumn(38, OverflowWrap), want: []string{"会議、資料作成"}},
+		{name: "項目単位で次の行へ", details: []string{"会議", "研修", "営業活動", "資料作成"}, col: testColumn(28, OverflowWrap), want: []string{"会議、研修", "営業活動", "資料作成"}},
+		{name: "半角を含むと多く入る", details: []string{"ETC 1200円", "駐車場 800円"}, col: testColumn(42, OverflowWrap), want: []string{"ETC 1200円、駐車場 800円"}},
+		{name: "長い項目は折り返して続ける", details: []string{"取引先との打ち合わせ", "研修"}, col: testColumn(24.5, OverflowWrap), want: []string{"取引先との打ち", "合わせ、研修"}, wantActions: []string{cellWrapped}},
+		{name: "長い項目を縮小", details: []string{"会議", "取引先との打ち合わせ", "研修"}, col: testColumn(28, OverflowShrink), want: []string{"会議", "取引先との打ち合わせ", "研修"}, wantSizes: []float64{0, 8, 0}, wantActions: []string{cellShrunk}},
+		{name: "最小サイズでも収まらない", details: []string{"取引先との打ち合わせ"}, col: testColumn(14, OverflowShrink), want: []string{"取引先との打", "ち合わせ"}, wantSizes: []float64{6, 6}, wantActions: []string{cellShrunkWrapped}},
+		{name: "空の項目は無視", details: []string{"", "会議", "  "}, col: testColumn(38, OverflowWrap), want: []string{"会議"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := wrapDetailWidth(tt.details, tt.col, monoMeasurer{})
+			if !reflect.DeepEqual(got.Lines, tt.want) || got.RowCount != len(tt.want) {
+				t.Errorf("wrapDetailWidth() = %q (%d rows), want %q", got.Lines, got.RowCount, tt.want)
+			}
+			if !reflect.DeepEqual(got.FontSizes, tt.wantSizes) {
+				t.Errorf("FontSizes = %v, want %v", got.FontSizes, tt.wantSizes)
+			}
+			if actions := diagnosticActions(got.Diagnostics); !reflect.DeepEqual(actions, tt.wantActions) {
+				t.Errorf("diagnostics = %v, want %v", actions, tt.wantActions)
+			}
+		})
+	}
+}
+
+func TestWrapKukanWidth(t *testing.T) {
+	tests := []struct {
+		name      string
+		kukan     string
+		col       ColumnConfig
+		want      []string
+		wantSizes []float64
+		wantDiag  *CellDiagnostic
+	}{
+		{name: "空", kukan: "", col: testColumn(73, OverflowShrink), want: []string{""}},
+		{name: "1行", kukan: "東京駅　新横浜駅　名古屋駅", col: testColumn(73, OverflowShrink), want: []string{"東京駅　新横浜駅　名古屋駅"}},
+		{name: "区間単位で折り返し", kukan: "東京駅　新横浜駅　名古屋駅", col: testColumn(28, OverflowShrink), want: []string{"東京駅　新横浜駅", "名古屋駅"}},
+		{name: "区切り文字の正規化", kukan: "長崎|佐世保 ｜ 福岡", col: testColumn(73, OverflowShrink), want: []string{"長崎　佐世保　福岡"}},
+		{
+			name: "幅を超える区間を折り返し", kukan: "東京　新横浜駅から名古屋駅　大阪", col: testColumn(28, OverflowWrap),
+			want:     []string{"東京", "新横浜駅から名古", "屋駅　大阪"},
+			wantDiag: &CellDiagnostic{Column: "kukan", Row: 1, Text: "新横浜駅から名古屋駅", Action: cellWrapped},
+		},
+		{
+			name: "幅を超える区間を縮小", kukan: "東京　新横浜駅から名古屋駅　大阪", col: testColumn(28, OverflowShrink),
+			want: []string{"東京", "新横浜駅から名古屋駅", "大阪"}, wantSizes: []float64{0, 8, 0},
+			wantDiag: &CellDiagnostic{Column: "kukan", Row: 1, Text: "新横浜駅から名古屋駅", Action: cellShrunk, FontSize: 8},
+		},
+		{
+			name: "最小サイズで折り返し", kukan: "新横浜駅から名古屋駅", col: testColumn(14, OverflowShrink),
+			want: []string{"新横浜駅から", "名古屋駅"}, wantSizes: []float64{6, 6},
+			wantDiag: &CellDiagnostic{Column: "kukan", Row: 0, Text: "新横浜駅から名古屋駅", Action: cellShrunkWrapped, FontSize: 6},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := wrapKukanWidth(tt.kukan, tt.col, monoMeasurer{})
+			if !reflect.DeepEqual(got.Lines, tt.want) || got.RowCount != len(tt.want) {
+				t.Errorf("wrapKukanWidth(%q) = %q (%d rows), want %q", tt.kukan, got.Lines, got.RowCount, tt.want)
+			}
+			if !reflect.DeepEqual(got.FontSizes, tt.wantSizes) {
+				t.Errorf("FontSizes = %v, want %v", got.FontSizes, tt.wantSizes)
+			}
+			if tt.wantDiag == nil {
+				if len(got.Diagnostics) != 0 {
+					t.Errorf("unexpected diagnostics: %+v", got.Diagnostics)
+				}
+			} else if len(got.Diagnostics) != 1 || got.Diagnostics[0] != *tt.wantDiag {
+				t.Errorf("diagnostics = %+v, want %+v", got.Diagnostics, *tt.wantDiag)
+			}
+			for _, line := range got.Lines {
+				if line == "exceed*" {
+					t.Error("placeholder must not be printed")
+				}
+			}
+		})
+	}
+}
+
+// diagnosticActions - 診断の処理内容だけを取り出す
+func diagnosticActions(diags []CellDiagnostic) []string {
+	var actions []string
+	for _, d := range diags {
+		actions = append(actions, d.Action)
+	}
+	return actions
+}
+
+func TestPrepareRyohiForPrintWidth(t *testing.T) {
+	ryohi := Ryohi{
+		Date:   StringPtr("2024-12-17"),
+		Dest:   StringPtr("福岡"),
+		Detail: []string{"会議", "研修", "営業活動", "資料作成"},
+		Kukan:  StringPtr("長崎　佐世保から博多までの高速バス"),
+		Price:  IntPtr(3000),
+	}
+	got := prepareRyohiForPrintWidth(ryohi, monoMeasurer{}, testColumn(28, OverflowWrap), monoMeasurer{}, testColumn(35, OverflowShrink), DateStyleSlash)
+	if got.MaxRows != 3 {
+		t.Fatalf("MaxRows = %d, want 3", got.MaxRows)
+	}
+	for name, lines := range map[string][]string{"date": got.DateLines, "detail": got.DetailLines, "kukan": got.KukanLines, "price": got.PriceLines} {
+		if len(lines) != 3 {
+			t.Errorf("%s has %d lines, want 3", name, len(lines))
+		}
+	}
+	if got.DateLines[0] != "12/17" || got.KukanLines[1] != "佐世保から博多までの高速バス" || got.DetailLines[2] != "資料作成" {
+		t.Errorf("unexpected print data: %+v", got)
+	}
+	if size := lineFontSize(got.KukanSizes, 1, 10); size != 7.1 {
+		t.Errorf("kukan row 1 font size = %v, want 7.1", size)
+	}
+	if size := lineFontSize(got.KukanSizes, 2, 10); size != 10 {
+		t.Errorf("kukan row 2 font size = %v, want column size", size)
+	}
+	if len(got.Diagnostics) != 1 || got.Diagnostics[0].Action != cellShrunk {
+		t.Errorf("diagnostics = %+v, want one shrunk kukan cell", got.Diagnostics)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// 描画幅（mm）による折り返し
+// 文字数ではなく実際のフォントで測った幅で折り返すため、半角数字と全角漢字が混在しても列に収まる
+
+// textMeasurer - 文字列の描画幅（mm）を測る（*gofpdf.Fpdf は現在のフォントで測る）
+type textMeasurer interface {
+	GetStringWidth(s string) float64
+}
+
+// fontSizeMeasurer - 指定した文字サイズに切り替えてから測る
+type fontSizeMeasurer struct {
+	pdf  *gofpdf.Fpdf
+	size float64
+}
+
+func (m fontSizeMeasurer) GetStringWidth(s string) float64 {
+	m.pdf.SetFontSize(m.size)
+	return m.pdf.GetStringWidth(s)
+}
+
+// 禁則処理: 行頭に置かない文字（句読点・閉じ括弧・小書き仮名・長音など）
+const kinsokuNoStart = "、。，．・：；？！゛゜ー―‐〜～…‥）〕］｝〉》」』】〙〗ヽヾゝゞ々〻" +
+	"ぁぃぅぇぉっゃゅょゎゕゖァィゥェォッャュョヮヵヶㇰㇱㇲㇳㇴㇵㇶㇷㇸㇹㇺㇻㇼㇽㇾㇿ" +
+	",.:;?!)]}%％"
+
+// 禁則処理: 行末に置かない文字（開き括弧など）
+const kinsokuNoEnd = "（〔［｛〈《「『【〘〖([{￥＄"
+
+func isKinsokuNoStart(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return strings.ContainsRune(kinsokuNoStart, r)
+}
+
+func isKinsokuNoEnd(s string) bool {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return strings.ContainsRune(kinsokuNoEnd, r)
+}
+
+// isWrapSpace - 行頭・行末から取り除く空白
+func isWrapSpace(r rune) bool {
+	return r == ' ' || r == '　'
+}
+
+// splitWrapTokens - 折り返しの単位に分割（半角英数字の連続は1単位、それ以外は1文字ずつ）
+func splitWrapTokens(text string) []string {
+	var tokens []string
+	word := -1 // 半角英数字の連続の開始位置
+	for i, r := range text {
+		isWord := r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r))
+		if isWord {
+			if word < 0 {
+				word = i
+			}
+			continue
+		}
+		if word >= 0 {
+			tokens = append(tokens, text[word:i])
+			word = -1
+		}
+		tokens = append(tokens, string(r))
+	}
+	if word >= 0 {
+		tokens = append(tokens, text[word:])
+	}
+	return tokens
+}
+
+// wrapTextWidth - 描画幅が maxWidth（mm）以下になるよう折り返す
+// 行頭禁則文字は前の文字と一緒に次の行へ送り、行末禁則文字は次の行へ送る（追い出し）
+// 1文字でも幅を超える場合はその文字だけで1行にする
+func wrapTextWidth(text string, maxWidth float64, m textMeasurer) []string {
+	if text == "" {
+		return nil
+	}
+	queue := splitWrapTokens(text)
+	var lines []string
+	var line []string
+
+	for len(queue) > 0 {
+		token := queue[0]
+		queue = queue[1:]
+		if len(line) == 0 && strings.TrimFunc(token, isWrapSpace) == "" {
+			continue // 行頭の空白は詰める
+		}
+		candidate := strings.Join(line, "") + token
+		if m.GetStringWidth(strings.TrimRightFunc(candidate, isWrapSpace)) <= maxWidth {
+			line = append(line, token)
+			continue
+		}
+		// 英数字の連続が1行に収まらない場合は1文字ずつに分ける
+		if utf8.RuneCountInString(token) > 1 && m.GetStringWidth(token) > maxWidth {
+			queue = append(strings.Split(token, ""), queue...)
+			continue
+		}
+		if len(line) == 0 {
+			lines = append(lines, token)
+			continue
+		}
+
+		// 禁則処理: 次の行の先頭が行頭禁則文字、または現在行の末尾が行末禁則文字の間は次の行へ送る
+		next := []string{token}
+		for len(line) > 1 && (isKinsokuNoStart(next[0]) || isKinsokuNoEnd(line[len(line)-1])) {
+			next = append([]string{line[len(line)-1]}, next...)
+			line = line[:len(line)-1]
+		}
+		lines = append(lines, strings.Join(line, ""))
+		line = nil
+		queue = append(next, queue...)
+	}
+	if len(line) > 0 {
+		lines = append(lines, strings.Join(line, ""))
+	}
+
+	for i, l := range lines {
+		lines[i] = strings.TrimFunc(l, isWrapSpace)
+	}
+	return lines
+}
+
+// 列幅を超えるテキストの扱い（pdf.*.overflow）
+const (
+	OverflowWrap   = "wrap"   // 文字単位で折り返す（禁則処理あり）
+	OverflowShrink = "shrink" // 文字サイズを縮小して1行に収める（最小サイズでも収まらない場合は最小サイズで折り返す）
+)
+
+// CellDiagnostics の処理内容
+const (
+	cellWrapped       = "wrapped"        // 文字単位で折り返した
+	cellShrunk        = "shrunk"         // 文字サイズを縮小した
+	cellShrunkWrapped = "shrunk+wrapped" // 最小サイズに縮小しても収まらず折り返した
+)
+
+// CellDiagnostic - 列幅に収まらなかったセルと、その処理内容
+type CellDiagnostic struct {
+	Column   string  // 列（detail / kukan）
+	Row      int     // 折り返し後の最初の行（0始まり）
+	Text     string  // 収まらなかったテキスト
+	Action   string  // wrapped / shrunk / shrunk+wrapped
+	FontSize float64 // 縮小後の文字サイズ（縮小しなかった場合は0）
+}
+
+// fitOverflow - 1区切りで列幅を超えるテキストを列の設定に従って収める
+// m は列の文字サイズ（col.FontSize）で測る。size は各行の文字サイズ（縮小しない場合は0）
+func fitOverflow(text string, col ColumnConfig, m textMeasurer) (lines []string, size float64, action string) {
+	if col.Overflow != OverflowShrink {
+		return wrapTextWidth(text, col.WidthMM, m), 0, cellWrapped
+	}
+
+	// 描画幅は文字サイズに比例するので、収まるサイズを計算して0.1pt単位で切り捨てる
+	size = math.Floor(col.FontSize*col.WidthMM/m.GetStringWidth(text)*10) / 10
+	if size >= col.MinFontSize {
+		return []string{text}, size, cellShrunk
+	}
+	// 最小サイズで折り返す（最小サイズでの幅 maxWidth は、列のサイズでは maxWidth*FontSize/MinFontSize）
+	return wrapTextWidth(text, col.WidthMM*col.FontSize/col.MinFontSize, m), col.MinFontSize, cellShrunkWrapped
+}
+
+// lineFontSize - 行の文字サイズ（指定がない場合は列の文字サイズ）
+func lineFontSize(sizes []float64, row int, def float64) float64 {
+	if row < len(sizes) && sizes[row] > 0 {
+		return sizes[row]
+	}
+	return def
+}
+
+// cellColumnNames - 診断メッセージに表示する列名
+var cellColumnNames = map[string]string{"detail": "摘要", "kukan": "区間"}
+
+// String - ログに記録する説明
+func (d CellDiagnostic) String() string {
+	var action string
+	switch d.Action {
+	case cellShrunk:
+		action = fmt.Sprintf("文字サイズを%.1fptに縮小しました", d.FontSize)
+	case cellShrunkWrapped:
+		action = fmt.Sprintf("最小の文字サイズ%.1fptで折り返しました", d.FontSize)
+	default:
+		action = "文字単位で折り返しました"
+	}
+	return fmt.Sprintf("%sが列幅に収まらないため%s（%d行目）: %q", cellColumnNames[d.Column], action, d.Row+1, d.Text)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// monoMeasurer - 全角 3.5mm、半角 1.75mm として測る（10ptの明朝体に近い）
+type monoMeasurer struct{}
+
+func (monoMeasurer) GetStringWidth(s string) float64 {
+	width := 0.0
+	for _, r := range s {
+		if r < utf8.RuneSelf || (r >= 0xFF61 && r <= 0xFF9F) {
+			width += 1.75
+		} else {
+			width += 3.5
+		}
+	}
+	return width
+}
+
+func TestWrapTextWidth(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxWidth float64
+		want     []string
+	}{
+		{name: "空文字", text: "", maxWidth: 35, want: nil},
+		{name: "収まる", text: "会議資料作成", maxWidth: 35, want: []string{"会議資料作成"}},
+		{name: "全角で折り返し", text: "東京本社にて打ち合わせ会議", maxWidth: 35, want: []string{"東京本社にて打ち合わ", "せ会議"}},
+		{name: "半角数字は全角の半分の幅", text: "12345678901234567890", maxWidth: 35, want: []string{"12345678901234567890"}},
+		{name: "行頭の句点は前の文字と一緒に送る", text: "東京駅で会議をしました。", maxWidth: 38.5, want: []string{"東京駅で会議をしまし", "た。"}},
+		{name: "行頭の読点", text: "東京、大阪、名古屋、福岡", maxWidth: 17.5, want: []string{"東京、大", "阪、名古", "屋、福岡"}},
+		{name: "行頭の閉じ括弧", text: "会議（東京）ショップ", maxWidth: 17.5, want: []string{"会議（東", "京）ショッ", "プ"}},
+		{name: "行末の開き括弧は次の行へ", text: "会議資料作成（東京）", maxWidth: 24.5, want: []string{"会議資料作成", "（東京）"}},
+		{name: "英数字の連続は分けない", text: "会議ABC123", maxWidth: 14, want: []string{"会議", "ABC123"}},
+		{name: "幅を超える英数字は1文字ずつ", text: "ABCDEFGHIJ", maxWidth: 7, want: []string{"ABCD", "EFGH", "IJ"}},
+		{name: "行頭・行末の空白を詰める", text: "東京　大阪　名古屋", maxWidth: 10.5, want: []string{"東京", "大阪", "名古屋"}},
+		{name: "1文字も収まらない", text: "東京", maxWidth: 2, want: []string{"東", "京"}},
+	}
+
+	m := monoMeasurer{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := wrapTextWidth(tt.text, tt.maxWidth, m)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("wrapTextWidth(%q, %v) = %q, want %q", tt.text, tt.maxWidth, got, tt.want)
+			}
+			for _, line := range got {
+				if utf8.RuneCountInString(line) > 1 && m.GetStringWidth(line) > tt.maxWidth {
+					t.Errorf("line %q is wider than %vmm", line, tt.maxWidth)
+				}
+			}
+		})
+	}
+}
+
+func TestFontSizeMeasurer(t *testing.T) {
+	pdf := gofpdf.New("L", "mm", "A5", "")
+	pdf.SetFont("Helvetica", "", 10)
+	small := fontSizeMeasurer{pdf, 10}.GetStringWidth("12345")
+	large := fontSizeMeasurer{pdf, 20}.GetStringWidth("12345")
+	if small <= 0 || large < small*1.99 || large > small*2.01 {
+		t.Errorf("width at 10pt = %v, at 20pt = %v; want doubled", small, large)
+	}
+}
+
+func TestCellDiagnosticString(t *testing.T) {
+	tests := []struct {
+		diag CellDiagnostic
+		want string
+	}{
+		{CellDiagnostic{Column: "kukan", Row: 1, Text: "新横浜駅から名古屋駅", Action: cellShrunk, FontSize: 8}, `区間が列幅に収まらないため文字サイズを8.0ptに縮小しました（2行目）: "新横浜駅から名古屋駅"`},
+		{CellDiagnostic{Column: "kukan", Text: "新横浜駅から名古屋駅", Action: cellShrunkWrapped, FontSize: 6}, `区間が列幅に収まらないため最小の文字サイズ6.0ptで折り返しました（1行目）: "新横浜駅から名古屋駅"`},
+		{CellDiagnostic{Column: "detail", Row: 2, Text: "取引先との打ち合わせ", Action: cellWrapped}, `摘要が列幅に収まらないため文字単位で折り返しました（3行目）: "取引先との打ち合わせ"`},
+	}
+	for _, tt := range tests {
+		if got := tt.diag.String(); got != tt.want {
+			t.Errorf("String() = %s, want %s", got, tt.want)
+		}
+	}
+}
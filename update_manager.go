@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// 管理APIのエラー（api_errors.go のエラーコードに対応）
+var (
+	errUpdateInProgress  = errors.New("アップデート処理中です")
+	errReleaseNotFound   = errors.New("指定されたバージョンのリリースが見つかりません")
+	errNoUpdateAvailable = errors.New("適用できる新しいバージョンがありません")
+	errNoRollback        = errors.New("ロールバックできる前のバージョンがありません")
+	errDevBuild          = errors.New("開発ビルドはアップデートできません")
+	// errSelfUpdateUnsupported - 自己アップデートはWindowsのみ対応
+	errSelfUpdateUnsupported = errors.New("このプラットフォームでは自己アップデートに対応していません")
+)
+
+// updateManager - 自動アップデートと管理APIで共有するアップデート状態
+// 適用・ロールバックは同時に1つだけ実行し、成功した場合はプロセス終了まで busy のまま
+type updateManager struct {
+	mu          sync.Mutex
+	busy        bool
+	lastChecked time.Time
+	latest      *Release // 最後のチェックで見つかった適用可能なリリース
+	lastError   string
+}
+
+var updates = &updateManager{}
+
+// Status - 現在のアップデート状態
+func (m *updateManager) Status() UpdateStatusResponse {
+	cfg := currentConfig().Update
+	status := UpdateStatusResponse{
+		CurrentVersion: Version,
+		Channel:        cfg.Channel,
+		PinnedVersion:  cfg.PinnedVersion,
+		Source:         cfg.Source.Type,
+		AutoUpdate:     cfg.Enabled && Version != "dev" && selfUpdateSupported,
+	}
+	if source, err := newReleaseSource(cfg.Source); err == nil {
+		status.Source = source.String()
+	}
+	if exe, err := executablePath(); err == nil {
+		if _, err := os.Stat(exe + ".old"); err == nil {
+			status.RollbackAvailable = true
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status.InProgress = m.busy
+	status.LastError = m.lastError
+	if m.latest != nil {
+		status.LatestVersion = StringPtr(m.latest.Version)
+		status.UpdateAvailable = true
+	}
+	if !m.lastChecked.IsZero() {
+		status.LastChecked = StringPtr(m.lastChecked.Format(time.RFC3339))
+	}
+	return status
+}
+
+// Check - 設定されたアップデート元で新しいバージョンを確認
+func (m *updateManager) Check(ctx context.Context) error {
+	if Version == "dev" {
+		return errDevBuild
+	}
+	cfg := currentConfig().Update
+	source, err := newReleaseSource(cfg.Source)
+	if err != nil {
+		return err
+	}
+	_, _, err = m.check(ctx, source, cfg)
+	return err
+}
+
+// check - チェック結果を記録して返す
+func (m *updateManager) check(ctx context.Context, source ReleaseSource, cfg UpdateConfig) (Release, bool, error) {
+	release, found, err := findAvailableUpdate(ctx, source, cfg)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastChecked = time.Now()
+	if err != nil {
+		m.lastError = err.Error()
+		return release, found, err
+	}
+	m.lastError = ""
+	m.latest = nil
+	if found {
+		m.latest = &release
+	}
+	return release, found, nil
+}
+
+// Apply - 指定バージョン（空の場合はチャンネルに従った最新版）を展開して置換用スクリプトを起動
+// 成功後は呼び出し側が restartForUpdate でプロセスを終了すること
+func (m *updateManager) Apply(ctx context.Context, version string) (Release, error) {
+	if !selfUpdateSupported {
+		return Release{}, errSelfUpdateUnsupported
+	}
+	if Version == "dev" {
+		return Release{}, errDevBuild
+	}
+	cfg := currentConfig().Update
+	source, err := newReleaseSource(cfg.Source)
+	if err != nil {
+		return Release{}, err
+	}
+
+	var release Release
+	if version == "" {
+		var found bool
+		if release, found, err = m.check(ctx, source, cfg); err != nil {
+			return Release{}, err
+		}
+		if !found {
+			return Release{}, errNoUpdateAvailable
+		}
+	} else if release, err = findRelease(ctx, source, version); err != nil {
+		return Release{}, err
+	}
+
+	return release, m.apply(ctx, source, release)
+}
+
+// apply - リリースを適用（同時実行は不可）
+func (m *updateManager) apply(ctx context.Context, source ReleaseSource, release Release) error {
+	if err := m.begin(); err != nil {
+		return err
+	}
+	writeEventLog("INFO", fmt.Sprintf("アップデートを適用します: %s -> %s", Version, release.Version))
+	err := performUpdate(ctx, source, release)
+	m.finish(err)
+	return err
+}
+
+// Rollback - 前のバージョン（.old）に戻すスクリプトを起動
+// 成功後は呼び出し側が restartForUpdate でプロセスを終了すること
+func (m *updateManager) Rollback() error {
+	if !selfUpdateSupported {
+		return errSelfUpdateUnsupported
+	}
+	if err := m.begin(); err != nil {
+		return err
+	}
+	oldExe, err := rollbackUpdate()
+	if err == nil {
+		writeEventLog("INFO", fmt.Sprintf("前のバージョンへのロールバックを開始します: %s", oldExe))
+	}
+	m.finish(err)
+	return err
+}
+
+// begin - 処理中でなければ処理中にする
+func (m *updateManager) begin() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.busy {
+		return errUpdateInProgress
+	}
+	m.busy = true
+	return nil
+}
+
+// finish - 失敗した場合のみ処理中を解除（成功時はプロセス終了まで新たな処理を受け付けない）
+func (m *updateManager) finish(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		m.busy = false
+		m.lastError = err.Error()
+	}
+}
+
+// findRelease - 指定したバージョンのリリースを探す（古いバージョンへの切り替えも可能）
+func findRelease(ctx context.Context, source ReleaseSource, version string) (Release, error) {
+	target, err := parseSemVer(version)
+	if err != nil {
+		return Release{}, err
+	}
+	if current, err := parseSemVer(Version); err == nil && current.Compare(target) == 0 {
+		return Release{}, fmt.Errorf("%w: %s は実行中のバージョンです", errNoUpdateAvailable, Version)
+	}
+
+	releases, err := source.Releases(ctx)
+	if err != nil {
+		return Release{}, err
+	}
+	for _, release := range releases {
+		if release.Draft {
+			continue
+		}
+		if v, err := parseSemVer(release.Version); err == nil && v.Compare(target) == 0 {
+			return release, nil
+		}
+	}
+	return Release{}, fmt.Errorf("%w: %s", errReleaseNotFound, version)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// updateTestEnv - 管理API・アップデートのテスト環境
+type updateTestEnv struct {
+	exe       string // 差し替えた実行ファイルのパス
+	script    string // 起動されたバッチファイル（未起動なら空）
+	restarted int    // scheduleRestart の呼び出し回数
+}
+
+// withUpdateEnv - ディレクトリのアップデート元・実行ファイル・再起動をテスト用に差し替える
+func withUpdateEnv(t *testing.T, version string) *updateTestEnv {
+	t.Helper()
+	set := newTestReleaseSet(t)
+	stubPublicKey(t, set.publicKey)
+
+	releaseDir := t.TempDir()
+	for name, data := range set.files {
+		if err := os.WriteFile(filepath.Join(releaseDir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	env := &updateTestEnv{exe: filepath.Join(t.TempDir(), "print_pdf.exe")}
+	os.WriteFile(env.exe, []byte("print_pdf "+version), 0755)
+
+	originalConfig, originalVersion, originalUpdates := currentConfig(), Version, updates
+	originalExe, originalStart, originalRestart := executablePath, startUpdateScript, scheduleRestart
+	originalSupported := selfUpdateSupported
+	cfg := originalConfig
+	cfg.Update.Source = UpdateSourceConfig{Type: UpdateSourceDir, Path: releaseDir}
+	cfg.Update.Channel = UpdateChannelStable
+	cfg.Admin.Token = "secret"
+	setConfig(cfg)
+	Version = version
+	updates = &updateManager{}
+	selfUpdateSupported = true
+	executablePath = func() (string, error) { return env.exe, nil }
+	startUpdateScript = func(batchFile string) error {
+		env.script = batchFile
+		return nil
+	}
+	scheduleRestart = func() { env.restarted++ }
+	t.Cleanup(func() {
+		setConfig(originalConfig)
+		Version, updates = originalVersion, originalUpdates
+		executablePath, startUpdateScript, scheduleRestart = originalExe, originalStart, originalRestart
+		selfUpdateSupported = originalSupported
+	})
+	return env
+}
+
+func TestUpdateManagerApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		version string
+		want    string
+		wantErr error
+	}{
+		{name: "latest for channel", current: "v1.1.0", want: "v1.2.0"},
+		{name: "explicit prerelease", current: "v1.1.0", version: "v1.3.0-beta.1", want: "v1.3.0-beta.1"},
+		{name: "explicit older version", current: "v1.2.0", version: "1.1.0", want: "v1.1.0"},
+		{name: "already latest", current: "v1.2.0", wantErr: errNoUpdateAvailable},
+		{name: "running version", current: "v1.1.0", version: "v1.1.0", wantErr: errNoUpdateAvailable},
+		{name: "unknown version", current: "v1.1.0", version: "v9.9.9", wantErr: errReleaseNotFound},
+		{name: "development build", current: "dev", wantErr: errDevBuild},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := withUpdateEnv(t, tt.current)
+
+			release, err := updates.Apply(context.Background(), tt.version)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Apply error = %v, want %v", err, tt.wantErr)
+				}
+				if env.script != "" {
+					t.Error("update script must not run")
+				}
+				if updates.Status().InProgress {
+					t.Error("failed apply must not leave the update in progress")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if release.Version != tt.want {
+				t.Errorf("applied %s, want %s", release.Version, tt.want)
+			}
+			if data, _ := os.ReadFile(env.exe + ".new"); string(data) != "print_pdf "+tt.want {
+				t.Errorf("staged executable = %q", data)
+			}
+			if env.script == "" {
+				t.Error("update script was not started")
+			}
+			// 再起動するまで次の適用は受け付けない
+			if _, err := updates.Apply(context.Background(), tt.version); !errors.Is(err, errUpdateInProgress) {
+				t.Errorf("second Apply error = %v, want %v", err, errUpdateInProgress)
+			}
+		})
+	}
+}
+
+func TestUpdateManagerStatus(t *testing.T) {
+	withUpdateEnv(t, "v1.1.0")
+
+	status := updates.Status()
+	if status.CurrentVersion != "v1.1.0" || status.LastChecked != nil || status.LatestVersion != nil || status.UpdateAvailable {
+		t.Fatalf("status before check = %+v", status)
+	}
+	if !strings.HasPrefix(status.Source, "ディレクトリ ") {
+		t.Errorf("source = %q", status.Source)
+	}
+
+	if err := updates.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	status = updates.Status()
+	if status.LatestVersion == nil || *status.LatestVersion != "v1.2.0" || !status.UpdateAvailable || status.LastChecked == nil {
+		t.Errorf("status after check = %+v", status)
+	}
+	if status.RollbackAvailable {
+		t.Error("rollback must not be available without a previous executable")
+	}
+}
+
+func TestUpdateManagerRollback(t *testing.T) {
+	env := withUpdateEnv(t, "v1.2.0")
+
+	if err := updates.Rollback(); !errors.Is(err, errNoRollback) {
+		t.Fatalf("Rollback without .old error = %v", err)
+	}
+
+	os.WriteFile(env.exe+".old", []byte("print_pdf v1.1.0"), 0755)
+	if !updates.Status().RollbackAvailable {
+		t.Error("rollback should be available")
+	}
+	if err := updates.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(env.script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`move /Y "` + env.exe + `" "` + env.exe + `.rolledback"`,
+		`copy /Y "` + env.exe + `.old" "` + env.exe + `"`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("rollback script does not contain %q", want)
+		}
+	}
+	if err := updates.Rollback(); !errors.Is(err, errUpdateInProgress) {
+		t.Errorf("second Rollback error = %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// アップデートチャンネル
+const (
+	UpdateChannelStable = "stable" // 正式リリースのみ
+	UpdateChannelBeta   = "beta"   // プレリリースを含む
+	UpdateChannelPinned = "pinned" // pinnedVersion で指定したバージョンのみ
+)
+
+// selectRelease - チャンネルに従って適用すべきリリースを選ぶ（現在より新しいもののみ）
+func selectRelease(releases []Release, current semVersion, cfg UpdateConfig) (Release, bool, error) {
+	var pinned semVersion
+	if cfg.Channel == UpdateChannelPinned {
+		var err error
+		if pinned, err = parseSemVer(cfg.PinnedVersion); err != nil {
+			return Release{}, false, fmt.Errorf("pinnedVersion が不正です: %v", err)
+		}
+	}
+
+	var best Release
+	var bestVersion semVersion
+	found := false
+	for _, release := range releases {
+		if release.Draft {
+			continue
+		}
+		version, err := parseSemVer(release.Version)
+		if err != nil {
+			continue // バージョン形式でないタグは無視
+		}
+
+		switch cfg.Channel {
+		case UpdateChannelPinned:
+			if version.Compare(pinned) != 0 {
+				continue
+			}
+		case UpdateChannelBeta:
+		default:
+			if release.Prerelease || version.IsPrerelease() {
+				continue
+			}
+		}
+
+		// 前方向（新しいバージョン）への更新のみ
+		if version.Compare(current) <= 0 {
+			continue
+		}
+		if !found || version.Compare(bestVersion) > 0 {
+			best, bestVersion, found = release, version, true
+		}
+	}
+	return best, found, nil
+}
+
+// MaintenanceWindow - アップデートを適用してよい時間帯（ローカル時刻）
+type MaintenanceWindow struct {
+	Start string   `json:"start"` // "HH:MM"（空の場合は常に適用可能）
+	End   string   `json:"end"`   // "HH:MM"（Start より前なら日付をまたぐ）
+	Days  []string `json:"days"`  // 曜日 "Mon".."Sun"（空の場合は毎日）。日付をまたぐ場合は開始日の曜日
+}
+
+// Contains - 指定時刻がメンテナンス時間内かどうか
+func (w MaintenanceWindow) Contains(now time.Time) (bool, error) {
+	if w.Start == "" && w.End == "" {
+		return true, nil
+	}
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return false, err
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return false, err
+	}
+
+	minute := now.Hour()*60 + now.Minute()
+	day := now.Weekday()
+	var inside bool
+	switch {
+	case start == end:
+		inside = true // 終日
+	case start < end:
+		inside = minute >= start && minute < end
+	default:
+		// 日付をまたぐ（例: 22:00-05:00）。翌日側は前日の曜日で判定
+		if minute >= start {
+			inside = true
+		} else if minute < end {
+			inside = true
+			day = (day + 6) % 7
+		}
+	}
+	if !inside {
+		return false, nil
+	}
+	return w.allowsDay(day)
+}
+
+// allowsDay - 曜日の条件を満たすか
+func (w MaintenanceWindow) allowsDay(day time.Weekday) (bool, error) {
+	if len(w.Days) == 0 {
+		return true, nil
+	}
+	for _, d := range w.Days {
+		wd, ok := weekdays[strings.ToLower(d)]
+		if !ok {
+			return false, fmt.Errorf("曜日の指定が不正です: %q", d)
+		}
+		if wd == day {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseClock - "HH:MM" を0時からの分に変換
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("時刻の指定が不正です: %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSelectRelease(t *testing.T) {
+	releases := []Release{
+		{Version: "v1.3.0-beta.1", Prerelease: true},
+		{Version: "v1.2.1"},
+		{Version: "v1.2.0"},
+		{Version: "v1.1.0"},
+		{Version: "v1.4.0", Draft: true},
+		{Version: "nightly"},
+	}
+
+	tests := []struct {
+		name    string
+		current string
+		cfg     UpdateConfig
+		want    string // 空なら更新なし
+		wantErr bool
+	}{
+		{name: "stable: 最新の正式版", current: "v1.1.0", cfg: UpdateConfig{Channel: UpdateChannelStable}, want: "v1.2.1"},
+		{name: "stable: 最新を使用中", current: "v1.2.1", cfg: UpdateConfig{Channel: UpdateChannelStable}},
+		{name: "stable: 古いタグには戻らない", current: "v1.5.0", cfg: UpdateConfig{Channel: UpdateChannelStable}},
+		{name: "stable: プレリリース使用中でも正式版へ", current: "v1.2.1-rc.1", cfg: UpdateConfig{Channel: UpdateChannelStable}, want: "v1.2.1"},
+		{name: "beta: プレリリースを含む", current: "v1.1.0", cfg: UpdateConfig{Channel: UpdateChannelBeta}, want: "v1.3.0-beta.1"},
+		{name: "beta: ドラフトは除外", current: "v1.3.0-beta.1", cfg: UpdateConfig{Channel: UpdateChannelBeta}},
+		{name: "未知のチャンネルはstable扱い", current: "v1.1.0", cfg: UpdateConfig{Channel: "nightly"}, want: "v1.2.1"},
+		{name: "pinned: 指定バージョン", current: "v1.1.0", cfg: UpdateConfig{Channel: UpdateChannelPinned, PinnedVersion: "v1.2.0"}, want: "v1.2.0"},
+		{name: "pinned: 指定バージョンを使用中", current: "v1.2.0", cfg: UpdateConfig{Channel: UpdateChannelPinned, PinnedVersion: "1.2.0"}},
+		{name: "pinned: 指定が現在より古い", current: "v1.2.1", cfg: UpdateConfig{Channel: UpdateChannelPinned, PinnedVersion: "v1.2.0"}},
+		{name: "pinned: 存在しないバージョン", current: "v1.1.0", cfg: UpdateConfig{Channel: UpdateChannelPinned, PinnedVersion: "v9.9.9"}},
+		{name: "pinned: 指定が不正", current: "v1.1.0", cfg: UpdateConfig{Channel: UpdateChannelPinned, PinnedVersion: "latest"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, err := parseSemVer(tt.current)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, found, err := selectRelease(releases, current, tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if found != (tt.want != "") || got.Version != tt.want {
+				t.Errorf("got %q (found=%v), want %q", got.Version, found, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaintenanceWindowContains(t *testing.T) {
+	// 2026-10-14 は水曜日
+	at := func(day int, clock string) time.Time {
+		c, _ := time.Parse("15:04", clock)
+		return time.Date(2026, 10, day, c.Hour(), c.Minute(), 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name    string
+		window  MaintenanceWindow
+		now     time.Time
+		want    bool
+		wantErr bool
+	}{
+		{name: "未設定は常に許可", window: MaintenanceWindow{}, now: at(14, "12:00"), want: true},
+		{name: "時間内", window: MaintenanceWindow{Start: "02:00", End: "05:00"}, now: at(14, "03:30"), want: true},
+		{name: "開始時刻ちょうど", window: MaintenanceWindow{Start: "02:00", End: "05:00"}, now: at(14, "02:00"), want: true},
+		{name: "終了時刻ちょうどは範囲外", window: MaintenanceWindow{Start: "02:00", End: "05:00"}, now: at(14, "05:00")},
+		{name: "時間外", window: MaintenanceWindow{Start: "02:00", End: "05:00"}, now: at(14, "12:00")},
+		{name: "日付またぎ（開始側）", window: MaintenanceWindow{Start: "22:00", End: "04:00"}, now: at(14, "23:00"), want: true},
+		{name: "日付またぎ（翌日側）", window: MaintenanceWindow{Start: "22:00", End: "04:00"}, now: at(15, "01:00"), want: true},
+		{name: "日付またぎ（時間外）", window: MaintenanceWindow{Start: "22:00", End: "04:00"}, now: at(14, "12:00")},
+		{name: "曜日指定（一致）", window: MaintenanceWindow{Start: "02:00", End: "05:00", Days: []string{"Wed"}}, now: at(14, "03:00"), want: true},
+		{name: "曜日指定（不一致）", window: MaintenanceWindow{Start: "02:00", End: "05:00", Days: []string{"Sat", "Sun"}}, now: at(14, "03:00")},
+		{name: "日付またぎは開始日の曜日", window: MaintenanceWindow{Start: "22:00", End: "04:00", Days: []string{"sat"}}, now: at(18, "01:00"), want: true},
+		{name: "日付またぎ翌日側で曜日不一致", window: MaintenanceWindow{Start: "22:00", End: "04:00", Days: []string{"Sun"}}, now: at(18, "01:00")},
+		{name: "不正な時刻", window: MaintenanceWindow{Start: "2時", End: "05:00"}, now: at(14, "03:00"), wantErr: true},
+		{name: "不正な曜日", window: MaintenanceWindow{Start: "02:00", End: "05:00", Days: []string{"Funday"}}, now: at(14, "03:00"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.window.Contains(tt.now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Contains(%s) = %v, want %v", tt.now.Format("Mon 15:04"), got, tt.want)
+			}
+		})
+	}
+}
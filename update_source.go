@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Release - アップデート元に依存しないリリース情報
+type Release struct {
+	Version    string         `json:"version"` // タグ（例: v1.2.3）
+	Prerelease bool           `json:"prerelease"`
+	Draft      bool           `json:"draft"`
+	Assets     []ReleaseAsset `json:"assets"`
+}
+
+// ReleaseAsset - リリースに含まれるファイル
+type ReleaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"url"` // 取得元での場所（HTTPはURL、ディレクトリはファイルパス）
+}
+
+// ReleaseSource - アップデートの取得元
+type ReleaseSource interface {
+	// String - ログに出力する取得元の説明
+	String() string
+	// Releases - 利用可能なリリース一覧
+	Releases(ctx context.Context) ([]Release, error)
+	// Open - リリースファイルを開く
+	Open(ctx context.Context, asset ReleaseAsset) (io.ReadCloser, error)
+}
+
+// アップデート元の種類
+const (
+	UpdateSourceGitHub = "github" // GitHub Releases
+	UpdateSourceHTTP   = "http"   // HTTPで配信するJSONマニフェスト（社内ミラー等）
+	UpdateSourceDir    = "dir"    // ローカルディレクトリまたはUNC共有
+)
+
+// 既定のGitHubリポジトリ
+const defaultUpdateRepository = "ohishi-yhonda-org/print_pdf"
+
+// リリース一覧取得のタイムアウト（ダウンロードはクライアントのタイムアウトに従う）
+const releaseListTimeout = 30 * time.Second
+
+// newReleaseSource - 設定からアップデート元を作成
+func newReleaseSource(cfg UpdateSourceConfig) (ReleaseSource, error) {
+	client := &http.Client{Timeout: 300 * time.Second} // 5分タイムアウト
+	switch cfg.Type {
+	case "", UpdateSourceGitHub:
+		repo := cfg.Repository
+		if repo == "" {
+			repo = defaultUpdateRepository
+		}
+		apiURL := cfg.URL
+		if apiURL == "" {
+			apiURL = "https://api.github.com"
+		}
+		return &githubSource{client: client, apiURL: strings.TrimSuffix(apiURL, "/"), repo: repo}, nil
+	case UpdateSourceHTTP:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("update.source.url が設定されていません")
+		}
+		return &manifestSource{client: client, url: cfg.URL}, nil
+	case UpdateSourceDir:
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("update.source.path が設定されていません")
+		}
+		return &dirSource{dir: cfg.Path}, nil
+	}
+	return nil, fmt.Errorf("不明なアップデート元です: %q", cfg.Type)
+}
+
+// --- GitHub Releases ---
+
+// githubSource - GitHub Releases API
+type githubSource struct {
+	client *http.Client
+	apiURL string // https://api.github.com（GitHub Enterprise の場合は変更）
+	repo   string // owner/name
+}
+
+// GitHubリリース情報
+type GitHubRelease struct {
+	TagName    string        `json:"tag_name"`
+	Name       string        `json:"name"`
+	Draft      bool          `json:"draft"`
+	Prerelease bool          `json:"prerelease"`
+	Assets     []GitHubAsset `json:"assets"`
+}
+
+// GitHubAsset - リリースに添付されたファイル
+type GitHubAsset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+func (s *githubSource) String() string {
+	return fmt.Sprintf("GitHub %s", s.repo)
+}
+
+// Releases - リリース一覧（プレリリースを含む。releases/latest は正式版のみ返すため使わない）
+func (s *githubSource) Releases(ctx context.Context) ([]Release, error) {
+	var ghReleases []GitHubRelease
+	if err := getJSON(ctx, s.client, fmt.Sprintf("%s/repos/%s/releases?per_page=30", s.apiURL, s.repo), &ghReleases); err != nil {
+		return nil, err
+	}
+
+	releases := make([]Release, 0, len(ghReleases))
+	for _, gh := range ghReleases {
+		release := Release{Version: gh.TagName, Prerelease: gh.Prerelease, Draft: gh.Draft}
+		for _, asset := range gh.Assets {
+			release.Assets = append(release.Assets, ReleaseAsset{Name: asset.Name, URL: asset.BrowserDownloadURL})
+		}
+		releases = append(releases, release)
+	}
+	return releases, nil
+}
+
+func (s *githubSource) Open(ctx context.Context, asset ReleaseAsset) (io.ReadCloser, error) {
+	return openHTTP(ctx, s.client, asset.URL)
+}
+
+// --- HTTP マニフェスト ---
+
+// manifestSource - {"releases": [Release...]} 形式のJSONマニフェスト
+// アセットのURLが相対パスの場合はマニフェストのURLを基準に解決する
+type manifestSource struct {
+	client *http.Client
+	url    string
+}
+
+// releaseManifest - マニフェストファイルの形式
+type releaseManifest struct {
+	Releases []Release `json:"releases"`
+}
+
+func (s *manifestSource) String() string {
+	return fmt.Sprintf("マニフェスト %s", s.url)
+}
+
+func (s *manifestSource) Releases(ctx context.Context) ([]Release, error) {
+	base, err := url.Parse(s.url)
+	if err != nil {
+		return nil, fmt.Errorf("マニフェストのURLが不正です: %v", err)
+	}
+
+	var manifest releaseManifest
+	if err := getJSON(ctx, s.client, s.url, &manifest); err != nil {
+		return nil, err
+	}
+
+	for i := range manifest.Releases {
+		for j, asset := range manifest.Releases[i].Assets {
+			if asset.URL == "" {
+				asset.URL = asset.Name
+			}
+			ref, err := url.Parse(asset.URL)
+			if err != nil {
+				return nil, fmt.Errorf("アセットのURLが不正です: %s: %v", asset.Name, err)
+			}
+			manifest.Releases[i].Assets[j].URL = base.ResolveReference(ref).String()
+		}
+	}
+	return manifest.Releases, nil
+}
+
+func (s *manifestSource) Open(ctx context.Context, asset ReleaseAsset) (io.ReadCloser, error) {
+	return openHTTP(ctx, s.client, asset.URL)
+}
+
+// --- ローカルディレクトリ / UNC共有 ---
+
+// dirSource - print_pdf_<version>.zip と検証用ファイルを置いたディレクトリ
+type dirSource struct {
+	dir string
+}
+
+// リリースzipのファイル名（print_pdf_v1.2.3.zip）
+var releaseArchivePattern = regexp.MustCompile(`^print_pdf_(v?\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?)\.zip$`)
+
+func (s *dirSource) String() string {
+	return fmt.Sprintf("ディレクトリ %s", s.dir)
+}
+
+func (s *dirSource) Releases(ctx context.Context) ([]Release, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("ディレクトリ読み込みエラー: %v", err)
+	}
+
+	files := map[string]bool{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			files[entry.Name()] = true
+		}
+	}
+
+	var releases []Release
+	for _, entry := range entries {
+		match := releaseArchivePattern.FindStringSubmatch(entry.Name())
+		if match == nil || entry.IsDir() {
+			continue
+		}
+		version, err := parseSemVer(match[1])
+		if err != nil {
+			continue
+		}
+
+		release := Release{Version: match[1], Prerelease: version.IsPrerelease()}
+		for _, name := range []string{entry.Name(), entry.Name() + checksumSuffix, entry.Name() + signatureSuffix} {
+			if files[name] {
+				release.Assets = append(release.Assets, ReleaseAsset{Name: name, URL: filepath.Join(s.dir, name)})
+			}
+		}
+		releases = append(releases, release)
+	}
+	return releases, nil
+}
+
+// Open - ディレクトリ外のファイルは開かない
+func (s *dirSource) Open(ctx context.Context, asset ReleaseAsset) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(s.dir, filepath.Base(asset.Name)))
+}
+
+// --- HTTP共通 ---
+
+// openHTTP - GETしてレスポンスボディを返す（200以外はエラー）
+func openHTTP(ctx context.Context, client *http.Client, rawURL string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, rawURL)
+	}
+	return resp.Body, nil
+}
+
+// getJSON - GETしたJSONを解析
+func getJSON(ctx context.Context, client *http.Client, rawURL string, v interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, releaseListTimeout)
+	defer cancel()
+
+	body, err := openHTTP(ctx, client, rawURL)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("レスポンス読み取りエラー: %v", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("JSON パースエラー: %v", err)
+	}
+	return nil
+}
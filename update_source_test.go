@@ -0,0 +1,305 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testReleaseFiles - 署名済みのリリースファイル一式（ファイル名 → 内容）を作成
+func testReleaseFiles(t *testing.T, key ed25519.PrivateKey, version string) map[string][]byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("print_pdf.exe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(w, "print_pdf %s", version)
+	zw.Close()
+
+	name := "print_pdf_" + version + ".zip"
+	checksums := []byte(fmt.Sprintf("%x  %s\n", sha256.Sum256(buf.Bytes()), name))
+	return map[string][]byte{
+		name:                   buf.Bytes(),
+		name + checksumSuffix:  checksums,
+		name + signatureSuffix: []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, checksums))),
+	}
+}
+
+// testReleaseSet - 各取得元のテストで共通のリリース構成
+type testReleaseSet struct {
+	files     map[string][]byte
+	releases  []Release // URL は名前のみ
+	publicKey string
+}
+
+func newTestReleaseSet(t *testing.T) testReleaseSet {
+	t.Helper()
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	set := testReleaseSet{files: map[string][]byte{}, publicKey: base64.StdEncoding.EncodeToString(pub)}
+	for _, version := range []string{"v1.1.0", "v1.2.0", "v1.3.0-beta.1"} {
+		release := Release{Version: version, Prerelease: strings.Contains(version, "-")}
+		for name, data := range testReleaseFiles(t, priv, version) {
+			set.files[name] = data
+			release.Assets = append(release.Assets, ReleaseAsset{Name: name, URL: name})
+		}
+		set.releases = append(set.releases, release)
+	}
+	return set
+}
+
+// serveFiles - リリースファイルを配信するハンドラー
+func (set testReleaseSet) serveFiles(prefix string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, ok := set.files[strings.TrimPrefix(r.URL.Path, prefix)]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}
+}
+
+// githubTestSource - GitHub API を模したサーバー
+func githubTestSource(t *testing.T, set testReleaseSet) ReleaseSource {
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/example/print_pdf/releases", func(w http.ResponseWriter, r *http.Request) {
+		var ghReleases []GitHubRelease
+		for _, release := range set.releases {
+			gh := GitHubRelease{TagName: release.Version, Prerelease: release.Prerelease}
+			for _, asset := range release.Assets {
+				gh.Assets = append(gh.Assets, GitHubAsset{Name: asset.Name, BrowserDownloadURL: server.URL + "/download/" + asset.Name})
+			}
+			ghReleases = append(ghReleases, gh)
+		}
+		json.NewEncoder(w).Encode(ghReleases)
+	})
+	mux.HandleFunc("/download/", set.serveFiles("/download/"))
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	source, err := newReleaseSource(UpdateSourceConfig{Type: UpdateSourceGitHub, Repository: "example/print_pdf", URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return source
+}
+
+// manifestTestSource - 相対URLのマニフェストを配信するサーバー
+func manifestTestSource(t *testing.T, set testReleaseSet) ReleaseSource {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mirror/manifest.json", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(releaseManifest{Releases: set.releases})
+	})
+	mux.HandleFunc("/mirror/", set.serveFiles("/mirror/"))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	source, err := newReleaseSource(UpdateSourceConfig{Type: UpdateSourceHTTP, URL: server.URL + "/mirror/manifest.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return source
+}
+
+// dirTestSource - リリースファイルを置いた一時ディレクトリ
+func dirTestSource(t *testing.T, set testReleaseSet) ReleaseSource {
+	dir := t.TempDir()
+	for name, data := range set.files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 関係のないファイルは無視される
+	os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(dir, "print_pdf_latest.zip"), []byte("x"), 0644)
+
+	source, err := newReleaseSource(UpdateSourceConfig{Type: UpdateSourceDir, Path: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return source
+}
+
+// TestUpdaterEndToEnd - 各取得元から検索・ダウンロード・検証・展開までを実行
+func TestUpdaterEndToEnd(t *testing.T) {
+	sources := []struct {
+		name   string
+		source func(t *testing.T, set testReleaseSet) ReleaseSource
+	}{
+		{"github", githubTestSource},
+		{"http", manifestTestSource},
+		{"dir", dirTestSource},
+	}
+	channels := []struct {
+		channel string
+		want    string
+	}{
+		{UpdateChannelStable, "v1.2.0"},
+		{UpdateChannelBeta, "v1.3.0-beta.1"},
+	}
+
+	for _, src := range sources {
+		for _, ch := range channels {
+			t.Run(src.name+"/"+ch.channel, func(t *testing.T) {
+				set := newTestReleaseSet(t)
+				source := src.source(t, set)
+				stubPublicKey(t, set.publicKey)
+
+				originalVersion := Version
+				Version = "v1.1.0"
+				t.Cleanup(func() { Version = originalVersion })
+
+				exeDir := t.TempDir()
+				exe := filepath.Join(exeDir, "print_pdf.exe")
+				os.WriteFile(exe, []byte("print_pdf v1.1.0"), 0755)
+				originalExe, originalStart := executablePath, startUpdateScript
+				executablePath = func() (string, error) { return exe, nil }
+				var script string
+				startUpdateScript = func(batchFile string) error {
+					script = batchFile
+					return nil
+				}
+				t.Cleanup(func() { executablePath, startUpdateScript = originalExe, originalStart })
+
+				release, found, err := findAvailableUpdate(context.Background(), source, UpdateConfig{Channel: ch.channel})
+				if err != nil || !found {
+					t.Fatalf("findAvailableUpdate: found=%v err=%v", found, err)
+				}
+				if release.Version != ch.want {
+					t.Fatalf("selected %s, want %s", release.Version, ch.want)
+				}
+
+				if err := performUpdate(context.Background(), source, release); err != nil {
+					t.Fatalf("performUpdate: %v", err)
+				}
+
+				if data, _ := os.ReadFile(exe + ".new"); string(data) != "print_pdf "+ch.want {
+					t.Errorf("staged executable = %q", data)
+				}
+				if data, _ := os.ReadFile(exe + ".backup"); string(data) != "print_pdf v1.1.0" {
+					t.Errorf("backup = %q", data)
+				}
+				if script != filepath.Join(exeDir, "update_replace.bat") {
+					t.Errorf("script = %q", script)
+				}
+				if data, _ := os.ReadFile(script); !strings.Contains(string(data), "'"+ch.want+"'") {
+					t.Errorf("script does not check for %s", ch.want)
+				}
+			})
+		}
+	}
+}
+
+// TestUpdaterRejectsUnsignedRelease - 署名が一致しないリリースは展開しない
+func TestUpdaterRejectsUnsignedRelease(t *testing.T) {
+	set := newTestReleaseSet(t)
+	other := newTestReleaseSet(t)
+	stubPublicKey(t, other.publicKey) // 別の鍵で署名されたものとして扱う
+
+	exe := filepath.Join(t.TempDir(), "print_pdf.exe")
+	os.WriteFile(exe, []byte("old"), 0755)
+	originalExe, originalStart := executablePath, startUpdateScript
+	executablePath = func() (string, error) { return exe, nil }
+	startUpdateScript = func(string) error {
+		t.Error("update script must not run")
+		return nil
+	}
+	t.Cleanup(func() { executablePath, startUpdateScript = originalExe, originalStart })
+
+	source := dirTestSource(t, set)
+	if err := performUpdate(context.Background(), source, Release{Version: "v1.2.0", Assets: set.releases[1].Assets}); err == nil {
+		t.Fatal("performUpdate succeeded with a bad signature")
+	}
+	if _, err := os.Stat(exe + ".new"); !os.IsNotExist(err) {
+		t.Error("executable was staged despite bad signature")
+	}
+}
+
+func TestNewReleaseSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     UpdateSourceConfig
+		want    string
+		wantErr bool
+	}{
+		{name: "既定はGitHub", cfg: UpdateSourceConfig{}, want: "GitHub " + defaultUpdateRepository},
+		{name: "GitHub", cfg: UpdateSourceConfig{Type: "github", Repository: "a/b"}, want: "GitHub a/b"},
+		{name: "HTTP", cfg: UpdateSourceConfig{Type: "http", URL: "http://mirror/manifest.json"}, want: "マニフェスト http://mirror/manifest.json"},
+		{name: "HTTP URLなし", cfg: UpdateSourceConfig{Type: "http"}, wantErr: true},
+		{name: "ディレクトリ", cfg: UpdateSourceConfig{Type: "dir", Path: `\\fileserver\print_pdf`}, want: `ディレクトリ \\fileserver\print_pdf`},
+		{name: "ディレクトリ パスなし", cfg: UpdateSourceConfig{Type: "dir"}, wantErr: true},
+		{name: "不明な種類", cfg: UpdateSourceConfig{Type: "ftp"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := newReleaseSource(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && source.String() != tt.want {
+				t.Errorf("String() = %q, want %q", source.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestManifestSourceResolvesURLs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"releases":[{"version":"v1.2.0","assets":[
+			{"name":"print_pdf_v1.2.0.zip"},
+			{"name":"print_pdf_v1.2.0.zip.sha256","url":"sums/print_pdf_v1.2.0.zip.sha256"},
+			{"name":"print_pdf_v1.2.0.zip.sha256.sig","url":"https://cdn.example/sig"}]}]}`))
+	}))
+	defer server.Close()
+
+	source := &manifestSource{client: server.Client(), url: server.URL + "/print_pdf/manifest.json"}
+	releases, err := source.Releases(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		server.URL + "/print_pdf/print_pdf_v1.2.0.zip",
+		server.URL + "/print_pdf/sums/print_pdf_v1.2.0.zip.sha256",
+		"https://cdn.example/sig",
+	}
+	for i, asset := range releases[0].Assets {
+		if asset.URL != want[i] {
+			t.Errorf("asset %d URL = %q, want %q", i, asset.URL, want[i])
+		}
+	}
+}
+
+func TestDirSourceOpenStaysInDirectory(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "releases")
+	os.Mkdir(dir, 0755)
+	os.WriteFile(filepath.Join(parent, "secret.txt"), []byte("secret"), 0644)
+	os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("release"), 0644)
+
+	source := &dirSource{dir: dir}
+	reader, err := source.Open(context.Background(), ReleaseAsset{Name: "../secret.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if data, _ := io.ReadAll(reader); string(data) != "release" {
+		t.Errorf("opened file outside the release directory: %q", data)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// UpdatePublicKey - リリース署名を検証するed25519公開鍵（base64）
+// CIのビルド時に -ldflags "-X main.UpdatePublicKey=..." で埋め込む。空の場合は自動アップデートを行わない
+var UpdatePublicKey = ""
+
+// リリースに添付する検証用ファイルの接尾辞（scripts/sign_release.go が作成）
+const (
+	checksumSuffix  = ".sha256"     // sha256sum 形式のチェックサムファイル
+	signatureSuffix = ".sha256.sig" // チェックサムファイルへのed25519署名（base64）
+)
+
+// verifyUpdateArchive - ダウンロードしたzipをチェックサムと署名で検証
+// 署名はチェックサムファイル全体に対して行うため、チェックサムの改ざんも検出できる
+func verifyUpdateArchive(archivePath, archiveName string, checksums, signature []byte, publicKey string) error {
+	if err := verifyUpdateSignature(publicKey, checksums, signature); err != nil {
+		return err
+	}
+
+	expected, err := parseChecksumFile(checksums, archiveName)
+	if err != nil {
+		return err
+	}
+
+	actual, err := fileSHA256(archivePath)
+	if err != nil {
+		return fmt.Errorf("チェックサム計算エラー: %v", err)
+	}
+	if !bytes.Equal(expected, actual) {
+		return fmt.Errorf("チェックサムが一致しません: expected %x, got %x", expected, actual)
+	}
+	return nil
+}
+
+// verifyUpdateSignature - ed25519署名を検証
+func verifyUpdateSignature(publicKey string, message, signature []byte) error {
+	if publicKey == "" {
+		return fmt.Errorf("署名検証用の公開鍵が埋め込まれていません")
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("公開鍵の形式が不正です")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("署名ファイルの形式が不正です")
+	}
+
+	if !ed25519.Verify(ed25519.PublicKey(key), message, sig) {
+		return fmt.Errorf("署名の検証に失敗しました")
+	}
+	return nil
+}
+
+// parseChecksumFile - sha256sum 形式（"<hex>  <ファイル名>"）から指定ファイルのハッシュを取得
+func parseChecksumFile(data []byte, name string) ([]byte, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		// バイナリモードの "*" 接頭辞を許容
+		if strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		sum, err := hex.DecodeString(fields[0])
+		if err != nil || len(sum) != sha256.Size {
+			return nil, fmt.Errorf("チェックサムの形式が不正です: %s", name)
+		}
+		return sum, nil
+	}
+	return nil, fmt.Errorf("チェックサムファイルに %s が含まれていません", name)
+}
+
+// fileSHA256 - ファイルのSHA-256ハッシュを計算
+func fileSHA256(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
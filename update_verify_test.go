@@ -0,0 +1,132 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// signedRelease - テスト用に署名済みのzip・チェックサム・署名を作成
+type signedRelease struct {
+	archivePath string
+	checksums   []byte
+	signature   []byte
+	publicKey   string
+	privateKey  ed25519.PrivateKey
+}
+
+func newSignedRelease(t *testing.T, name string, content []byte) signedRelease {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archivePath := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(archivePath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	checksums := []byte(fmt.Sprintf("%x  %s\n", sha256.Sum256(content), name))
+	return signedRelease{
+		archivePath: archivePath,
+		checksums:   checksums,
+		signature:   []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, checksums)) + "\n"),
+		publicKey:   base64.StdEncoding.EncodeToString(pub),
+		privateKey:  priv,
+	}
+}
+
+func TestVerifyUpdateArchive(t *testing.T) {
+	const name = "print_pdf_v1.2.3.zip"
+	otherPub, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	tests := []struct {
+		name    string
+		mutate  func(r *signedRelease)
+		wantErr string
+	}{
+		{name: "正常", mutate: func(r *signedRelease) {}},
+		{name: "zipの改ざん", mutate: func(r *signedRelease) {
+			os.WriteFile(r.archivePath, []byte("tampered"), 0644)
+		}, wantErr: "チェックサムが一致しません"},
+		{name: "チェックサムの改ざん", mutate: func(r *signedRelease) {
+			r.checksums = []byte(fmt.Sprintf("%x  %s\n", sha256.Sum256([]byte("tampered")), name))
+		}, wantErr: "署名の検証に失敗しました"},
+		{name: "チェックサムを改ざんして再署名（別の鍵）", mutate: func(r *signedRelease) {
+			_, attacker, _ := ed25519.GenerateKey(rand.Reader)
+			r.checksums = []byte(fmt.Sprintf("%x  %s\n", sha256.Sum256([]byte("tampered")), name))
+			r.signature = []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(attacker, r.checksums)))
+		}, wantErr: "署名の検証に失敗しました"},
+		{name: "別の公開鍵", mutate: func(r *signedRelease) {
+			r.publicKey = base64.StdEncoding.EncodeToString(otherPub)
+		}, wantErr: "署名の検証に失敗しました"},
+		{name: "公開鍵が未設定", mutate: func(r *signedRelease) {
+			r.publicKey = ""
+		}, wantErr: "公開鍵が埋め込まれていません"},
+		{name: "公開鍵の形式が不正", mutate: func(r *signedRelease) {
+			r.publicKey = "not-base64!"
+		}, wantErr: "公開鍵の形式が不正です"},
+		{name: "署名の形式が不正", mutate: func(r *signedRelease) {
+			r.signature = []byte("AAAA")
+		}, wantErr: "署名ファイルの形式が不正です"},
+		{name: "チェックサムに対象ファイルがない", mutate: func(r *signedRelease) {
+			r.checksums = []byte(fmt.Sprintf("%x  other.zip\n", sha256.Sum256([]byte("x"))))
+			r.signature = []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(r.privateKey, r.checksums)))
+		}, wantErr: "含まれていません"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newSignedRelease(t, name, []byte("PK\x03\x04 release content"))
+			tt.mutate(&r)
+
+			err := verifyUpdateArchive(r.archivePath, name, r.checksums, r.signature, r.publicKey)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseChecksumFile(t *testing.T) {
+	sum := sha256.Sum256([]byte("data"))
+	hexSum := fmt.Sprintf("%x", sum)
+
+	tests := []struct {
+		name    string
+		data    string
+		target  string
+		wantErr bool
+	}{
+		{name: "1行", data: hexSum + "  a.zip\n", target: "a.zip"},
+		{name: "複数行", data: "00  b.zip\n" + hexSum + "  a.zip\n", target: "a.zip"},
+		{name: "バイナリモード", data: hexSum + " *a.zip\n", target: "a.zip"},
+		{name: "CRLF", data: hexSum + "  a.zip\r\n", target: "a.zip"},
+		{name: "対象なし", data: hexSum + "  b.zip\n", target: "a.zip", wantErr: true},
+		{name: "不正な16進数", data: "zz  a.zip\n", target: "a.zip", wantErr: true},
+		{name: "長さ不正", data: "abcd  a.zip\n", target: "a.zip", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChecksumFile([]byte(tt.data), tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && fmt.Sprintf("%x", got) != hexSum {
+				t.Errorf("sum = %x, want %s", got, hexSum)
+			}
+		})
+	}
+}
@@ -0,0 +1,538 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Windowsサービス名
+const serviceName = "PDF Generator API Service"
+
+// runUpdateLoop - 起動5秒後と、以降 checkIntervalMinutes ごとにアップデートを確認
+func runUpdateLoop(ctx context.Context) {
+	delay := 5 * time.Second // サーバー起動後に実行
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		checkForUpdates(ctx)
+
+		interval := currentConfig().Update.CheckIntervalMinutes
+		if interval <= 0 {
+			writeEventLog("INFO", "定期アップデートチェックは無効です（起動時のみ）")
+			return
+		}
+		delay = time.Duration(interval) * time.Minute
+	}
+}
+
+// 自動アップデート機能
+func checkForUpdates(ctx context.Context) {
+	// dev環境では自動アップデートを実行しない
+	if Version == "dev" {
+		writeEventLog("INFO", "開発環境のため自動アップデートをスキップします")
+		return
+	}
+
+	if !selfUpdateSupported {
+		writeEventLog("INFO", "このプラットフォームでは自動アップデートに対応していません")
+		return
+	}
+
+	cfg := currentConfig().Update
+	if !cfg.Enabled {
+		writeEventLog("INFO", "設定により自動アップデートは無効です")
+		return
+	}
+
+	source, err := newReleaseSource(cfg.Source)
+	if err != nil {
+		writeEventLog("WARN", fmt.Sprintf("アップデート元の設定が不正です: %v", err))
+		return
+	}
+
+	writeEventLog("INFO", fmt.Sprintf("現在のバージョン: %s (チャンネル: %s)", Version, cfg.Channel))
+	writeEventLog("INFO", fmt.Sprintf("最新バージョンをチェック中: %s", source))
+
+	release, found, err := updates.check(ctx, source, cfg)
+	if err != nil {
+		writeEventLog("WARN", fmt.Sprintf("アップデートチェックに失敗: %v", err))
+		return
+	}
+	if !found {
+		writeEventLog("INFO", "最新バージョンを使用中です")
+		return
+	}
+	writeEventLog("INFO", fmt.Sprintf("新しいバージョンが利用可能: %s -> %s", Version, release.Version))
+
+	// メンテナンス時間外は適用しない（次回のチェックで再判定）
+	inWindow, err := cfg.MaintenanceWindow.Contains(time.Now())
+	if err != nil {
+		writeEventLog("WARN", fmt.Sprintf("メンテナンス時間の設定が不正です: %v", err))
+		return
+	}
+	if !inWindow {
+		writeEventLog("INFO", fmt.Sprintf("メンテナンス時間外のため %s の適用を保留します (%s-%s)",
+			release.Version, cfg.MaintenanceWindow.Start, cfg.MaintenanceWindow.End))
+		return
+	}
+
+	// 自動アップデートを実行
+	if err := updates.apply(ctx, source, release); err != nil {
+		writeEventLog("ERROR", fmt.Sprintf("アップデートを中止しました: %v", err))
+		return
+	}
+	restartForUpdate()
+}
+
+// restartForUpdate - 処理中のジョブの完了を待ってから終了（置換用バッチファイルが再起動する）
+func restartForUpdate() {
+	writeEventLog("INFO", "アップデート準備完了。処理中のジョブの完了を待ってから再起動します...")
+
+	// 印刷中のジョブを中断しないよう、受付を止めて完了を待ってから終了
+	// 置換用バッチファイルはこのプロセスの終了を待ってから実行ファイルを置き換える
+	if err := drainAndShutdown(); err != nil {
+		writeEventLog("WARN", fmt.Sprintf("処理中のジョブを待機中にタイムアウトしました: %v", err))
+	}
+
+	// サービスとして実行中の場合は、サービスマネージャーの停止手順で終了する（停止を報告しないと回復動作で再起動される）
+	switch {
+	case isServiceProcess() && stopServiceForUpdate():
+		writeEventLog("INFO", "サービスとして実行中のため、サービスマネージャーに停止を報告して終了します")
+		time.Sleep(serviceStopForUpdateTimeout)
+		writeEventLog("WARN", "サービスの停止を報告できなかったため、そのまま終了します")
+	case isServiceProcess():
+		writeEventLog("INFO", "サービスとして実行中のため、終了します")
+	default:
+		writeEventLog("INFO", "コンソールアプリケーションとして実行中のため、バッチファイルで再起動します")
+	}
+	exitProcess(0)
+}
+
+// serviceStopForUpdateTimeout - サービスハンドラーが停止を報告してプロセスが終了するまで待つ時間
+var serviceStopForUpdateTimeout = 30 * time.Second
+
+// プロセス終了（テストで差し替え可能）
+var exitProcess = os.Exit
+
+// findAvailableUpdate - チャンネル設定に従って、現在より新しいリリースを探す
+func findAvailableUpdate(ctx context.Context, source ReleaseSource, cfg UpdateConfig) (Release, bool, error) {
+	current, err := parseSemVer(Version)
+	if err != nil {
+		return Release{}, false, fmt.Errorf("現在のバージョンを解析できません: %v", err)
+	}
+
+	releases, err := source.Releases(ctx)
+	if err != nil {
+		return Release{}, false, err
+	}
+	return selectRelease(releases, current, cfg)
+}
+
+// updateAssets - アップデートに必要なリリースファイル
+type updateAssets struct {
+	Archive   ReleaseAsset // print_pdf_<tag>.zip
+	Checksum  ReleaseAsset // <zip>.sha256（sha256sum 形式）
+	Signature ReleaseAsset // <zip>.sha256.sig（チェックサムファイルへのed25519署名）
+}
+
+// releaseArchiveName - CIが公開するリリースzipのファイル名
+func releaseArchiveName(version string) string {
+	return "print_pdf_" + version + ".zip"
+}
+
+// findUpdateAssets - リリースからzip・チェックサム・署名を探す（zipはCIが公開する名前と完全に一致するもの）
+func findUpdateAssets(release Release) (updateAssets, error) {
+	var assets updateAssets
+	archiveName := releaseArchiveName(release.Version)
+	for _, asset := range release.Assets {
+		if asset.Name == archiveName {
+			assets.Archive = asset
+			break
+		}
+	}
+	if assets.Archive.Name == "" {
+		return assets, fmt.Errorf("アップデート用のzipファイルが見つかりません: %s", archiveName)
+	}
+
+	for _, asset := range release.Assets {
+		switch asset.Name {
+		case assets.Archive.Name + checksumSuffix:
+			assets.Checksum = asset
+		case assets.Archive.Name + signatureSuffix:
+			assets.Signature = asset
+		}
+	}
+	if assets.Checksum.Name == "" {
+		return assets, fmt.Errorf("チェックサムファイルが見つかりません: %s%s", assets.Archive.Name, checksumSuffix)
+	}
+	if assets.Signature.Name == "" {
+		return assets, fmt.Errorf("署名ファイルが見つかりません: %s%s", assets.Archive.Name, signatureSuffix)
+	}
+	return assets, nil
+}
+
+// アップデートを実行（ダウンロード・検証・展開し、置換用バッチファイルを起動）
+func performUpdate(ctx context.Context, source ReleaseSource, release Release) error {
+	tempDir, err := os.MkdirTemp("", "print_pdf_update")
+	if err != nil {
+		return fmt.Errorf("一時ディレクトリ作成エラー: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	archivePath, err := downloadVerifiedUpdate(ctx, source, release, tempDir)
+	if err != nil {
+		return err
+	}
+
+	writeEventLog("INFO", "検証完了。アップデートを適用中...")
+
+	// zipファイルを解凍
+	if err := extractUpdate(archivePath, release.Version); err != nil {
+		return fmt.Errorf("アップデート適用エラー: %v", err)
+	}
+
+	writeEventLog("INFO", "アップデート適用完了")
+	return nil
+}
+
+// downloadVerifiedUpdate - zipをダウンロードし、チェックサムと署名を検証（検証済みzipのパスを返す）
+func downloadVerifiedUpdate(ctx context.Context, source ReleaseSource, release Release, dir string) (string, error) {
+	assets, err := findUpdateAssets(release)
+	if err != nil {
+		return "", err
+	}
+
+	writeEventLog("INFO", fmt.Sprintf("アップデートファイルをダウンロード中: %s", assets.Archive.URL))
+	archivePath := filepath.Join(dir, filepath.Base(assets.Archive.Name))
+	if err := downloadAsset(ctx, source, assets.Archive, archivePath); err != nil {
+		return "", fmt.Errorf("ダウンロードエラー: %v", err)
+	}
+
+	checksums, err := readSmallAsset(ctx, source, assets.Checksum)
+	if err != nil {
+		return "", fmt.Errorf("チェックサムファイルのダウンロードエラー: %v", err)
+	}
+	signature, err := readSmallAsset(ctx, source, assets.Signature)
+	if err != nil {
+		return "", fmt.Errorf("署名ファイルのダウンロードエラー: %v", err)
+	}
+
+	writeEventLog("INFO", "ダウンロード完了。チェックサムと署名を検証中...")
+	if err := verifyUpdateArchive(archivePath, assets.Archive.Name, checksums, signature, UpdatePublicKey); err != nil {
+		return "", err
+	}
+	return archivePath, nil
+}
+
+// downloadAsset - リリースファイルを保存
+func downloadAsset(ctx context.Context, source ReleaseSource, asset ReleaseAsset, destPath string) error {
+	reader, err := source.Open(ctx, asset)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	file, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, reader); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// チェックサム・署名ファイルの最大サイズ
+const maxSmallAssetSize = 64 << 10
+
+// readSmallAsset - 小さなリリースファイルをメモリに読み込む
+func readSmallAsset(ctx context.Context, source ReleaseSource, asset ReleaseAsset) ([]byte, error) {
+	reader, err := source.Open(ctx, asset)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxSmallAssetSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSmallAssetSize {
+		return nil, fmt.Errorf("ファイルサイズが上限(%dバイト)を超えています", maxSmallAssetSize)
+	}
+	return data, nil
+}
+
+// 実行ファイルのパス（テストで差し替え可能）
+var executablePath = os.Executable
+
+// zipファイルを解凍してアップデートを適用
+func extractUpdate(zipPath string, version string) error {
+	// 現在の実行ファイル名を取得
+	currentExe, err := executablePath()
+	if err != nil {
+		return fmt.Errorf("実行ファイルパス取得エラー: %v", err)
+	}
+
+	// zipファイルを開く
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return fmt.Errorf("zip読み込みエラー: %v", err)
+	}
+	defer reader.Close()
+
+	// バックアップ作成
+	backupPath := currentExe + ".backup"
+	if err := copyFile(currentExe, backupPath); err != nil {
+		return fmt.Errorf("バックアップ作成エラー: %v", err)
+	}
+
+	// 解凍処理
+	for _, file := range reader.File {
+		if file.Name == "print_pdf.exe" {
+			// 実行ファイルを更新
+			if err := extractFile(file, currentExe+".new"); err != nil {
+				return fmt.Errorf("新しい実行ファイル展開エラー: %v", err)
+			}
+
+			// バッチファイルでファイル置換を実行（Windowsでは実行中のファイルを置換できないため）
+			// サービスとして実行中かどうかを判定して適切な再起動方法を選択
+			params := updateScriptParams{
+				CurrentExe:      currentExe,
+				NewExe:          currentExe + ".new",
+				BackupExe:       backupPath,
+				OldExe:          currentExe + ".old",
+				FailedExe:       currentExe + ".failed",
+				HealthURL:       "http://127.0.0.1" + httpPort + "/v1/health",
+				ExpectedVersion: version,
+				HealthAttempts:  healthCheckAttempts(currentConfig().Update.HealthCheckTimeoutSec),
+				ProcessID:       os.Getpid(),
+				WaitExitSeconds: int((drainTimeout() + 30*time.Second).Seconds()),
+			}
+			params.StartCommand, params.StopCommand = restartCommands(currentExe)
+			return runUpdateScript(updateScriptTemplate, params)
+		}
+	}
+
+	return fmt.Errorf("アップデート用実行ファイルが見つかりません")
+}
+
+// rollbackUpdate - 前のバージョン（.old）に戻すバッチファイルを起動
+// 実際の置き換えはこのプロセスの終了後に行われるため、呼び出し側は restartForUpdate で終了すること
+func rollbackUpdate() (string, error) {
+	currentExe, err := executablePath()
+	if err != nil {
+		return "", fmt.Errorf("実行ファイルパス取得エラー: %v", err)
+	}
+	oldExe := currentExe + ".old"
+	if _, err := os.Stat(oldExe); err != nil {
+		return "", errNoRollback
+	}
+
+	params := updateScriptParams{
+		CurrentExe:      currentExe,
+		OldExe:          oldExe,
+		FailedExe:       currentExe + ".rolledback",
+		ProcessID:       os.Getpid(),
+		WaitExitSeconds: int((drainTimeout() + 30*time.Second).Seconds()),
+	}
+	params.StartCommand, params.StopCommand = restartCommands(currentExe)
+	if err := runUpdateScript(rollbackScriptTemplate, params); err != nil {
+		return "", err
+	}
+	return oldExe, nil
+}
+
+// restartCommands - 実行モードに応じた起動・停止コマンド
+func restartCommands(currentExe string) (start, stop string) {
+	if isServiceProcess() {
+		// サービスとして実行中の場合はサービス再起動
+		return fmt.Sprintf(`sc start "%s"`, serviceName), fmt.Sprintf(`sc stop "%s"`, serviceName)
+	}
+	// コンソールアプリケーションとして実行中の場合は直接起動
+	return fmt.Sprintf(`start "" "%s"`, currentExe), fmt.Sprintf(`taskkill /F /IM "%s"`, filepath.Base(currentExe))
+}
+
+// runUpdateScript - バッチファイルを生成して非同期で実行
+func runUpdateScript(tmpl *template.Template, params updateScriptParams) error {
+	batchContent, err := buildUpdateScript(tmpl, params)
+	if err != nil {
+		return fmt.Errorf("バッチファイル生成エラー: %v", err)
+	}
+
+	// サービスの作業ディレクトリはSystem32のため、実行ファイルと同じ場所に作成
+	batchFile := filepath.Join(filepath.Dir(params.CurrentExe), "update_replace.bat")
+	if err := os.WriteFile(batchFile, []byte(batchContent), 0755); err != nil {
+		return fmt.Errorf("バッチファイル作成エラー: %v", err)
+	}
+
+	// バッチファイルを実行（非同期）
+	if err := startUpdateScript(batchFile); err != nil {
+		return fmt.Errorf("アップデートスクリプト実行エラー: %v", err)
+	}
+	return nil
+}
+
+// updateScriptParams - 置換用バッチファイルのパラメーター
+type updateScriptParams struct {
+	CurrentExe      string
+	NewExe          string
+	BackupExe       string // 更新前に作成したコピー
+	OldExe          string // ロールバック用に残す旧バージョン
+	FailedExe       string // ロールバック時に退避する新バージョン
+	StartCommand    string
+	StopCommand     string
+	HealthURL       string
+	ExpectedVersion string
+	HealthAttempts  int // 2秒間隔でのヘルスチェック回数
+	ProcessID       int // 終了を待つ現在のプロセス
+	WaitExitSeconds int // プロセス終了を待つ最大秒数（ジョブの完了待ちを含む）
+}
+
+// healthCheckAttempts - ヘルスチェックのタイムアウト秒数を試行回数に変換
+func healthCheckAttempts(timeoutSec int) int {
+	if timeoutSec < 2 {
+		return 1
+	}
+	return timeoutSec / 2
+}
+
+// 終了待ち・置換・再起動・ヘルスチェック・ロールバックを行うバッチファイル
+// 旧プロセスが処理中のジョブを終えて終了してから置換し、新しいバージョンが時間内に /v1/health で期待したバージョンを返さなければ .old に戻す
+var updateScriptTemplate = template.Must(template.Must(waitExitTemplate.Clone()).New("update").Parse(`@echo off
+{{template "waitexit" .}}
+echo Moving new executable...
+move /Y "{{.NewExe}}" "{{.CurrentExe}}"
+if errorlevel 1 (
+    echo Failed to replace executable
+    exit /b 1
+)
+echo Moving backup...
+move /Y "{{.BackupExe}}" "{{.OldExe}}"
+echo Starting service...
+{{.StartCommand}}
+if errorlevel 1 (
+    echo Failed to start service, retrying...
+    timeout /t 2 /nobreak >nul
+    {{.StartCommand}}
+)
+echo Waiting for {{.ExpectedVersion}} to become healthy...
+set /a TRIES=0
+:healthcheck
+timeout /t 2 /nobreak >nul
+powershell -NoProfile -Command "try { $r = Invoke-RestMethod -Uri '{{.HealthURL}}' -TimeoutSec 5; if ($r.version -eq '{{.ExpectedVersion}}') { exit 0 } } catch {}; exit 1"
+if not errorlevel 1 goto healthy
+set /a TRIES+=1
+if %TRIES% lss {{.HealthAttempts}} goto healthcheck
+echo New version did not become healthy, rolling back...
+{{.StopCommand}}
+timeout /t 5 /nobreak >nul
+move /Y "{{.CurrentExe}}" "{{.FailedExe}}"
+copy /Y "{{.OldExe}}" "{{.CurrentExe}}"
+{{.StartCommand}}
+echo Rollback completed
+goto end
+:healthy
+echo Update completed
+:end
+timeout /t 2 /nobreak >nul
+del "%~f0"
+`))
+
+// 旧プロセスが処理中のジョブを終えて終了するまで待つ（共通部分）
+var waitExitTemplate = template.Must(template.New("waitexit").Parse(`echo Waiting for process {{.ProcessID}} to finish pending jobs and exit...
+set /a WAITED=0
+:waitexit
+tasklist /FI "PID eq {{.ProcessID}}" /NH 2>nul | find " {{.ProcessID}} " >nul
+if errorlevel 1 goto exited
+set /a WAITED+=1
+if %WAITED% geq {{.WaitExitSeconds}} (
+    echo Process did not exit in time, update aborted
+    exit /b 1
+)
+timeout /t 1 /nobreak >nul
+goto waitexit
+:exited
+timeout /t 2 /nobreak >nul`))
+
+// 管理APIからのロールバック: 現在の実行ファイルを退避して .old を戻し、再起動する
+var rollbackScriptTemplate = template.Must(template.Must(waitExitTemplate.Clone()).New("rollback").Parse(`@echo off
+{{template "waitexit" .}}
+echo Rolling back to previous version...
+move /Y "{{.CurrentExe}}" "{{.FailedExe}}"
+if errorlevel 1 (
+    echo Failed to move current executable
+    exit /b 1
+)
+copy /Y "{{.OldExe}}" "{{.CurrentExe}}"
+{{.StartCommand}}
+echo Rollback completed
+timeout /t 2 /nobreak >nul
+del "%~f0"
+`))
+
+// buildUpdateScript - 置換用バッチファイルの内容を生成
+func buildUpdateScript(tmpl *template.Template, params updateScriptParams) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, params); err != nil {
+		return "", err
+	}
+	// cmd.exe はCRLFを前提とする
+	return strings.ReplaceAll(buf.String(), "\n", "\r\n"), nil
+}
+
+// ファイルをコピー
+func copyFile(src, dst string) error {
+	sourceFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close()
+
+	destFile, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer destFile.Close()
+
+	_, err = io.Copy(destFile, sourceFile)
+	return err
+}
+
+// zipファイルから個別ファイルを展開
+func extractFile(file *zip.File, destPath string) error {
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	// ディレクトリを作成
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return err
+	}
+
+	// ファイルを作成
+	writer, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	_, err = io.Copy(writer, reader)
+	return err
+}
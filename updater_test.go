@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func testRelease(tag string, names ...string) Release {
+	release := Release{Version: tag}
+	for _, name := range names {
+		release.Assets = append(release.Assets, ReleaseAsset{Name: name, URL: "http://example.invalid/" + name})
+	}
+	return release
+}
+
+func TestFindUpdateAssets(t *testing.T) {
+	tests := []struct {
+		name    string
+		release Release
+		wantErr string
+	}{
+		{name: "全て揃っている", release: testRelease("v1.2.3", "print_pdf_v1.2.3.zip", "print_pdf_v1.2.3.zip.sha256", "print_pdf_v1.2.3.zip.sha256.sig")},
+		{name: "zipなし", release: testRelease("v1.2.3", "print_pdf_v1.2.2.zip"), wantErr: "zipファイルが見つかりません"},
+		{name: "バージョンを含む別のzip", release: testRelease("v1.2.3", "print_pdf_v1.2.30.zip", "print_pdf_v1.2.30.zip.sha256", "print_pdf_v1.2.30.zip.sha256.sig"), wantErr: "zipファイルが見つかりません: print_pdf_v1.2.3.zip"},
+		{name: "タグを含む別名のzip", release: testRelease("v1.2.3", "source_v1.2.3.zip", "source_v1.2.3.zip.sha256", "source_v1.2.3.zip.sha256.sig"), wantErr: "zipファイルが見つかりません"},
+		{name: "他のzipより後にある", release: testRelease("v1.2.3", "print_pdf_v1.2.30.zip", "print_pdf_v1.2.3-debug.zip", "print_pdf_v1.2.3.zip", "print_pdf_v1.2.3.zip.sha256", "print_pdf_v1.2.3.zip.sha256.sig")},
+		{name: "チェックサムなし", release: testRelease("v1.2.3", "print_pdf_v1.2.3.zip", "print_pdf_v1.2.3.zip.sha256.sig"), wantErr: "チェックサムファイルが見つかりません"},
+		{name: "署名なし", release: testRelease("v1.2.3", "print_pdf_v1.2.3.zip", "print_pdf_v1.2.3.zip.sha256"), wantErr: "署名ファイルが見つかりません"},
+		{name: "別のzipのチェックサムは使わない", release: testRelease("v1.2.3", "print_pdf_v1.2.3.zip", "other.zip.sha256", "other.zip.sha256.sig"), wantErr: "チェックサムファイルが見つかりません"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assets, err := findUpdateAssets(tt.release)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if assets.Archive.Name != "print_pdf_v1.2.3.zip" || assets.Checksum.Name == "" || assets.Signature.Name == "" {
+					t.Errorf("assets = %+v", assets)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDownloadVerifiedUpdate(t *testing.T) {
+	const name = "print_pdf_v1.2.3.zip"
+	content := []byte("PK\x03\x04 release content")
+	signed := newSignedRelease(t, name, content)
+
+	tests := []struct {
+		name    string
+		archive []byte
+		status  int
+		wantErr bool
+	}{
+		{name: "正常", archive: content, status: http.StatusOK},
+		{name: "改ざんされたzip", archive: []byte("tampered"), status: http.StatusOK, wantErr: true},
+		{name: "ダウンロード失敗", archive: content, status: http.StatusNotFound, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/" + name:
+					w.WriteHeader(tt.status)
+					w.Write(tt.archive)
+				case "/" + name + checksumSuffix:
+					w.Write(signed.checksums)
+				case "/" + name + signatureSuffix:
+					w.Write(signed.signature)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			release := Release{Version: "v1.2.3"}
+			for _, asset := range []string{name, name + checksumSuffix, name + signatureSuffix} {
+				release.Assets = append(release.Assets, ReleaseAsset{Name: asset, URL: server.URL + "/" + asset})
+			}
+			source := &manifestSource{client: server.Client(), url: server.URL}
+			stubPublicKey(t, signed.publicKey)
+
+			path, err := downloadVerifiedUpdate(context.Background(), source, release, t.TempDir())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				if data, _ := os.ReadFile(path); string(data) != string(content) {
+					t.Errorf("downloaded content mismatch")
+				}
+			}
+		})
+	}
+}
+
+// stubPublicKey - テスト中だけ埋め込み公開鍵を差し替える
+func stubPublicKey(t *testing.T, key string) {
+	t.Helper()
+	original := UpdatePublicKey
+	UpdatePublicKey = key
+	t.Cleanup(func() { UpdatePublicKey = original })
+}
+
+func TestBuildUpdateScript(t *testing.T) {
+	script, err := buildUpdateScript(updateScriptTemplate, updateScriptParams{
+		CurrentExe:      `C:\svc\print_pdf.exe`,
+		NewExe:          `C:\svc\print_pdf.exe.new`,
+		BackupExe:       `C:\svc\print_pdf.exe.backup`,
+		OldExe:          `C:\svc\print_pdf.exe.old`,
+		FailedExe:       `C:\svc\print_pdf.exe.failed`,
+		StartCommand:    `sc start "PDF Generator API Service"`,
+		StopCommand:     `sc stop "PDF Generator API Service"`,
+		HealthURL:       "http://127.0.0.1:8081/v1/health",
+		ExpectedVersion: "v1.2.3",
+		HealthAttempts:  30,
+		ProcessID:       4242,
+		WaitExitSeconds: 150,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`tasklist /FI "PID eq 4242" /NH 2>nul | find " 4242 "`,
+		`if %WAITED% geq 150 (`,
+		`move /Y "C:\svc\print_pdf.exe.new" "C:\svc\print_pdf.exe"`,
+		`move /Y "C:\svc\print_pdf.exe.backup" "C:\svc\print_pdf.exe.old"`,
+		`Invoke-RestMethod -Uri 'http://127.0.0.1:8081/v1/health'`,
+		`$r.version -eq 'v1.2.3'`,
+		`if %TRIES% lss 30 goto healthcheck`,
+		`sc stop "PDF Generator API Service"`,
+		`move /Y "C:\svc\print_pdf.exe" "C:\svc\print_pdf.exe.failed"`,
+		`copy /Y "C:\svc\print_pdf.exe.old" "C:\svc\print_pdf.exe"`,
+		`del "%~f0"`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script does not contain %q", want)
+		}
+	}
+	if strings.Contains(strings.ReplaceAll(script, "\r\n", ""), "\n") {
+		t.Error("script must use CRLF line endings")
+	}
+	// 旧プロセスの終了を待ってから置換する
+	if strings.Index(script, ":exited") > strings.Index(script, "move /Y") {
+		t.Error("executable must be replaced only after the old process exited")
+	}
+	// ロールバックはヘルスチェック失敗時のみ
+	if strings.Index(script, ":healthy") < strings.Index(script, "rolling back") {
+		t.Error("rollback must come before the :healthy label")
+	}
+}
+
+func TestBuildRollbackScript(t *testing.T) {
+	script, err := buildUpdateScript(rollbackScriptTemplate, updateScriptParams{
+		CurrentExe:      `C:\svc\print_pdf.exe`,
+		OldExe:          `C:\svc\print_pdf.exe.old`,
+		FailedExe:       `C:\svc\print_pdf.exe.rolledback`,
+		StartCommand:    `sc start "PDF Generator API Service"`,
+		ProcessID:       4242,
+		WaitExitSeconds: 150,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`tasklist /FI "PID eq 4242" /NH 2>nul | find " 4242 "`,
+		`move /Y "C:\svc\print_pdf.exe" "C:\svc\print_pdf.exe.rolledback"`,
+		`copy /Y "C:\svc\print_pdf.exe.old" "C:\svc\print_pdf.exe"`,
+		`sc start "PDF Generator API Service"`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script does not contain %q", want)
+		}
+	}
+	if strings.Index(script, ":exited") > strings.Index(script, "move /Y") {
+		t.Error("executable must be replaced only after the old process exited")
+	}
+}
+
+func TestHealthCheckAttempts(t *testing.T) {
+	tests := []struct {
+		timeout int
+		want    int
+	}{
+		{0, 1},
+		{1, 1},
+		{2, 1},
+		{60, 30},
+		{61, 30},
+	}
+	for _, tt := range tests {
+		if got := healthCheckAttempts(tt.timeout); got != tt.want {
+			t.Errorf("healthCheckAttempts(%d) = %d, want %d", tt.timeout, got, tt.want)
+		}
+	}
+}
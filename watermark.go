@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// 透かし（下書き・再発行・控え）
+// 確認用に印刷した用紙を正式な用紙と見分けられるよう、リクエストで指定した文字を全ページに斜めに半透明で重ねる
+
+// 透かしの種類（PrintRequest.watermark.type）
+const (
+	WatermarkDraft   = "draft"   // 下書き
+	WatermarkReissue = "reissue" // 再発行（再発行日を添える）
+	WatermarkCopy    = "copy"    // 控え
+)
+
+// watermarkLabels - 種類ごとの既定の文字（改行で複数行）
+var watermarkLabels = map[string]string{
+	WatermarkDraft:   "下書き\nDRAFT",
+	WatermarkReissue: "再発行",
+	WatermarkCopy:    "控え\nCOPY",
+}
+
+// Watermark - リクエストで指定する透かし
+type Watermark struct {
+	Type string  `json:"type"` // draft, reissue, copy
+	Text *string `json:"text"` // 透かしの文字（省略時は種類ごとの既定。改行で複数行）
+	Date *string `json:"date"` // 再発行日（reissue のみ。省略時は当日）
+}
+
+// RenderOptions - 帳票の内容以外の描画オプション
+type RenderOptions struct {
+	Watermark *Watermark // 全ページに重ねる透かし（nil は透かしなし）
+}
+
+// renderOptions - リクエストの描画オプション
+func (r PrintRequest) renderOptions() RenderOptions {
+	return RenderOptions{Watermark: r.Watermark}
+}
+
+// WatermarkConfig - 透かしの見た目
+type WatermarkConfig struct {
+	Font      string  `json:"font"`      // フォントファイル（TTF。空の場合は帳票の日本語フォント）
+	FontSize  float64 `json:"fontSize"`  // 最大の文字サイズ（pt。長い文字は縮小）
+	Opacity   float64 `json:"opacity"`   // 不透明度（0〜1）
+	Angle     float64 `json:"angle"`     // 傾き（度。反時計回り）
+	Color     [3]int  `json:"color"`     // 文字の色（RGB）
+	DateStyle string  `json:"dateStyle"` // 再発行日の書式
+}
+
+// watermarkFontFamily - 設定したフォントを登録する名前
+const watermarkFontFamily = "watermark"
+
+// watermarkBox - 透かしを収める枠（用紙の中央。傾けても用紙からはみ出さない大きさ）
+var watermarkBox = fitBox{X: 20, Y: 39, W: 170, H: 70}
+
+// validate - 設定を確認
+func (w WatermarkConfig) validate() error {
+	if w.Opacity < 0 || w.Opacity > 1 {
+		return fmt.Errorf("透かしの不透明度は0〜1で指定してください: %v", w.Opacity)
+	}
+	if w.FontSize <= 0 {
+		return fmt.Errorf("透かしの文字サイズは0より大きい値を指定してください: %v", w.FontSize)
+	}
+	for _, c := range w.Color {
+		if c < 0 || c > 255 {
+			return fmt.Errorf("透かしの色は0〜255で指定してください: %v", w.Color)
+		}
+	}
+	if err := validateDateStyle(w.DateStyle); err != nil {
+		return fmt.Errorf("透かしの日付: %v", err)
+	}
+	if w.Font != "" {
+		if _, err := os.Stat(w.Font); err != nil {
+			return fmt.Errorf("透かしのフォントが見つかりません: %v", err)
+		}
+		if err := addWatermarkFont(gofpdf.New("L", "mm", "A5", ""), w.Font); err != nil {
+			return fmt.Errorf("透かしのフォントを読み込めません: %v", err)
+		}
+	}
+	return nil
+}
+
+// validateWatermark - リクエストの透かしを検証
+func validateWatermark(w *Watermark) []FieldError {
+	if w == nil {
+		return nil
+	}
+	var errs []FieldError
+	if _, ok := watermarkLabels[w.Type]; !ok {
+		errs = append(errs, FieldError{Field: "watermark.type", Message: fmt.Sprintf("must be one of %s, %s or %s", WatermarkDraft, WatermarkReissue, WatermarkCopy)})
+	}
+	if w.Text != nil && strings.TrimSpace(*w.Text) == "" {
+		errs = append(errs, FieldError{Field: "watermark.text", Message: "must not be blank when specified"})
+	}
+	if w.Date != nil {
+		switch {
+		case w.Type != WatermarkReissue:
+			errs = append(errs, FieldError{Field: "watermark.date", Message: "is only allowed for reissue"})
+		default:
+			if _, err := parseItemDate(*w.Date); err != nil {
+				errs = append(errs, FieldError{Field: "watermark.date", Message: err.Error()})
+			}
+		}
+	}
+	return errs
+}
+
+// watermarkText - 透かしの文字（再発行は再発行日を次の行に添える。now は日付省略時の当日）
+func watermarkText(w Watermark, dateStyle string, now time.Time) string {
+	text := watermarkLabels[w.Type]
+	if w.Text != nil && strings.TrimSpace(*w.Text) != "" {
+		text = strings.TrimSpace(*w.Text)
+	}
+	if w.Type != WatermarkReissue {
+		return text
+	}
+	date := now.In(businessLocation())
+	if w.Date != nil {
+		if t, err := parseItemDate(*w.Date); err == nil {
+			date = t
+		}
+	}
+	return text + "\n" + formatDate(date, dateStyle)
+}
+
+// addWatermarkFont - フォントファイルを透かしのフォントとして登録
+// 読み込めない場合は pdf のエラーを消して返す（gofpdf は壊れたTTFで panic することがあるため回復する）
+func addWatermarkFont(pdf *gofpdf.Fpdf, font string) (err error) {
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("TTFを解析できません: %v", r)
+		}
+		if err == nil {
+			err = pdf.Error()
+		}
+		if err != nil {
+			pdf.ClearError()
+		}
+	}()
+	pdf.SetFontLocation(filepath.Dir(font))
+	pdf.AddUTF8Font(watermarkFontFamily, "", filepath.Base(font))
+	return nil
+}
+
+// watermarkFont - 透かしのフォント名（設定したフォントを初回に登録。読み込めない場合は帳票のフォント）
+func (c *ReportLabStylePdfClient) watermarkFont(cfg WatermarkConfig) string {
+	if cfg.Font == "" {
+		return "yumin"
+	}
+	if c.pdf.GetFontDesc(watermarkFontFamily, "").Ascent != 0 {
+		return watermarkFontFamily
+	}
+	if err := addWatermarkFont(c.pdf, cfg.Font); err != nil {
+		writeEventLog("WARN", fmt.Sprintf("透かしのフォントを読み込めないため帳票のフォントを使います: %v", err))
+		return "yumin"
+	}
+	return watermarkFontFamily
+}
+
+// drawWatermark - 描画済みのページに透かしを重ねる（用紙の中央を中心に傾け、半透明で描画）
+func (c *ReportLabStylePdfClient) drawWatermark(w Watermark) {
+	cfg := currentConfig().PDF.Watermark
+	text := watermarkText(w, cfg.DateStyle, time.Now())
+	box := watermarkBox
+
+	c.pdf.SetFont(c.watermarkFont(cfg), "", cfg.FontSize)
+	c.pdf.SetTextColor(cfg.Color[0], cfg.Color[1], cfg.Color[2])
+	c.pdf.SetAlpha(cfg.Opacity, "Normal")
+	c.pdf.TransformBegin()
+	c.pdf.TransformRotate(cfg.Angle, box.X+box.W/2, box.Y+box.H/2)
+	c.fitText(box, text, fitStyle{Align: alignCenter, MinSize: cfg.FontSize / 4, MaxSize: cfg.FontSize}, "透かし")
+	c.pdf.TransformEnd()
+	c.pdf.SetAlpha(1, "Normal")
+	c.pdf.SetTextColor(0, 0, 0)
+	c.pdf.SetFont("yumin", "", 10)
+}
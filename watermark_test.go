@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+func TestValidateWatermark(t *testing.T) {
+	tests := []struct {
+		name string
+		w    *Watermark
+		want []string // エラーのフィールド
+	}{
+		{name: "指定なし", w: nil},
+		{name: "下書き", w: &Watermark{Type: WatermarkDraft}},
+		{name: "再発行日あり", w: &Watermark{Type: WatermarkReissue, Date: StringPtr("2025-02-01")}},
+		{name: "文字の置き換え", w: &Watermark{Type: WatermarkCopy, Text: StringPtr("社内控え")}},
+		{name: "不明な種類", w: &Watermark{Type: "void"}, want: []string{"watermark.type"}},
+		{name: "空白の文字", w: &Watermark{Type: WatermarkDraft, Text: StringPtr("　")}, want: []string{"watermark.text"}},
+		{name: "再発行以外の日付", w: &Watermark{Type: WatermarkCopy, Date: StringPtr("2025-02-01")}, want: []string{"watermark.date"}},
+		{name: "不正な日付", w: &Watermark{Type: WatermarkReissue, Date: StringPtr("2025-02-30")}, want: []string{"watermark.date"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validateWatermark(tt.w)
+			var fields []string
+			for _, e := range errs {
+				fields = append(fields, e.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("fields = %v, want %v", fields, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePrintRequestWatermark(t *testing.T) {
+	req := PrintRequest{Items: []Item{{Name: "山田"}}, Watermark: &Watermark{Type: "final"}}
+	errs := validatePrintRequest(req)
+	if len(errs) != 1 || errs[0].Field != "watermark.type" {
+		t.Errorf("errors = %v, want watermark.type", errs)
+	}
+}
+
+func TestWatermarkText(t *testing.T) {
+	// 当日は業務のタイムゾーンの日付（UTCでは前日）
+	now := time.Date(2025, 3, 9, 16, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		w     Watermark
+		style string
+		want  string
+	}{
+		{name: "下書き", w: Watermark{Type: WatermarkDraft}, want: "下書き\nDRAFT"},
+		{name: "控え", w: Watermark{Type: WatermarkCopy}, want: "控え\nCOPY"},
+		{name: "再発行日", w: Watermark{Type: WatermarkReissue, Date: StringPtr("2025/2/1")}, style: DateStyleYMD, want: "再発行\n2025年 02月 01日"},
+		{name: "再発行日の省略", w: Watermark{Type: WatermarkReissue}, style: DateStyleWareki, want: "再発行\n令和7年3月10日"},
+		{name: "文字の置き換え", w: Watermark{Type: WatermarkCopy, Text: StringPtr(" 経理控え ")}, want: "経理控え"},
+		{name: "再発行の文字の置き換え", w: Watermark{Type: WatermarkReissue, Text: StringPtr("REISSUED"), Date: StringPtr("2025-02-01")}, style: DateStyleISO, want: "REISSUED\n2025-02-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := watermarkText(tt.w, tt.style, now); got != tt.want {
+				t.Errorf("watermarkText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// installedJapaneseFont - 帳票の日本語フォントのうちインストールされているもの（ない場合はテストを省略）
+func installedJapaneseFont(t *testing.T) string {
+	t.Helper()
+	for _, font := range japaneseFonts {
+		if _, err := os.Stat(font.path); err == nil {
+			return font.path
+		}
+	}
+	t.Skip("日本語フォントがありません")
+	return ""
+}
+
+// writeInvalidTTF - TTFとして読み込めないフォントファイルを作成
+func writeInvalidTTF(t *testing.T) string {
+	t.Helper()
+	font := filepath.Join(t.TempDir(), "watermark.ttf")
+	if err := os.WriteFile(font, []byte("dummy"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return font
+}
+
+func TestLoadConfigWatermark(t *testing.T) {
+	font := installedJapaneseFont(t)
+	fontJSON := strings.ReplaceAll(font, `\`, `\\`)
+	invalidJSON := strings.ReplaceAll(writeInvalidTTF(t), `\`, `\\`)
+
+	tests := []struct {
+		name    string
+		config  string
+		want    WatermarkConfig
+		wantErr string
+	}{
+		{name: "既定値", config: `{}`, want: defaultConfig().PDF.Watermark},
+		{
+			name:   "変更",
+			config: `{"pdf":{"watermark":{"font":"` + fontJSON + `","opacity":0.3,"angle":-45,"color":[200,0,0],"dateStyle":"wareki"}}}`,
+			want:   WatermarkConfig{Font: font, FontSize: 72, Opacity: 0.3, Angle: -45, Color: [3]int{200, 0, 0}, DateStyle: DateStyleWareki},
+		},
+		{name: "不透明度が範囲外", config: `{"pdf":{"watermark":{"opacity":1.5}}}`, wantErr: "不透明度"},
+		{name: "文字サイズが0", config: `{"pdf":{"watermark":{"fontSize":0}}}`, wantErr: "文字サイズ"},
+		{name: "色が範囲外", config: `{"pdf":{"watermark":{"color":[0,0,256]}}}`, wantErr: "色"},
+		{name: "不明な日付の書式", config: `{"pdf":{"watermark":{"dateStyle":"us"}}}`, wantErr: "日付の書式"},
+		{name: "フォントがない", config: `{"pdf":{"watermark":{"font":"missing.ttf"}}}`, wantErr: "フォント"},
+		{name: "TTFでないフォント", config: `{"pdf":{"watermark":{"font":"` + invalidJSON + `"}}}`, wantErr: "フォントを読み込めません"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), configFileName)
+			if err := os.WriteFile(path, []byte(tt.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := loadConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.PDF.Watermark != tt.want {
+				t.Errorf("watermark = %+v, want %+v", cfg.PDF.Watermark, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderPDFWithWatermark(t *testing.T) {
+	original := currentConfig()
+	t.Cleanup(func() { setConfig(original) })
+
+	items := []Item{{Name: "山田　太郎"}, {Name: "佐藤　花子"}}
+	render := func(opts RenderOptions) []byte {
+		t.Helper()
+		var buf bytes.Buffer
+		pages, err := RenderPDFWithOptions(&buf, "", items, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pages != len(items) {
+			t.Errorf("pages = %d, want %d", pages, len(items))
+		}
+		return buf.Bytes()
+	}
+
+	cfg := defaultConfig()
+	cfg.PDF.Watermark.Opacity = 0.25
+	setConfig(cfg)
+
+	// 透かしなしでは半透明のグラフィックス状態を使わない
+	if out := render(RenderOptions{}); bytes.Contains(out, []byte("/ca 0.25")) {
+		t.Error("PDF without watermark uses transparency")
+	}
+	for _, w := range []Watermark{
+		{Type: WatermarkDraft},
+		{Type: WatermarkReissue, Date: StringPtr("2025-02-01")},
+		{Type: WatermarkCopy, Text: StringPtr(strings.Repeat("控え", 40))}, // 長い文字は縮小・省略
+	} {
+		if out := render(RenderOptions{Watermark: &w}); !bytes.Contains(out, []byte("/ca 0.25")) {
+			t.Errorf("%s: PDF has no translucent watermark", w.Type)
+		}
+	}
+
+	// 読み込めないフォントは帳票のフォントで描画する（設定の確認後に置き換えられた場合も帳票全体を失敗にしない）
+	for _, font := range []string{filepath.Join(t.TempDir(), "missing.ttf"), writeInvalidTTF(t)} {
+		cfg.PDF.Watermark.Font = font
+		setConfig(cfg)
+		if out := render(RenderOptions{Watermark: &Watermark{Type: WatermarkDraft}}); !bytes.Contains(out, []byte("/ca 0.25")) {
+			t.Errorf("%s: PDF has no translucent watermark", filepath.Base(font))
+		}
+	}
+}
+
+func TestAddWatermarkFont(t *testing.T) {
+	pdf := gofpdf.New("L", "mm", "A5", "")
+	if err := addWatermarkFont(pdf, writeInvalidTTF(t)); err == nil {
+		t.Error("invalid TTF was accepted")
+	}
+	if err := pdf.Error(); err != nil {
+		t.Errorf("pdf error was not cleared: %v", err)
+	}
+	if err := addWatermarkFont(pdf, installedJapaneseFont(t)); err != nil {
+		t.Errorf("addWatermarkFont() = %v", err)
+	}
+	if pdf.GetFontDesc(watermarkFontFamily, "").Ascent == 0 {
+		t.Error("watermark font was not registered")
+	}
+}